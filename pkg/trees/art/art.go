@@ -0,0 +1,370 @@
+package art
+
+import (
+	"strings"
+)
+
+// Tree implements an adaptive radix tree (ART). Like radix.Tree it is a
+// compressed prefix trie with sorted, prefix-based lookups and ordered
+// iteration, and it has the same API. The difference is in the layout
+// of the nodes: instead of a sorted slice of edges, every node picks one
+// of four layouts (holding up to 4, 16, 48 or 256 children) depending on
+// how many children it has, and grows or shrinks as they come and go. A
+// child is found with a short scan in the small nodes and a direct index
+// in the large ones, which keeps lookups fast for dense keyspaces without
+// wasting memory on the sparse parts of the tree. The type parameter V is
+// the type of the values stored in the tree.
+//
+// A Tree is not safe for concurrent use.
+type Tree[V any] struct {
+	root *node[V]
+	size int
+}
+
+// NewTree returns a new pointer to an empty Tree (adaptive radix tree)
+func NewTree[V any]() *Tree[V] {
+	return &Tree[V]{
+		root: new(node[V]),
+		size: 0,
+	}
+}
+
+// longestPrefix finds the (longest) length of a shared
+// prefix of the two strings provided
+func longestPrefix(k1, k2 string) int {
+	max := len(k1)
+	if l := len(k2); l < max {
+		max = l
+	}
+	var i int
+	for i = 0; i < max; i++ {
+		if k1[i] != k2[i] {
+			break
+		}
+	}
+	return i
+}
+
+// Insert is used to add a new entry or update an existing entry. It
+// returns the previous value and a boolean indicating true if the
+// entry was updated.
+func (t *Tree[V]) Insert(k string, v V) (V, bool) {
+	n := t.root
+	search := k
+	for {
+		// Split the node if the key leaves the prefix part way through
+		if common := longestPrefix(search, n.prefix); common < len(n.prefix) {
+			n.split(common)
+		}
+		search = search[len(n.prefix):]
+
+		// Handle key exhaustion
+		if len(search) == 0 {
+			if n.leaf != nil {
+				// update old value
+				old := n.leaf.val
+				n.leaf.val = v
+				return old, true
+			}
+			n.leaf = &leaf[V]{key: k, val: v}
+			t.size++
+			return *new(V), false
+		}
+
+		// Look for a child, or create a new one
+		c := n.findChild(search[0])
+		if c == nil {
+			n.addChild(
+				search[0], &node[V]{
+					prefix: search[1:],
+					leaf:   &leaf[V]{key: k, val: v},
+				},
+			)
+			t.size++
+			return *new(V), false
+		}
+		n = c
+		search = search[1:]
+	}
+}
+
+// Delete is used to delete a key. It will return the previous
+// value and a boolean indicating true if it was deleted.
+func (t *Tree[V]) Delete(k string) (V, bool) {
+	var parent *node[V]
+	var label byte
+	n := t.root
+	search := k
+	for {
+		// Consume the search prefix
+		if !strings.HasPrefix(search, n.prefix) {
+			return *new(V), false
+		}
+		search = search[len(n.prefix):]
+		if len(search) == 0 {
+			break
+		}
+
+		// Look for a child
+		c := n.findChild(search[0])
+		if c == nil {
+			return *new(V), false
+		}
+		parent, label, n = n, search[0], c
+		search = search[1:]
+	}
+	if n.leaf == nil {
+		return *new(V), false
+	}
+
+	// Delete the leaf
+	l := n.leaf
+	n.leaf = nil
+	t.size--
+	if n == t.root {
+		return l.val, true
+	}
+
+	// Remove the node if it is empty, and then merge whichever node
+	// is left with a single child, so the path stays compressed.
+	if n.num == 0 {
+		parent.removeChild(label)
+		n = parent
+	}
+	if n != t.root && n.leaf == nil && n.num == 1 {
+		n.mergeChild()
+	}
+	return l.val, true
+}
+
+// DeletePrefix is used to remove the subtree under a given prefix. It
+// returns the number of entries that were deleted. This method can be
+// used to remove a large subtree efficiently.
+func (t *Tree[V]) DeletePrefix(prefix string) int {
+	parent, label, n := t.seekPrefix(prefix)
+	if n == nil {
+		return 0
+	}
+	num := count(n)
+	t.size -= num
+	if n == t.root {
+		t.root = new(node[V])
+		return num
+	}
+	parent.removeChild(label)
+	if parent != t.root && parent.leaf == nil && parent.num == 1 {
+		parent.mergeChild()
+	}
+	return num
+}
+
+// seekPrefix returns the node holding every key that begins with the
+// provided prefix, along with its parent and the label of the node in the
+// parent. It returns a nil node if there are no keys with the prefix.
+func (t *Tree[V]) seekPrefix(prefix string) (*node[V], byte, *node[V]) {
+	var parent *node[V]
+	var label byte
+	n := t.root
+	search := prefix
+	for {
+		// The node is under our search prefix
+		if strings.HasPrefix(n.prefix, search) {
+			return parent, label, n
+		}
+
+		// Consume the search prefix
+		if !strings.HasPrefix(search, n.prefix) {
+			return nil, 0, nil
+		}
+		search = search[len(n.prefix):]
+
+		// Look for a child
+		c := n.findChild(search[0])
+		if c == nil {
+			return nil, 0, nil
+		}
+		parent, label, n = n, search[0], c
+		search = search[1:]
+	}
+}
+
+// count returns the number of entries in the subtree at n
+func count[V any](n *node[V]) int {
+	num := 0
+	recursiveWalk(
+		n, func(string, V) bool {
+			num++
+			return false
+		},
+	)
+	return num
+}
+
+// recursiveWalk walks the tree recursively from node n, using the WalkFn fn.
+func recursiveWalk[V any](n *node[V], fn WalkFn[V]) bool {
+	// Visit the leaf values, if there are any
+	if n.leaf != nil && fn(n.leaf.key, n.leaf.val) {
+		return true
+	}
+	// Recurse on the children
+	return n.each(
+		func(_ byte, c *node[V]) bool {
+			return recursiveWalk(c, fn)
+		},
+	)
+}
+
+// Find is used to look up a specific key, returning the
+// associated value a boolean indicating true if it was found.
+func (t *Tree[V]) Find(k string) (V, bool) {
+	n := t.root
+	search := k
+	for {
+		// Consume the search prefix
+		if !(len(search) >= len(n.prefix) && search[0:len(n.prefix)] == n.prefix) {
+			// inlined version of !strings.HasPrefix(search, n.prefix)
+			break
+		}
+		search = search[len(n.prefix):]
+
+		// Check for key exhaustion
+		if len(search) == 0 {
+			if n.leaf != nil {
+				return n.leaf.val, true
+			}
+			break
+		}
+
+		// Look for a child
+		n = n.findChild(search[0])
+		if n == nil {
+			break
+		}
+		search = search[1:]
+	}
+	return *new(V), false
+}
+
+// FindLongestPrefix is very much like Find, but instead looking
+// for an exact match, it attempts to locate the longest prefix
+// match. Upon success, it will return the last matched key, value
+// and a boolean indicating true, otherwise "", the zero value and false.
+func (t *Tree[V]) FindLongestPrefix(k string) (string, V, bool) {
+	var last *leaf[V]
+	n := t.root
+	search := k
+	for {
+		// Consume the search prefix
+		if !strings.HasPrefix(search, n.prefix) {
+			break
+		}
+		search = search[len(n.prefix):]
+
+		// Look for a leaf node
+		if n.leaf != nil {
+			last = n.leaf
+		}
+
+		// Check for key exhaustion
+		if len(search) == 0 {
+			break
+		}
+
+		// Look for a child
+		n = n.findChild(search[0])
+		if n == nil {
+			break
+		}
+		search = search[1:]
+	}
+	if last != nil {
+		return last.key, last.val, true
+	}
+	return "", *new(V), false
+}
+
+// Len returns the number of elements in the tree.
+func (t *Tree[V]) Len() int {
+	return t.size
+}
+
+// Min returns the minimum key, and value in the tree.
+func (t *Tree[V]) Min() (string, V, bool) {
+	n := t.root
+	for {
+		if n.leaf != nil {
+			return n.leaf.key, n.leaf.val, true
+		}
+		if n.num == 0 {
+			break
+		}
+		n = n.minChild()
+	}
+	return "", *new(V), false
+}
+
+// Max returns the maximum key, and value in the tree.
+func (t *Tree[V]) Max() (string, V, bool) {
+	n := t.root
+	for {
+		if n.num > 0 {
+			n = n.maxChild()
+			continue
+		}
+		if n.leaf != nil {
+			return n.leaf.key, n.leaf.val, true
+		}
+		break
+	}
+	return "", *new(V), false
+}
+
+// WalkFn is the type of the function called for each key and value visited
+// by the walk functions. Returning true stops the walk.
+type WalkFn[V any] func(s string, v V) bool
+
+// Walk recursively walks the tree using the WalkFn fn.
+func (t *Tree[V]) Walk(fn WalkFn[V]) {
+	recursiveWalk(t.root, fn)
+}
+
+// WalkPrefix recursively walks the tree using the supplied WalkFn fn, under
+// a specific prefix supplied by the prefix string.
+func (t *Tree[V]) WalkPrefix(prefix string, fn WalkFn[V]) {
+	if _, _, n := t.seekPrefix(prefix); n != nil {
+		recursiveWalk(n, fn)
+	}
+}
+
+// WalkPath recursively walks the tree using the supplied WalkFn fn, under
+// a specific path supplied by the path string. It is like WalkPrefix, but
+// instead of visiting all the entries under a given prefix, this walks the
+// entries above the path.
+func (t *Tree[V]) WalkPath(path string, fn WalkFn[V]) {
+	n := t.root
+	search := path
+	for {
+		// Consume the search prefix
+		if !strings.HasPrefix(search, n.prefix) {
+			return
+		}
+		search = search[len(n.prefix):]
+
+		// Visit the leaf values, if there are any.
+		if n.leaf != nil && fn(n.leaf.key, n.leaf.val) {
+			return
+		}
+
+		// Check for key exhaustion
+		if len(search) == 0 {
+			return
+		}
+
+		// Look for a child
+		n = n.findChild(search[0])
+		if n == nil {
+			return
+		}
+		search = search[1:]
+	}
+}
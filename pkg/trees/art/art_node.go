@@ -0,0 +1,321 @@
+package art
+
+type nodeKind uint8
+
+const (
+	node4 nodeKind = iota
+	node16
+	node48
+	node256
+)
+
+// The number of children each kind of node can hold, and the number
+// of children at which a node is shrunk down to the next smaller kind.
+// The shrink thresholds leave some room in the smaller node, so a node
+// does not flip back and forth when children are added and removed
+// right at the boundary.
+const (
+	max4   = 4
+	max16  = 16
+	max48  = 48
+	min16  = 3
+	min48  = 12
+	min256 = 37
+)
+
+type leaf[V any] struct {
+	key string
+	val V
+}
+
+// node is a single node of an adaptive radix tree. A child is reached
+// from its parent through a single label byte, and the prefix holds the
+// (compressed) path of bytes that follow the label. The layout of keys
+// and children depends on the kind of node:
+//
+//	node4, node16: keys[:num] holds the sorted labels, and children[i]
+//	               is the child for keys[i]. The labels are kept inline,
+//	               so a lookup only has to load the child it finds. The
+//	               children are nil for a node that has never had a
+//	               child (most leaves.)
+//	node48:        index is indexed by label and holds the position of
+//	               the child in children plus one, or zero if the label
+//	               has no child.
+//	node256:       children is indexed by label.
+//
+// A node holding a leaf may have children as well, which is how a key
+// that is a prefix of another key is stored.
+type node[V any] struct {
+	kind     nodeKind
+	num      int
+	prefix   string
+	leaf     *leaf[V]
+	keys     [max16]byte
+	index    *[256]byte
+	children []*node[V]
+}
+
+// lowerBound returns the position of the first label in a node4 or
+// node16 that is greater than or equal to the provided label
+func (n *node[V]) lowerBound(label byte) int {
+	if n.kind == node4 {
+		i := 0
+		for i < n.num && n.keys[i] < label {
+			i++
+		}
+		return i
+	}
+	lo, hi := 0, n.num
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if n.keys[mid] < label {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// findChild returns the child for the label, or nil if there is none
+func (n *node[V]) findChild(label byte) *node[V] {
+	switch n.kind {
+	case node4, node16:
+		if i := n.lowerBound(label); i < n.num && n.keys[i] == label {
+			return n.children[i]
+		}
+	case node48:
+		if s := n.index[label]; s != 0 {
+			return n.children[s-1]
+		}
+	case node256:
+		return n.children[label]
+	}
+	return nil
+}
+
+// addChild adds a child for a label that does not have one yet,
+// growing the node to the next larger kind if it is full.
+func (n *node[V]) addChild(label byte, c *node[V]) {
+	switch n.kind {
+	case node4, node16:
+		if n.children == nil {
+			n.children = make([]*node[V], max4)
+		}
+		if n.num == len(n.children) {
+			n.grow()
+			n.addChild(label, c)
+			return
+		}
+		i := n.lowerBound(label)
+		copy(n.keys[i+1:n.num+1], n.keys[i:n.num])
+		copy(n.children[i+1:n.num+1], n.children[i:n.num])
+		n.keys[i] = label
+		n.children[i] = c
+	case node48:
+		if n.num == max48 {
+			n.grow()
+			n.addChild(label, c)
+			return
+		}
+		s := 0
+		for n.children[s] != nil {
+			s++
+		}
+		n.children[s] = c
+		n.index[label] = byte(s + 1)
+	case node256:
+		n.children[label] = c
+	}
+	n.num++
+}
+
+// removeChild removes the child for the label, shrinking the node
+// to the next smaller kind if it has become sparse enough.
+func (n *node[V]) removeChild(label byte) {
+	switch n.kind {
+	case node4, node16:
+		i := n.lowerBound(label)
+		if i == n.num || n.keys[i] != label {
+			return
+		}
+		copy(n.keys[i:], n.keys[i+1:n.num])
+		copy(n.children[i:], n.children[i+1:n.num])
+		n.children[n.num-1] = nil
+		n.num--
+		if n.kind == node16 && n.num == min16 {
+			n.shrink()
+		}
+	case node48:
+		s := n.index[label]
+		if s == 0 {
+			return
+		}
+		n.children[s-1] = nil
+		n.index[label] = 0
+		n.num--
+		if n.num == min48 {
+			n.shrink()
+		}
+	case node256:
+		if n.children[label] == nil {
+			return
+		}
+		n.children[label] = nil
+		n.num--
+		if n.num == min256 {
+			n.shrink()
+		}
+	}
+}
+
+// grow turns a full node into the next larger kind of node
+func (n *node[V]) grow() {
+	switch n.kind {
+	case node4:
+		children := make([]*node[V], max16)
+		copy(children, n.children)
+		n.kind, n.children = node16, children
+	case node16:
+		index := new([256]byte)
+		children := make([]*node[V], max48)
+		for i := 0; i < n.num; i++ {
+			index[n.keys[i]] = byte(i + 1)
+			children[i] = n.children[i]
+		}
+		n.kind, n.index, n.children = node48, index, children
+	case node48:
+		children := make([]*node[V], 256)
+		for label, s := range n.index {
+			if s != 0 {
+				children[label] = n.children[s-1]
+			}
+		}
+		n.kind, n.index, n.children = node256, nil, children
+	}
+}
+
+// shrink turns a sparse node into the next smaller kind of node
+func (n *node[V]) shrink() {
+	var keys [max16]byte
+	var index *[256]byte
+	var children []*node[V]
+	var kind nodeKind
+	switch n.kind {
+	case node16:
+		kind = node4
+		children = make([]*node[V], max4)
+	case node48:
+		kind = node16
+		children = make([]*node[V], max16)
+	case node256:
+		kind = node48
+		index = new([256]byte)
+		children = make([]*node[V], max48)
+	default:
+		return
+	}
+	i := 0
+	n.each(
+		func(label byte, c *node[V]) bool {
+			if index != nil {
+				index[label] = byte(i + 1)
+			} else {
+				keys[i] = label
+			}
+			children[i] = c
+			i++
+			return false
+		},
+	)
+	n.kind, n.keys, n.index, n.children = kind, keys, index, children
+}
+
+// each calls fn for every child of the node in label order, stopping
+// early if fn returns true. It returns true if the walk was stopped.
+func (n *node[V]) each(fn func(label byte, c *node[V]) bool) bool {
+	switch n.kind {
+	case node4, node16:
+		for i := 0; i < n.num; i++ {
+			if fn(n.keys[i], n.children[i]) {
+				return true
+			}
+		}
+	case node48:
+		for label, s := range n.index {
+			if s != 0 && fn(byte(label), n.children[s-1]) {
+				return true
+			}
+		}
+	case node256:
+		for label, c := range n.children {
+			if c != nil && fn(byte(label), c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// minChild returns the child with the lowest label
+func (n *node[V]) minChild() *node[V] {
+	var min *node[V]
+	n.each(
+		func(_ byte, c *node[V]) bool {
+			min = c
+			return true
+		},
+	)
+	return min
+}
+
+// maxChild returns the child with the highest label
+func (n *node[V]) maxChild() *node[V] {
+	switch n.kind {
+	case node4, node16:
+		if n.num > 0 {
+			return n.children[n.num-1]
+		}
+	case node48:
+		for label := 255; label >= 0; label-- {
+			if s := n.index[label]; s != 0 {
+				return n.children[s-1]
+			}
+		}
+	case node256:
+		for label := 255; label >= 0; label-- {
+			if c := n.children[label]; c != nil {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// split breaks the prefix of the node at the provided position. The node
+// keeps the first part of the prefix and everything else is moved into
+// a new child, which is reached through the byte found at the position.
+// The node is split in place, so the parent does not have to be updated.
+func (n *node[V]) split(at int) {
+	c := *n
+	c.prefix = n.prefix[at+1:]
+	label := n.prefix[at]
+	*n = node[V]{prefix: n.prefix[:at]}
+	n.addChild(label, &c)
+}
+
+// mergeChild merges a node without a leaf and with a single child
+// with that child. This is the reverse of split.
+func (n *node[V]) mergeChild() {
+	var label byte
+	var c *node[V]
+	n.each(
+		func(l byte, child *node[V]) bool {
+			label, c = l, child
+			return true
+		},
+	)
+	prefix := n.prefix + string(label) + c.prefix
+	*n = *c
+	n.prefix = prefix
+}
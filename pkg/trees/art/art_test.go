@@ -0,0 +1,405 @@
+package art
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/scottcagno/go-scratch/pkg/trees/radix"
+)
+
+func checkTree[V any](t *testing.T, tree *Tree[V]) {
+	t.Helper()
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func keys[V any](walk func(WalkFn[V])) []string {
+	var out []string
+	walk(
+		func(k string, _ V) bool {
+			out = append(out, k)
+			return false
+		},
+	)
+	return out
+}
+
+func TestTree(t *testing.T) {
+	r := NewTree[int]()
+	in := []string{"", "A", "AB", "ABC", "R", "S", "foo", "foo/bar", "foo/baz", "foobar", "zip", "zipzap"}
+	for i, k := range in {
+		if _, updated := r.Insert(k, i); updated {
+			t.Fatalf("unexpected update of %q", k)
+		}
+	}
+	checkTree(t, r)
+	if r.Len() != len(in) {
+		t.Fatalf("bad length, expected %d, got %d", len(in), r.Len())
+	}
+	for i, k := range in {
+		if v, ok := r.Find(k); !ok || v != i {
+			t.Fatalf("find(%q): expected %d, got %d, %v", k, i, v, ok)
+		}
+	}
+	if _, ok := r.Find("fo"); ok {
+		t.Fatalf("unexpected find")
+	}
+	if old, updated := r.Insert("foo", 100); !updated || old != 6 {
+		t.Fatalf("expected an update of %d, got %d, %v", 6, old, updated)
+	}
+	if k, _, _ := r.Min(); k != "" {
+		t.Fatalf("bad minimum %q", k)
+	}
+	if k, _, _ := r.Max(); k != "zipzap" {
+		t.Fatalf("bad maximum %q", k)
+	}
+	if k, v, ok := r.FindLongestPrefix("foo/bazooka"); !ok || k != "foo/baz" || v != 8 {
+		t.Fatalf("bad longest prefix %q=%d, %v", k, v, ok)
+	}
+	if k, _, _ := r.FindLongestPrefix("fo"); k != "" {
+		t.Fatalf("bad longest prefix %q", k)
+	}
+	if got := keys[int](func(fn WalkFn[int]) { r.WalkPrefix("foo", fn) }); !equal(
+		got, []string{"foo", "foo/bar", "foo/baz", "foobar"},
+	) {
+		t.Fatalf("bad prefix walk %v", got)
+	}
+	if got := keys[int](func(fn WalkFn[int]) { r.WalkPath("foo/bar/baz", fn) }); !equal(
+		got, []string{"", "foo", "foo/bar"},
+	) {
+		t.Fatalf("bad path walk %v", got)
+	}
+	for _, k := range in {
+		if _, ok := r.Delete(k); !ok {
+			t.Fatalf("failed to delete %q", k)
+		}
+		checkTree(t, r)
+	}
+	if r.Len() != 0 || r.root.num != 0 {
+		t.Fatalf("expected an empty tree")
+	}
+}
+
+func TestTree_Grow(t *testing.T) {
+	r := NewTree[int]()
+	// the root and the "x" node go through every kind of node
+	for i := 0; i < 256; i++ {
+		r.Insert(string([]byte{byte(i)}), i)
+		r.Insert(string([]byte{'x', byte(i), 'y'}), i)
+		checkTree(t, r)
+	}
+	if r.root.kind != node256 || r.root.findChild('x').kind != node256 {
+		t.Fatalf("expected node256")
+	}
+	got := keys[int](r.Walk)
+	if !sort.StringsAreSorted(got) || len(got) != 512 {
+		t.Fatalf("bad walk of %d keys", len(got))
+	}
+	for i := 255; i >= 0; i-- {
+		r.Delete(string([]byte{byte(i)}))
+		r.Delete(string([]byte{'x', byte(i), 'y'}))
+		checkTree(t, r)
+		if v, ok := r.Find(string([]byte{'x', 0, 'y'})); i > 0 && (!ok || v != 0) {
+			t.Fatalf("lost a key after deleting %d", i)
+		}
+	}
+	if r.Len() != 0 || r.root.kind != node4 {
+		t.Fatalf("expected an empty tree")
+	}
+}
+
+func TestTree_DeletePrefix(t *testing.T) {
+	r := NewTree[int]()
+	for i, k := range []string{"", "A", "AB", "ABC", "R", "S"} {
+		r.Insert(k, i)
+	}
+	if n := r.DeletePrefix("AB"); n != 2 {
+		t.Fatalf("expected 2 deletes, got %d", n)
+	}
+	checkTree(t, r)
+	if got := keys[int](r.Walk); !equal(got, []string{"", "A", "R", "S"}) {
+		t.Fatalf("bad walk %v", got)
+	}
+	if n := r.DeletePrefix("X"); n != 0 {
+		t.Fatalf("expected no deletes, got %d", n)
+	}
+	if n := r.DeletePrefix(""); n != 4 || r.Len() != 0 {
+		t.Fatalf("expected 4 deletes, got %d", n)
+	}
+}
+
+// TestTree_Random runs the same random operations against a radix.Tree and
+// an art.Tree and checks that they always agree with one another.
+func TestTree_ValidateCorrupt(t *testing.T) {
+	r := NewTree[int]()
+	for i, k := range []string{"", "A", "AB", "ABC", "foo", "foobar"} {
+		r.Insert(k, i)
+	}
+	checkTree(t, r)
+
+	// swap the labels of the root
+	r.root.keys[0], r.root.keys[1] = r.root.keys[1], r.root.keys[0]
+	if err := r.Validate(); !errors.Is(err, ErrBadNode) {
+		t.Errorf("expected %v, got %v", ErrBadNode, err)
+	}
+	r.root.keys[0], r.root.keys[1] = r.root.keys[1], r.root.keys[0]
+
+	// change the key of a leaf
+	r.root.leaf.key = "B"
+	if err := r.Validate(); !errors.Is(err, ErrBadKey) {
+		t.Errorf("expected %v, got %v", ErrBadKey, err)
+	}
+	r.root.leaf.key = ""
+
+	// miscount the leaves
+	r.size++
+	if err := r.Validate(); !errors.Is(err, ErrBadLength) {
+		t.Errorf("expected %v, got %v", ErrBadLength, err)
+	}
+	r.size--
+	checkTree(t, r)
+}
+
+func TestTree_Random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(33))
+	randKey := func() string {
+		b := make([]byte, rnd.Intn(8))
+		for i := range b {
+			b[i] = "abcd/"[rnd.Intn(5)]
+		}
+		return string(b)
+	}
+	a := NewTree[int]()
+	r := radix.NewTree[int]()
+	for i := 0; i < 20000; i++ {
+		k := randKey()
+		switch op := rnd.Intn(10); {
+		case op < 6:
+			ao, au := a.Insert(k, i)
+			ro, ru := r.Insert(k, i)
+			if ao != ro || au != ru {
+				t.Fatalf("insert(%q): got %d, %v, expected %d, %v", k, ao, au, ro, ru)
+			}
+		case op < 9:
+			av, aok := a.Delete(k)
+			rv, rok := r.Delete(k)
+			if av != rv || aok != rok {
+				t.Fatalf("delete(%q): got %d, %v, expected %d, %v", k, av, aok, rv, rok)
+			}
+		default:
+			if an, rn := a.DeletePrefix(k), r.DeletePrefix(k); an != rn {
+				t.Fatalf("delete prefix(%q): got %d, expected %d", k, an, rn)
+			}
+		}
+		if a.Len() != r.Len() {
+			t.Fatalf("bad length, got %d, expected %d", a.Len(), r.Len())
+		}
+		if i%500 == 0 {
+			checkTree(t, a)
+			checkSame(t, a, r, randKey())
+		}
+	}
+}
+
+func checkSame(t *testing.T, a *Tree[int], r *radix.Tree[int], k string) {
+	t.Helper()
+	got, want := keys[int](a.Walk), keys[int](func(fn WalkFn[int]) { r.Walk(radix.WalkFn[int](fn)) })
+	if !equal(got, want) {
+		t.Fatalf("walk: got %v, expected %v", got, want)
+	}
+	if got, want := keys[int](func(fn WalkFn[int]) { a.WalkPrefix(k, fn) }),
+		keys[int](func(fn WalkFn[int]) { r.WalkPrefix(k, radix.WalkFn[int](fn)) }); !equal(got, want) {
+		t.Fatalf("walk prefix(%q): got %v, expected %v", k, got, want)
+	}
+	if got, want := keys[int](func(fn WalkFn[int]) { a.WalkPath(k, fn) }),
+		keys[int](func(fn WalkFn[int]) { r.WalkPath(k, radix.WalkFn[int](fn)) }); !equal(got, want) {
+		t.Fatalf("walk path(%q): got %v, expected %v", k, got, want)
+	}
+	av, aok := a.Find(k)
+	rv, rok := r.Find(k)
+	if av != rv || aok != rok {
+		t.Fatalf("find(%q): got %d, %v, expected %d, %v", k, av, aok, rv, rok)
+	}
+	ak, _, _ := a.FindLongestPrefix(k)
+	rk, _, _ := r.FindLongestPrefix(k)
+	if ak != rk {
+		t.Fatalf("longest prefix(%q): got %q, expected %q", k, ak, rk)
+	}
+	// radix.Tree can leave empty nodes behind after a DeletePrefix,
+	// which confuses its Min and Max, so check against the walk instead
+	min, _, minOK := a.Min()
+	max, _, maxOK := a.Max()
+	if len(got) == 0 {
+		if minOK || maxOK {
+			t.Fatalf("min/max: got %q/%q from an empty tree", min, max)
+		}
+	} else if min != got[0] || max != got[len(got)-1] {
+		t.Fatalf("min/max: got %q/%q, expected %q/%q", min, max, got[0], got[len(got)-1])
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// urls returns n generated urls, which share long prefixes. They are a
+// synthetic stand-in for a real list of urls, such as an access log.
+func urls(n int) []string {
+	rnd := rand.New(rand.NewSource(1))
+	hosts := []string{"www.example.com", "api.example.com", "cdn.example.net", "www.example.org", "blog.example.io"}
+	sections := []string{"users", "groups", "posts", "comments", "images", "static", "v1", "v2", "search", "admin"}
+	out := make([]string, n)
+	for i := range out {
+		var sb strings.Builder
+		sb.WriteString("https://")
+		sb.WriteString(hosts[rnd.Intn(len(hosts))])
+		for j := 1 + rnd.Intn(3); j > 0; j-- {
+			sb.WriteByte('/')
+			sb.WriteString(sections[rnd.Intn(len(sections))])
+		}
+		fmt.Fprintf(&sb, "/%d", rnd.Intn(1_000_000))
+		out[i] = sb.String()
+	}
+	return out
+}
+
+// words returns n generated dictionary-like words, which are short and
+// spread out over the whole alphabet. They are a synthetic stand-in for a
+// real dictionary, such as /usr/share/dict/words.
+func words(n int) []string {
+	rnd := rand.New(rand.NewSource(2))
+	syllables := []string{
+		"a", "an", "ar", "be", "ca", "co", "de", "di", "e", "en", "er", "es", "fi", "ga", "he", "i", "in", "is",
+		"ka", "la", "li", "ma", "mo", "na", "ne", "o", "on", "or", "pa", "pe", "qu", "ra", "re", "ri", "sa", "se",
+		"st", "ta", "te", "ti", "to", "u", "un", "ur", "va", "ve", "wa", "xe", "ya", "ze",
+	}
+	out := make([]string, n)
+	for i := range out {
+		var sb strings.Builder
+		for j := 1 + rnd.Intn(4); j > 0; j-- {
+			sb.WriteString(syllables[rnd.Intn(len(syllables))])
+		}
+		if rnd.Intn(4) == 0 {
+			sb.WriteString([]string{"s", "ed", "ing", "ly"}[rnd.Intn(4)])
+		}
+		out[i] = sb.String()
+	}
+	return out
+}
+
+type dataset struct {
+	name string
+	keys []string
+}
+
+var (
+	datasetsOnce sync.Once
+	datasets     []dataset
+)
+
+// benchDatasets returns the datasets of the benchmarks, which are only
+// generated the first time they are needed, so the tests do not pay for
+// them
+func benchDatasets() []dataset {
+	datasetsOnce.Do(
+		func() {
+			datasets = []dataset{
+				{"urls", urls(100_000)},
+				{"words", words(100_000)},
+			}
+		},
+	)
+	return datasets
+}
+
+// index is the part of the API shared by art.Tree and radix.Tree
+type index interface {
+	Insert(k string, v int) (int, bool)
+	Find(k string) (int, bool)
+	Delete(k string) (int, bool)
+}
+
+var trees = []struct {
+	name string
+	new  func() index
+}{
+	{"art", func() index { return NewTree[int]() }},
+	{"radix", func() index { return radix.NewTree[int]() }},
+}
+
+func BenchmarkInsert(b *testing.B) {
+	for _, ds := range benchDatasets() {
+		for _, tr := range trees {
+			b.Run(
+				ds.name+"/"+tr.name, func(b *testing.B) {
+					b.ReportAllocs()
+					var t index
+					for i := 0; i < b.N; i++ {
+						if i%len(ds.keys) == 0 {
+							t = tr.new()
+						}
+						t.Insert(ds.keys[i%len(ds.keys)], i)
+					}
+				},
+			)
+		}
+	}
+}
+
+func BenchmarkFind(b *testing.B) {
+	for _, ds := range benchDatasets() {
+		for _, tr := range trees {
+			b.Run(
+				ds.name+"/"+tr.name, func(b *testing.B) {
+					t := tr.new()
+					for i, k := range ds.keys {
+						t.Insert(k, i)
+					}
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						t.Find(ds.keys[i%len(ds.keys)])
+					}
+				},
+			)
+		}
+	}
+}
+
+func BenchmarkDelete(b *testing.B) {
+	for _, ds := range benchDatasets() {
+		for _, tr := range trees {
+			b.Run(
+				ds.name+"/"+tr.name, func(b *testing.B) {
+					b.ReportAllocs()
+					var t index
+					for i := 0; i < b.N; i++ {
+						if i%len(ds.keys) == 0 {
+							b.StopTimer()
+							t = tr.new()
+							for j, k := range ds.keys {
+								t.Insert(k, j)
+							}
+							b.StartTimer()
+						}
+						t.Delete(ds.keys[i%len(ds.keys)])
+					}
+				},
+			)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package art
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBadNode   = errors.New("art: bad node layout")
+	ErrBadKey    = errors.New("art: leaf key does not match its path")
+	ErrBadMerge  = errors.New("art: node without a leaf has less than two children")
+	ErrBadLength = errors.New("art: bad tree length")
+)
+
+// Validate walks the entire tree and checks that all the structural
+// invariants of an adaptive radix tree hold. It checks the layout of every
+// node (the number of children is within the bounds of its kind, the labels
+// of a node4 or node16 are sorted, and the index of a node48 is consistent
+// with its children,) that the key of every leaf is the path leading to
+// it, that the paths are compressed (no node but the root has less than
+// two children and no leaf) and that Len matches the number of leaves. It
+// returns nil if the tree is valid, otherwise it returns an error wrapping
+// one of the ErrBad* errors describing the first violation it encountered.
+func (t *Tree[V]) Validate() error {
+	leaves, err := t.checkNode(t.root, t.root.prefix)
+	if err != nil {
+		return err
+	}
+	if leaves != t.size {
+		return fmt.Errorf("%w: Len is %d, the tree has %d leaves", ErrBadLength, t.size, leaves)
+	}
+	return nil
+}
+
+// checkNode recursively checks the node n (and all of its children,) which
+// is found at the provided path. It returns the number of leaves found.
+func (t *Tree[V]) checkNode(n *node[V], path string) (int, error) {
+	if err := checkLayout(n); err != nil {
+		return 0, fmt.Errorf("%w at path %q", err, path)
+	}
+	var leaves int
+	if n.leaf != nil {
+		if n.leaf.key != path {
+			return 0, fmt.Errorf("%w: leaf key %q at path %q", ErrBadKey, n.leaf.key, path)
+		}
+		leaves++
+	}
+	if n != t.root && n.leaf == nil && n.num < 2 {
+		return 0, fmt.Errorf("%w: %d children at path %q", ErrBadMerge, n.num, path)
+	}
+	var err error
+	n.each(
+		func(label byte, c *node[V]) bool {
+			var num int
+			num, err = t.checkNode(c, path+string([]byte{label})+c.prefix)
+			leaves += num
+			return err != nil
+		},
+	)
+	return leaves, err
+}
+
+// checkLayout checks the number of children and the labels of the node
+// against its kind
+func checkLayout[V any](n *node[V]) error {
+	var lo, hi int
+	switch n.kind {
+	case node4:
+		lo, hi = 0, max4
+	case node16:
+		lo, hi = min16+1, max16
+	case node48:
+		lo, hi = min48+1, max48
+	case node256:
+		lo, hi = min256+1, 256
+	default:
+		return fmt.Errorf("%w: unknown kind %d", ErrBadNode, n.kind)
+	}
+	if n.num < lo || n.num > hi {
+		return fmt.Errorf("%w: kind %d has %d children", ErrBadNode, n.kind, n.num)
+	}
+	if n.num > 0 && len(n.children) < hi {
+		return fmt.Errorf("%w: kind %d has room for %d children", ErrBadNode, n.kind, len(n.children))
+	}
+
+	var found int
+	switch n.kind {
+	case node4, node16:
+		for i := 0; i < n.num; i++ {
+			if i > 0 && n.keys[i-1] >= n.keys[i] {
+				return fmt.Errorf("%w: labels %q and %q out of order", ErrBadNode, n.keys[i-1], n.keys[i])
+			}
+			if n.children[i] != nil {
+				found++
+			}
+		}
+	case node48:
+		var used [max48]bool
+		for _, s := range n.index {
+			if s == 0 {
+				continue
+			}
+			if int(s) > max48 || used[s-1] || n.children[s-1] == nil {
+				return fmt.Errorf("%w: bad node48 index slot %d", ErrBadNode, s)
+			}
+			used[s-1] = true
+			found++
+		}
+	case node256:
+		for _, c := range n.children {
+			if c != nil {
+				found++
+			}
+		}
+	}
+	if found != n.num {
+		return fmt.Errorf("%w: kind %d has %d children, found %d", ErrBadNode, n.kind, n.num, found)
+	}
+	return nil
+}
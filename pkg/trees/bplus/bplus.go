@@ -49,23 +49,14 @@ func (t *Tree) Del(k keyType) (keyType, valType) {
 
 // Range provides a simple iteration function for the tree
 func (t *Tree) Range(iter func(k keyType, v valType) bool) {
-	c := findFirstLeaf(t.root)
-	if c == nil {
-		return
-	}
 	var e *record
-	for {
-		for i := 0; i < c.numKeys; i++ {
-			e = (*record)(c.ptrs[i])
+	for c := newLeafCursor(t.root); c.leaf != nil; c.next() {
+		for i := 0; i < c.leaf.numKeys; i++ {
+			e = (*record)(c.leaf.ptrs[i])
 			if e != nil && !iter(e.Key, e.Value) {
 				continue
 			}
 		}
-		if c.ptrs[order-1] != nil {
-			c = (*node)(c.ptrs[order-1])
-		} else {
-			break
-		}
 	}
 }
 
@@ -105,11 +96,10 @@ func (t *Tree) GetClosest(k keyType) (keyType, valType) {
 
 // Len returns the a count of the number of items in the tree
 func (t *Tree) Len() int {
-	var count int
-	for n := findFirstLeaf(t.root); n != nil; n = n.nextLeaf() {
-		count += n.numKeys
+	if t.root == nil {
+		return 0
 	}
-	return count
+	return t.root.count()
 }
 
 // Close closes the tree
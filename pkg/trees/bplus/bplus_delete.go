@@ -10,6 +10,9 @@ func (t *Tree) delete(k keyType) *record {
 	var old *record
 	keyLeaf, keyEntry := t.find(k)
 	if keyEntry != nil && keyLeaf != nil {
+		// we are going to modify the leaf, so make sure we own it
+		keyLeaf = t.ownLeaf(k)
+		grow(keyLeaf.parent, -1)
 		t.root = deleteEntry(t.root, keyLeaf, k, unsafe.Pointer(keyEntry))
 		old = keyEntry
 		keyEntry = nil
@@ -59,11 +62,12 @@ func deleteEntry(root, n *node, k keyType, pointer unsafe.Pointer) *node {
 
 	kPrime := n.parent.keys[kPrimeIndex]
 
+	// the neighbor is about to be modified, so make sure we own it
 	var neighbor *node
 	if neighborIndex == -1 {
-		neighbor = (*node)(n.parent.ptrs[1])
+		neighbor = n.parent.ownChild(1)
 	} else {
-		neighbor = (*node)(n.parent.ptrs[neighborIndex])
+		neighbor = n.parent.ownChild(neighborIndex)
 	}
 
 	if n.isLeaf {
@@ -111,10 +115,10 @@ func removeEntryFromNode(n *node, k keyType, pointer unsafe.Pointer) *node {
 	// make sure we decrement, because now we are one key fewer
 	n.numKeys--
 
-	// set the other pointers to nil for tidiness and remember that a leaf uses
-	// the last pointer to point to the next leaf
+	// set the other pointers to nil, so the node does not hold on to
+	// records or children it no longer has
 	if n.isLeaf {
-		for i = n.numKeys; i < order-1; i++ {
+		for i = n.numKeys; i < order; i++ {
 			n.ptrs[i] = nil
 		}
 	} else {
@@ -138,7 +142,7 @@ func adjustRoot(root *node) *node {
 	// promote the first child as the new root node (the tree must always have a root)
 	var newRoot *node
 	if !root.isLeaf {
-		newRoot = (*node)(root.ptrs[0])
+		newRoot = root.ownChild(0)
 		newRoot.parent = nil
 	} else {
 		// and if it is a leaf node (has no children) then the whole tree is in fact empty
@@ -203,19 +207,17 @@ func coalesceNodes(root, n, neighbor *node, neighborIndex int, kPrime keyType) *
 
 		// all children must now point up to the same parent
 		for i = 0; i < neighbor.numKeys+1; i++ {
-			tmp = (*node)(neighbor.ptrs[i])
-			tmp.parent = neighbor
+			neighbor.adopt(i)
 		}
+		neighbor.resize()
 	} else {
-		// otherwise, the node is a leaf node, append the keys and pointers of n to
-		// the neighbor and because it's a leaf node, we must set the neighbor's last
-		// pointer to point to what ha been n's rightmost neighbor
+		// otherwise, the node is a leaf node, so simply append the keys and
+		// pointers of n to the neighbor
 		for i, j = neighborInsertionIndex, 0; j < n.numKeys; i, j = i+1, j+1 {
 			neighbor.keys[i] = n.keys[j]
 			neighbor.ptrs[i] = n.ptrs[j]
 			neighbor.numKeys++
 		}
-		neighbor.ptrs[order-1] = n.ptrs[order-1]
 	}
 	root = deleteEntry(root, n.parent, kPrime, unsafe.Pointer(n))
 	n = nil // free
@@ -229,7 +231,6 @@ func redistributeNodes(root, n, neighbor *node, neighborIndex, kPrimeIndex int,
 
 	// initialize temporary variables
 	var i int
-	var tmp *node
 
 	// in the case where n has a neighbor to the left, pull the neighbor's last
 	// key-pointer pair over from the neighbor's right end to n's left end
@@ -243,8 +244,7 @@ func redistributeNodes(root, n, neighbor *node, neighborIndex, kPrimeIndex int,
 		}
 		if !n.isLeaf {
 			n.ptrs[0] = neighbor.ptrs[neighbor.numKeys]
-			tmp = (*node)(n.ptrs[0])
-			tmp.parent = n
+			n.adopt(0)
 			neighbor.ptrs[neighbor.numKeys] = nil
 			n.keys[0] = kPrime
 			n.parent.keys[kPrimeIndex] = neighbor.keys[neighbor.numKeys-1]
@@ -265,8 +265,7 @@ func redistributeNodes(root, n, neighbor *node, neighborIndex, kPrimeIndex int,
 		} else {
 			n.keys[n.numKeys] = kPrime
 			n.ptrs[n.numKeys+1] = neighbor.ptrs[0]
-			tmp = (*node)(n.ptrs[n.numKeys+1])
-			tmp.parent = n
+			n.adopt(n.numKeys + 1)
 			n.parent.keys[kPrimeIndex] = neighbor.keys[0]
 		}
 		for i = 0; i < neighbor.numKeys-1; i++ {
@@ -275,12 +274,18 @@ func redistributeNodes(root, n, neighbor *node, neighborIndex, kPrimeIndex int,
 		}
 		if !n.isLeaf {
 			neighbor.ptrs[i] = neighbor.ptrs[i+1]
+			i++
 		}
+		neighbor.ptrs[i] = nil
 	}
 
 	// now, n has one more key and one more pointer and the neighbor has one fewer
 	// of each, so don't forget to properly increment and decrement each accordingly
 	n.numKeys++
 	neighbor.numKeys--
+	if !n.isLeaf {
+		n.resize()
+		neighbor.resize()
+	}
 	return root
 }
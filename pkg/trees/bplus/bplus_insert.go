@@ -11,23 +11,26 @@ func (t *Tree) insert(k keyType, v valType) bool {
 	// if the root is nil, then the tree does not exist yet, start a new tree
 	if t.root == nil {
 		t.root = startNewTree(k, &record{k, v})
+		t.root.gen = t.gen
 		return false
 	}
 	// the current implementation ignores duplicates (will treat it kind of
 	// like a map's set operation), use insertUnique() if you wish to support
-	// an add only type of action.
-	leaf, recordPointer := t.find(k)
-	if recordPointer != nil {
-		// If the key already exists in this tree then we can simply proceed
-		// to just update the value of the record pointer that was returned
-		recordPointer.Value = v
-		return true
+	// an add only type of action. ownLeaf returns the same leaf as t.find,
+	// but copies it (and the path to it) if it is shared with a snapshot.
+	leaf := t.ownLeaf(k)
+	for i := 0; i < leaf.numKeys; i++ {
+		if leaf.keys[i].data == k.data {
+			// If the key already exists in this tree then we can simply proceed
+			// to just swap in a new record. the old record is left untouched,
+			// because a snapshot may still be holding on to it
+			leaf.ptrs[i] = unsafe.Pointer(&record{k, v})
+			return true
+		}
 	}
 	// if we are here, then no existing record has been found in the tree. now
-	// we must create a new record. it is worth mentioning that normally t.find
-	// would not return a record pointer in which case we would need to do
-	// something like the following:
-	// recordPointer = makeRecord(v)
+	// we must create a new record.
+	grow(leaf.parent, 1)
 
 	// check to see if the leaf (that the record should go into) has room, and
 	// if it does, simply insert into the leaf and return
@@ -47,6 +50,7 @@ func (t *Tree) insertUnique(k keyType, v valType) {
 	// if the root is nil, then the tree does not exist yet, start a new tree
 	if t.root == nil {
 		t.root = startNewTree(k, &record{k, v})
+		t.root.gen = t.gen
 		return
 	}
 	// see what we get when we try to find the correct leaf
@@ -57,6 +61,9 @@ func (t *Tree) insertUnique(k keyType, v valType) {
 		// should just return
 		return
 	}
+	// we are going to modify the leaf, so make sure we own it
+	leaf = t.ownLeaf(k)
+	grow(leaf.parent, 1)
 
 	// looks like it is not already in the tree, so now we must check
 	// to see if the leaf (that the record should go into) has room,
@@ -140,7 +147,7 @@ func insertIntoLeafAfterSplitting(root, leaf *node, k keyType, pointer *record)
 	}
 
 	// create new leaf
-	newLeaf := &node{isLeaf: true} // makeLeaf()
+	newLeaf := &node{isLeaf: true, gen: leaf.gen} // makeLeaf()
 
 	// writing to new leaf from split point to end of original leaf pre-split
 	for i, j = split, 0; i < order; i, j = i+1, j+1 {
@@ -155,9 +162,6 @@ func insertIntoLeafAfterSplitting(root, leaf *node, k keyType, pointer *record)
 		tempPointers[i] = nil       // zero Value
 	}
 
-	newLeaf.ptrs[order-1] = leaf.ptrs[order-1]
-	leaf.ptrs[order-1] = unsafe.Pointer(newLeaf)
-
 	for i = leaf.numKeys; i < order-1; i++ {
 		leaf.ptrs[i] = nil
 	}
@@ -197,12 +201,13 @@ func insertIntoParent(root *node, left *node, k keyType, right *node) *node {
 
 // insertIntoNewRoot creates a new root for two subtrees and inserts the appropriate key into the new root
 func insertIntoNewRoot(left *node, k keyType, right *node) *node {
-	root := &node{} // makeNode()
+	root := &node{gen: left.gen} // makeNode()
 	root.keys[0] = k
 	root.ptrs[0] = unsafe.Pointer(left)
 	root.ptrs[1] = unsafe.Pointer(right)
 	root.numKeys++
 	root.parent = nil
+	root.resize()
 	left.parent = root
 	right.parent = root
 	return root
@@ -284,8 +289,14 @@ func insertIntoNodeAfterSplitting(root, oldNode *node, leftIndex int, k keyType,
 	oldNode.ptrs[i] = tempPointers[i]
 	kPrime := tempKeys[split-1]
 
+	// clear out what is left of the old node, so it does not hold on to
+	// children that have moved to the new node
+	for j = i + 1; j < order; j++ {
+		oldNode.ptrs[j] = nil
+	}
+
 	// create a new node which will become the right child node
-	newNode := &node{} // makeNode()
+	newNode := &node{gen: oldNode.gen} // makeNode()
 
 	// ...and copy the other half (right/last half) of the temporary keys and
 	// pointers into the new node
@@ -307,12 +318,14 @@ func insertIntoNodeAfterSplitting(root, oldNode *node, leftIndex int, k keyType,
 	// create a child that will contain the value pointers of the newly split
 	// new node, and make the child node's parent, the new node (not sure i
 	// remember how this part actually works)
-	var child *node
 	for i = 0; i <= newNode.numKeys; i++ {
-		child = (*node)(newNode.ptrs[i])
-		child.parent = newNode
+		newNode.adopt(i)
 	}
 
+	// the records under the old node are now split between the two nodes
+	oldNode.resize()
+	newNode.resize()
+
 	// and then finally, insert new key into the parent of the two nodes resulting
 	// from the split with the old node to the left, and the new node to the right
 	return insertIntoParent(root, oldNode, kPrime, newNode)
@@ -5,7 +5,12 @@ import (
 	"strings"
 )
 
-var queue *print = nil
+// printQueue is the queue used by the printers to walk the tree in
+// level order. Each printer uses its own queue so that they can be
+// called on more than one tree at a time.
+type printQueue struct {
+	head *print
+}
 
 type print struct {
 	node *node
@@ -66,13 +71,13 @@ func newPrint(n *node) *print {
 	}
 }
 
-func enqueue(newNode *node) {
+func (q *printQueue) enqueue(newNode *node) {
 	var c *print
-	if queue == nil {
-		queue = newPrint(newNode)
-		queue.next = nil
+	if q.head == nil {
+		q.head = newPrint(newNode)
+		q.head.next = nil
 	} else {
-		c = queue
+		c = q.head
 		for c.next != nil {
 			c = c.next
 		}
@@ -81,36 +86,32 @@ func enqueue(newNode *node) {
 	}
 }
 
-func dequeue() *print {
+func (q *printQueue) dequeue() *print {
 	var n *print
-	n = queue
-	queue = queue.next
+	n = q.head
+	q.head = q.head.next
 	n.next = nil
 	return n
 }
 
+func (q *printQueue) empty() bool {
+	return q.head == nil
+}
+
 func printLeaves(root *node) {
 	if root == nil {
 		fmt.Println("empty tree")
 		return
 	}
-	var c *node
-	c = root
-	for !c.isLeaf {
-		c = (*node)(c.ptrs[0])
-	}
-	for {
-		for i := 0; i < c.numKeys; i++ {
-			fmt.Printf("%d ", c.keys[i])
-			fmt.Printf("%p ", c.ptrs[i])
+	for c := newLeafCursor(root); c.leaf != nil; {
+		for i := 0; i < c.leaf.numKeys; i++ {
+			fmt.Printf("%d ", c.leaf.keys[i])
+			fmt.Printf("%p ", c.leaf.ptrs[i])
 		}
-		fmt.Printf("%p ", c.ptrs[order-1])
-		if c.ptrs[order-1] != nil {
-			fmt.Printf(" | ")
-			c = (*node)(c.ptrs[order-1])
-		} else {
+		if c.next() == nil {
 			break
 		}
+		fmt.Printf(" | ")
 		fmt.Printf("\n")
 	}
 }
@@ -146,12 +147,12 @@ func printTree(root *node) {
 		fmt.Println("empty tree")
 		return
 	}
-	queue = nil
-	enqueue(root)
+	queue := new(printQueue)
+	queue.enqueue(root)
 	fmt.Println("graph TD")
 	fmt.Printf("\ttitle{B+Tree of order %d}\n", M)
-	for queue != nil {
-		n := dequeue()
+	for !queue.empty() {
+		n := queue.dequeue()
 		if n.node.parent != nil && n.node == (*node)(n.node.parent.ptrs[0]) {
 			newRank = pathToRoot(root, n.node)
 			if newRank != rank {
@@ -161,7 +162,7 @@ func printTree(root *node) {
 		printNodeMarkdown(n.node)
 		if !n.node.isLeaf {
 			for i := 0; i <= n.node.numKeys; i++ {
-				enqueue((*node)(n.node.ptrs[i]))
+				queue.enqueue((*node)(n.node.ptrs[i]))
 			}
 		}
 	}
@@ -173,13 +174,13 @@ func _printTree(root *node) {
 		fmt.Println("empty tree")
 		return
 	}
-	queue = nil
+	queue := new(printQueue)
 	// put the root node in the current node
-	enqueue(root)
+	queue.enqueue(root)
 	var nn int
-	for queue != nil {
+	for !queue.empty() {
 		// get the current node out of the queue
-		n := dequeue()
+		n := queue.dequeue()
 		if n.node.parent != nil && n.node == (*node)(n.node.parent.ptrs[0]) {
 			newRank = pathToRoot(root, n.node)
 			if newRank != rank {
@@ -202,7 +203,7 @@ func _printTree(root *node) {
 		if !n.node.isLeaf {
 			for i := 0; i <= n.node.numKeys; i++ {
 				child := (*node)(n.node.ptrs[i])
-				enqueue(child)
+				queue.enqueue(child)
 			}
 		}
 		// if it is a leaf, print the values
@@ -232,10 +233,10 @@ func print_tree(root *node) {
 		fmt.Printf("Empty tree.\n")
 		return
 	}
-	queue = nil
-	enqueue(root)
-	for queue != nil {
-		prt := dequeue()
+	queue := new(printQueue)
+	queue.enqueue(root)
+	for !queue.empty() {
+		prt := queue.dequeue()
 		if prt.node.parent != nil && prt.node == (*node)(prt.node.parent.ptrs[0]) {
 			new_rank = pathToRoot(root, prt.node)
 			if new_rank != rank {
@@ -253,7 +254,7 @@ func print_tree(root *node) {
 		fmt.Printf("%d]", prt.node.keys[prt.node.numKeys-1].data)
 		if !prt.node.isLeaf {
 			for i = 0; i <= prt.node.numKeys; i++ {
-				enqueue((*node)(prt.node.ptrs[i]))
+				queue.enqueue((*node)(prt.node.ptrs[i]))
 			}
 		}
 		fmt.Printf("  ")
@@ -268,10 +269,10 @@ func print_tree_v2(root *node) {
 		fmt.Printf("Empty tree.\n")
 		return
 	}
-	queue = nil
-	enqueue(root)
-	for queue != nil {
-		prt := dequeue()
+	queue := new(printQueue)
+	queue.enqueue(root)
+	for !queue.empty() {
+		prt := queue.dequeue()
 		if prt.node.parent != nil && prt.node == (*node)(prt.node.parent.ptrs[0]) {
 			new_rank = pathToRoot(root, prt.node)
 			if new_rank != rank {
@@ -289,7 +290,7 @@ func print_tree_v2(root *node) {
 		fmt.Printf("%d]", prt.node.keys[prt.node.numKeys-1].data)
 		if !prt.node.isLeaf {
 			for i = 0; i <= prt.node.numKeys; i++ {
-				enqueue((*node)(prt.node.ptrs[i]))
+				queue.enqueue((*node)(prt.node.ptrs[i]))
 			}
 		}
 		fmt.Printf("  ")
@@ -304,11 +305,11 @@ func print_markdown_tree(root *node) {
 		sss = append(sss, []string{"root[ ]"})
 		return
 	}
-	queue = nil
-	enqueue(root)
-	for queue != nil {
+	queue := new(printQueue)
+	queue.enqueue(root)
+	for !queue.empty() {
 		var ss []string
-		prt := dequeue()
+		prt := queue.dequeue()
 		if prt.node.parent != nil && prt.node == (*node)(prt.node.parent.ptrs[0]) {
 			new_rank = pathToRoot(root, prt.node)
 			if new_rank != rank {
@@ -330,7 +331,7 @@ func print_markdown_tree(root *node) {
 		ss = append(ss, fmt.Sprintf("r%dn[%.2d]**", rank, prt.node.keys[prt.node.numKeys-1].data))
 		if !prt.node.isLeaf {
 			for i = 0; i <= prt.node.numKeys; i++ {
-				enqueue((*node)(prt.node.ptrs[i]))
+				queue.enqueue((*node)(prt.node.ptrs[i]))
 			}
 		}
 		sss = append(sss, ss)
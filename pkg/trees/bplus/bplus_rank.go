@@ -0,0 +1,123 @@
+package bplus
+
+// count returns the number of records in the subtree rooted at n
+func (n *node) count() int {
+	if n.isLeaf {
+		return n.numKeys
+	}
+	return n.size
+}
+
+// resize recalculates the size of an internal node from the
+// counts of its children. It is used after a split, coalesce or
+// redistribution moves children from one internal node to another.
+func (n *node) resize() {
+	n.size = 0
+	for i := 0; i <= n.numKeys; i++ {
+		n.size += (*node)(n.ptrs[i]).count()
+	}
+}
+
+// grow adds delta to the size of n and every one of its ancestors. It
+// is used when a record is added to (or removed from) a leaf, before
+// the leaf is split or merged, which only moves records around.
+func grow(n *node, delta int) {
+	for ; n != nil; n = n.parent {
+		n.size += delta
+	}
+}
+
+// rank returns the number of records in the subtree at root
+// with a key that is less than the provided key
+func rank(root *node, k keyType) int {
+	if root == nil {
+		return 0
+	}
+	var r int
+	c := root
+	for !c.isLeaf {
+		i := 0
+		for i < c.numKeys && k.data >= c.keys[i].data {
+			r += (*node)(c.ptrs[i]).count()
+			i++
+		}
+		c = (*node)(c.ptrs[i])
+	}
+	for i := 0; i < c.numKeys && c.keys[i].data < k.data; i++ {
+		r++
+	}
+	return r
+}
+
+// selectRecord returns the i-th (zero based) record in key
+// order from the subtree at root, or nil if it is out of range
+func selectRecord(root *node, i int) *record {
+	if root == nil || i < 0 || i >= root.count() {
+		return nil
+	}
+	c := root
+	for !c.isLeaf {
+		j := 0
+		for ; j < c.numKeys; j++ {
+			n := (*node)(c.ptrs[j]).count()
+			if i < n {
+				break
+			}
+			i -= n
+		}
+		c = (*node)(c.ptrs[j])
+	}
+	return (*record)(c.ptrs[i])
+}
+
+// Rank returns the number of keys in the tree that are less than the
+// provided key. If the key is in the tree, this is its (zero based)
+// position in key order.
+func (t *Tree) Rank(k keyType) int {
+	return rank(t.root, k)
+}
+
+// Select returns the key and value found at the i-th (zero based)
+// position in key order. It returns zero values if i is not in the
+// range [0, Len())
+func (t *Tree) Select(i int) (keyType, valType) {
+	e := selectRecord(t.root, i)
+	if e == nil {
+		return *new(keyType), *new(valType)
+	}
+	return e.Key, e.Value
+}
+
+// CountRange returns the number of keys in the tree that fall in the
+// range [lo, hi), that is, including lo but excluding hi.
+func (t *Tree) CountRange(lo, hi keyType) int {
+	if hi.data <= lo.data {
+		return 0
+	}
+	return rank(t.root, hi) - rank(t.root, lo)
+}
+
+// Rank returns the number of keys in the snapshot that are less
+// than the provided key
+func (s *Snapshot) Rank(k keyType) int {
+	return rank(s.root, k)
+}
+
+// Select returns the key and value found at the i-th (zero based)
+// position in key order of the snapshot
+func (s *Snapshot) Select(i int) (keyType, valType) {
+	e := selectRecord(s.root, i)
+	if e == nil {
+		return *new(keyType), *new(valType)
+	}
+	return e.Key, e.Value
+}
+
+// CountRange returns the number of keys in the snapshot that
+// fall in the range [lo, hi)
+func (s *Snapshot) CountRange(lo, hi keyType) int {
+	if hi.data <= lo.data {
+		return 0
+	}
+	return rank(s.root, hi) - rank(s.root, lo)
+}
@@ -0,0 +1,43 @@
+package bplus
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTree_RankSelect(t *testing.T) {
+	tree := new(Tree)
+	// insert only the even keys, in a random order
+	for _, i := range rand.New(rand.NewSource(7)).Perm(n * thousand) {
+		tree.Put(makeKey(i*2), makeVal(i*2))
+	}
+	AssertNoError(t, tree.Validate())
+	for i := 0; i < n*thousand; i++ {
+		AssertEqual(t, i, tree.Rank(makeKey(i*2)))
+		AssertEqual(t, i+1, tree.Rank(makeKey(i*2+1)))
+		k, v := tree.Select(i)
+		AssertEqual(t, makeKey(i*2), k)
+		AssertEqual(t, makeVal(i*2), v)
+	}
+	k, _ := tree.Select(n * thousand)
+	AssertEqual(t, keyType{}, k)
+	k, _ = tree.Select(-1)
+	AssertEqual(t, keyType{}, k)
+	AssertEqual(t, 5, tree.CountRange(makeKey(10), makeKey(20)))
+	AssertEqual(t, 5, tree.CountRange(makeKey(9), makeKey(19)))
+	AssertEqual(t, 0, tree.CountRange(makeKey(20), makeKey(10)))
+	AssertEqual(t, n*thousand, tree.CountRange(makeKey(0), makeKey(n*thousand*2)))
+
+	// delete every other record, the counts must follow along
+	for i := 0; i < n*thousand; i += 2 {
+		tree.Del(makeKey(i * 2))
+	}
+	AssertNoError(t, tree.Validate())
+	AssertLen(t, n*thousand/2, tree.Len())
+	for i := 0; i < n*thousand/2; i++ {
+		k, _ := tree.Select(i)
+		AssertEqual(t, makeKey(i*4+2), k)
+		AssertEqual(t, i, tree.Rank(k))
+	}
+	tree.Close()
+}
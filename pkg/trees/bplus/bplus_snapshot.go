@@ -0,0 +1,167 @@
+package bplus
+
+import (
+	"unsafe"
+)
+
+// Snapshot is a read-only, point-in-time view of a Tree. It shares all
+// of its nodes with the tree it was taken from until the tree modifies
+// them, at which point the tree copies the path from the root down to
+// the nodes it is changing (path copying) and leaves the originals for
+// the snapshot. This means a snapshot is never affected by any Put, Add
+// or Del made on the tree after the snapshot was taken, and reading from
+// a snapshot never has to block a writer.
+//
+// Taking a snapshot must be serialized with the other writes to the tree
+// like any other write, but once it has been taken the snapshot can be
+// read from any number of goroutines while the tree continues to change.
+// The tree never writes to a node it shares with a snapshot (which is why
+// the leaves are not linked to each other) so the nodes that only a
+// snapshot refers to are garbage collected once it has been dropped.
+type Snapshot struct {
+	root *node
+}
+
+// Snapshot returns a read-only view of the tree as it is right now.
+// Taking a snapshot is O(1); the cost of copying nodes is paid for
+// lazily by the writes that follow.
+func (t *Tree) Snapshot() *Snapshot {
+	// bumping the generation "freezes" every node that currently exists
+	// in the tree. from now on the tree must copy a node before it can
+	// modify it.
+	t.gen++
+	return &Snapshot{root: t.root}
+}
+
+// Has returns a boolean indicating weather or not the
+// provided key existed in the tree when the snapshot was taken.
+func (s *Snapshot) Has(k keyType) bool {
+	leaf := findLeaf(s.root, k)
+	return leaf != nil && leaf.hasKey(k)
+}
+
+// Get returns the record for a given key if it exists in the snapshot
+func (s *Snapshot) Get(k keyType) (keyType, valType) {
+	leaf := findLeaf(s.root, k)
+	if leaf == nil {
+		return *new(keyType), *new(valType)
+	}
+	e, ok := leaf.record(k)
+	if !ok {
+		return *new(keyType), *new(valType)
+	}
+	return e.Key, e.Value
+}
+
+// GetClosest attempts to return the closest match in the snapshot
+// if an explicit match cannot be found
+func (s *Snapshot) GetClosest(k keyType) (keyType, valType) {
+	leaf := findLeaf(s.root, k)
+	if leaf == nil {
+		return *new(keyType), *new(valType)
+	}
+	e, ok := leaf.closest(k)
+	if !ok {
+		return *new(keyType), *new(valType)
+	}
+	return e.Key, e.Value
+}
+
+// Min returns the minimum (lowest) key and value pair in the snapshot
+func (s *Snapshot) Min() (keyType, valType) {
+	c := findFirstLeaf(s.root)
+	if c == nil {
+		return *new(keyType), *new(valType)
+	}
+	e := (*record)(c.ptrs[0])
+	return e.Key, e.Value
+}
+
+// Max returns the maximum (highest) key and value pair in the snapshot
+func (s *Snapshot) Max() (keyType, valType) {
+	c := findLastLeaf(s.root)
+	if c == nil {
+		return *new(keyType), *new(valType)
+	}
+	e := (*record)(c.ptrs[c.numKeys-1])
+	return e.Key, e.Value
+}
+
+// Range calls iter for every record in the snapshot in ascending key
+// order, stopping early if iter returns false.
+func (s *Snapshot) Range(iter func(k keyType, v valType) bool) {
+	for c := newLeafCursor(s.root); c.leaf != nil; c.next() {
+		for i := 0; i < c.leaf.numKeys; i++ {
+			e := (*record)(c.leaf.ptrs[i])
+			if !iter(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of items in the snapshot
+func (s *Snapshot) Len() int {
+	if s.root == nil {
+		return 0
+	}
+	return s.root.count()
+}
+
+// cloneNode returns a copy of n that belongs to the provided generation
+// with its parent set to parent. The original is left untouched, so once
+// no snapshot refers to it the original can be garbage collected.
+func cloneNode(n *node, gen uint64, parent *node) *node {
+	c := *n
+	c.gen = gen
+	c.parent = parent
+	return &c
+}
+
+// ownChild returns the child at index i of the node n, copying it first
+// if it may be shared with a snapshot. The node n must already belong to
+// the current generation of the tree. The returned child is safe to modify.
+func (n *node) ownChild(i int) *node {
+	c := (*node)(n.ptrs[i])
+	if c.gen == n.gen {
+		return c
+	}
+	c = cloneNode(c, n.gen, n)
+	n.ptrs[i] = unsafe.Pointer(c)
+	return c
+}
+
+// adopt makes n the parent of its child at index i. A child from an older
+// generation is left alone: it may be shared with a snapshot, so its parent
+// pointer is not trusted by the tree until ownChild copies the child (and
+// sets the parent of the copy) on the way down to a node being modified.
+func (n *node) adopt(i int) {
+	if c := (*node)(n.ptrs[i]); c.gen == n.gen {
+		c.parent = n
+	}
+}
+
+// ownLeaf traces the path from the root to the leaf containing the given
+// key (just like findLeaf) copying any node along the way that may still
+// be shared with a snapshot. It returns the leaf, which is safe to modify.
+func (t *Tree) ownLeaf(k keyType) *node {
+	if t.root == nil {
+		return nil
+	}
+	if t.root.gen != t.gen {
+		t.root = cloneNode(t.root, t.gen, nil)
+	}
+	c := t.root
+	for !c.isLeaf {
+		i := 0
+		for i < c.numKeys {
+			if k.data >= c.keys[i].data {
+				i++
+			} else {
+				break
+			}
+		}
+		c = c.ownChild(i)
+	}
+	return c
+}
@@ -0,0 +1,200 @@
+package bplus
+
+import (
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// checkSnapshot ensures the snapshot holds exactly the records in the model
+func checkSnapshot(t *testing.T, s *Snapshot, model map[uint32]string) {
+	t.Helper()
+	var keys []uint32
+	for k := range model {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	var i int
+	s.Range(
+		func(k keyType, v valType) bool {
+			if i >= len(keys) || k.data != keys[i] || string(v.data) != model[k.data] {
+				t.Fatalf("snapshot range: unexpected record %d=%q at %d", k.data, v.data, i)
+			}
+			i++
+			return true
+		},
+	)
+	AssertLen(t, len(keys), i)
+	AssertLen(t, len(keys), s.Len())
+	for i, k := range keys {
+		if !s.Has(keyType{data: k}) {
+			t.Fatalf("snapshot has: missing key %d", k)
+		}
+		_, v := s.Get(keyType{data: k})
+		AssertEqual(t, model[k], string(v.data))
+		AssertEqual(t, i, s.Rank(keyType{data: k}))
+		sk, _ := s.Select(i)
+		AssertEqual(t, k, sk.data)
+	}
+	if len(keys) > 0 {
+		k, _ := s.Min()
+		AssertEqual(t, keys[0], k.data)
+		k, _ = s.Max()
+		AssertEqual(t, keys[len(keys)-1], k.data)
+	}
+}
+
+func copyModel(model map[uint32]string) map[uint32]string {
+	m := make(map[uint32]string, len(model))
+	for k, v := range model {
+		m[k] = v
+	}
+	return m
+}
+
+func TestTree_Snapshot(t *testing.T) {
+	tree := new(Tree)
+	model := make(map[uint32]string)
+	for i := 0; i < 256; i++ {
+		tree.Put(makeKey(i), makeVal(i))
+		model[uint32(i)] = string(makeVal(i).data)
+	}
+	snap := tree.Snapshot()
+	frozen := copyModel(model)
+
+	// update, delete and insert around the snapshot
+	for i := 0; i < 256; i += 2 {
+		tree.Del(makeKey(i))
+		delete(model, uint32(i))
+	}
+	for i := 1; i < 256; i += 4 {
+		tree.Put(makeKey(i), makeVal(i*10))
+		model[uint32(i)] = string(makeVal(i * 10).data)
+	}
+	for i := 256; i < 512; i++ {
+		tree.Add(makeKey(i), makeVal(i))
+		model[uint32(i)] = string(makeVal(i).data)
+	}
+	AssertNoError(t, tree.Validate())
+	AssertLen(t, len(model), tree.Len())
+	checkSnapshot(t, snap, frozen)
+	checkSnapshot(t, tree.Snapshot(), model)
+	tree.Close()
+	checkSnapshot(t, snap, frozen)
+}
+
+func TestTree_SnapshotRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	tree := new(Tree)
+	model := make(map[uint32]string)
+	type saved struct {
+		snap  *Snapshot
+		model map[uint32]string
+	}
+	var snaps []saved
+	for i := 0; i < 20*thousand; i++ {
+		k := r.Intn(512)
+		switch op := r.Intn(10); {
+		case op < 5:
+			tree.Put(makeKey(k), makeVal(i))
+			model[uint32(k)] = string(makeVal(i).data)
+		case op < 9:
+			tree.Del(makeKey(k))
+			delete(model, uint32(k))
+		default:
+			snaps = append(snaps, saved{tree.Snapshot(), copyModel(model)})
+		}
+		if i%97 == 0 {
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("validate at op %d: %v", i, err)
+			}
+		}
+	}
+	AssertNoError(t, tree.Validate())
+	AssertLen(t, len(model), tree.Len())
+	for _, s := range snaps {
+		checkSnapshot(t, s.snap, s.model)
+	}
+}
+
+func TestTree_SnapshotConcurrentReads(t *testing.T) {
+	tree := new(Tree)
+	for i := 0; i < n*thousand; i++ {
+		tree.Put(makeKey(i), makeVal(i))
+	}
+	snap := tree.Snapshot()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				AssertLen(t, n*thousand, snap.Len())
+			}
+		}()
+	}
+	for i := 0; i < n*thousand; i++ {
+		if i%3 == 0 {
+			tree.Del(makeKey(i))
+		} else {
+			tree.Put(makeKey(i+n*thousand), makeVal(i))
+		}
+	}
+	wg.Wait()
+	AssertNoError(t, tree.Validate())
+}
+
+func TestSnapshot_ReleasesMemory(t *testing.T) {
+	const size = 512
+	tree := new(Tree)
+	for i := 0; i < size; i++ {
+		tree.Put(makeKey(i), makeVal(i))
+	}
+	// take a number of snapshots, each followed by overwriting every key,
+	// and watch the records that only the dropped snapshots refer to
+	var watched, released int64
+	for g := 0; g < 8; g++ {
+		snap := tree.Snapshot()
+		for c := newLeafCursor(snap.root); c.leaf != nil; c.next() {
+			for i := 0; i < c.leaf.numKeys; i++ {
+				watched++
+				runtime.SetFinalizer((*record)(c.leaf.ptrs[i]), func(*record) { atomic.AddInt64(&released, 1) })
+			}
+		}
+		for i := 0; i < size; i++ {
+			tree.Put(makeKey(i), makeVal(i+g))
+		}
+		for i := g; i < size; i += 8 {
+			tree.Del(makeKey(i))
+			tree.Put(makeKey(i), makeVal(i))
+		}
+	}
+	AssertNoError(t, tree.Validate())
+	for i := 0; i < 50 && atomic.LoadInt64(&released) < watched; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := atomic.LoadInt64(&released); got != watched {
+		t.Fatalf("expected %d records to be released, got %d", watched, got)
+	}
+	runtime.KeepAlive(tree)
+}
+
+func BenchmarkTree_PutWithSnapshots(b *testing.B) {
+	tree := new(Tree)
+	for i := 0; i < n*thousand; i++ {
+		tree.Put(makeKey(i), makeVal(i))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%64 == 0 {
+			tree.Snapshot()
+		}
+		tree.Put(makeKey(i%(n*thousand)), makeVal(i))
+	}
+}
@@ -1,9 +1,13 @@
 package bplus
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -204,6 +208,68 @@ func TestTree_Range(t *testing.T) {
 	tree.Close()
 }
 
+func TestTree_Validate(t *testing.T) {
+	tree := new(Tree)
+	AssertNoError(t, tree.Validate())
+	keys := rand.New(rand.NewSource(1)).Perm(n * thousand)
+	for _, i := range keys {
+		tree.Put(makeKey(i), makeVal(i))
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("validate after put(%d): %v", i, err)
+		}
+	}
+	for _, i := range keys[:len(keys)/2] {
+		tree.Del(makeKey(i))
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("validate after del(%d): %v", i, err)
+		}
+	}
+	AssertLen(t, n*thousand/2, tree.Len())
+	tree.Close()
+}
+
+func TestTree_ValidateCorrupt(t *testing.T) {
+	tree := new(Tree)
+	for i := 0; i < 64; i++ {
+		tree.Put(makeKey(i), makeVal(i))
+	}
+	AssertNoError(t, tree.Validate())
+
+	// swap two keys in the first leaf
+	leaf := findFirstLeaf(tree.root)
+	leaf.keys[0], leaf.keys[1] = leaf.keys[1], leaf.keys[0]
+	if err := tree.Validate(); !errors.Is(err, ErrBadKeyOrder) {
+		t.Errorf("expected %v, got %v", ErrBadKeyOrder, err)
+	}
+	leaf.keys[0], leaf.keys[1] = leaf.keys[1], leaf.keys[0]
+
+	// break a parent pointer
+	parent := leaf.parent
+	leaf.parent = nil
+	if err := tree.Validate(); !errors.Is(err, ErrBadParent) {
+		t.Errorf("expected %v, got %v", ErrBadParent, err)
+	}
+	leaf.parent = parent
+	AssertNoError(t, tree.Validate())
+	tree.Close()
+}
+
+func TestTree_WriteDOT(t *testing.T) {
+	tree := new(Tree)
+	var buf bytes.Buffer
+	AssertNoError(t, tree.WriteDOT(&buf))
+	AssertTrue(t, strings.HasPrefix(buf.String(), "digraph bplus {"))
+	for i := 0; i < 32; i++ {
+		tree.Put(makeKey(i), makeVal(i))
+	}
+	buf.Reset()
+	AssertNoError(t, tree.WriteDOT(&buf))
+	out := buf.String()
+	AssertTrue(t, strings.HasSuffix(out, "}\n"))
+	AssertTrue(t, strings.Contains(out, "n0:p0 -> "))
+	tree.Close()
+}
+
 func TestTree_Close(t *testing.T) {
 	var tree *Tree
 	tree = new(Tree)
@@ -35,6 +35,15 @@ type node struct {
 	ptrs    [order]unsafe.Pointer
 	parent  *node
 	isLeaf  bool
+
+	// size is the number of records in the subtree rooted at an
+	// internal node. It is not maintained for leaves, use count.
+	size int
+
+	// gen is the generation of the tree the node was created in. Nodes
+	// from an older generation may be shared with a Snapshot and must be
+	// copied before they are modified (see bplus_snapshot.go.)
+	gen uint64
 }
 
 // String is node's stringer method
@@ -53,6 +62,7 @@ func (n *node) String() string {
 // is to simply call bpt := new(Tree)
 type Tree struct {
 	root *node
+	gen  uint64
 }
 
 // cut finds the appropriate place to split a node that is
@@ -64,10 +74,53 @@ func cut(length int) int {
 	return length/2 + 1
 }
 
-// nextLeaf returns the next non-nil leaf in the chain (to the right) of the current leaf
-func (n *node) nextLeaf() *node {
-	if p := (*node)(n.ptrs[order-1]); p != nil && p.isLeaf {
-		return p
+// leafCursor walks the leaves of a tree from left to right. The leaves
+// are not linked to each other, because with snapshots sharing them a
+// link could only be kept up to date by writing to a shared leaf, so the
+// cursor keeps the path from the root down to the current leaf instead.
+type leafCursor struct {
+	leaf  *node
+	stack []cursorFrame
+}
+
+// cursorFrame is an internal node on the path of a leafCursor along with
+// the index of the child the cursor went down into
+type cursorFrame struct {
+	n *node
+	i int
+}
+
+// newLeafCursor returns a cursor positioned on the leftmost leaf of the
+// tree rooted at root, its leaf is nil if the tree is empty
+func newLeafCursor(root *node) *leafCursor {
+	c := new(leafCursor)
+	if root != nil {
+		c.descend(root)
+	}
+	return c
+}
+
+// descend goes down the leftmost path of the subtree at n
+func (c *leafCursor) descend(n *node) {
+	for !n.isLeaf {
+		c.stack = append(c.stack, cursorFrame{n: n})
+		n = (*node)(n.ptrs[0])
+	}
+	c.leaf = n
+}
+
+// next moves the cursor to the next leaf (to the right) and returns it,
+// or nil once the cursor has gone past the rightmost leaf
+func (c *leafCursor) next() *node {
+	c.leaf = nil
+	for len(c.stack) > 0 {
+		top := &c.stack[len(c.stack)-1]
+		if top.i < top.n.numKeys {
+			top.i++
+			c.descend((*node)(top.n.ptrs[top.i]))
+			return c.leaf
+		}
+		c.stack = c.stack[:len(c.stack)-1]
 	}
 	return nil
 }
@@ -96,24 +149,15 @@ func destroyTreeNodes(n *node) {
 
 // Size attempts to return the tree size in bytes
 func (t *Tree) Size() int64 {
-	c := findFirstLeaf(t.root)
-	if c == nil {
-		return 0
-	}
 	var s int64
 	var r *record
-	for {
-		for i := 0; i < c.numKeys; i++ {
-			r = (*record)(c.ptrs[i])
+	for c := newLeafCursor(t.root); c.leaf != nil; c.next() {
+		for i := 0; i < c.leaf.numKeys; i++ {
+			r = (*record)(c.leaf.ptrs[i])
 			if r != nil {
 				s += int64(r.Size())
 			}
 		}
-		if c.ptrs[order-1] != nil {
-			c = (*node)(c.ptrs[order-1])
-		} else {
-			break
-		}
 	}
 	return s
 }
@@ -0,0 +1,208 @@
+package bplus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrBadKeyOrder   = errors.New("bplus: keys are out of order")
+	ErrBadFillFactor = errors.New("bplus: node fill factor out of bounds")
+	ErrBadParent     = errors.New("bplus: bad parent pointer")
+	ErrBadDepth      = errors.New("bplus: leaves are not at a uniform depth")
+	ErrBadRecord     = errors.New("bplus: bad record pointer")
+	ErrBadCount      = errors.New("bplus: bad subtree count")
+)
+
+// validator holds the state of a single call to Validate, so any
+// number of trees can be validated at the same time.
+type validator struct {
+	root      *node
+	depth     int
+	haveDepth bool
+}
+
+// Validate walks the entire tree and checks that all the structural
+// invariants of a b+tree hold. It checks key ordering (within a node,
+// and against the separator keys of the parent), the fill factor of
+// every non-root node, the parent pointers, the subtree counts and that
+// every leaf is found at the same depth. It returns nil if the tree is valid, otherwise it returns an
+// error wrapping one of the ErrBad* errors describing the first violation
+// it encountered.
+func (t *Tree) Validate() error {
+	if t.root == nil {
+		return nil
+	}
+	if t.root.parent != nil {
+		return fmt.Errorf("%w: root has a non-nil parent", ErrBadParent)
+	}
+	v := &validator{root: t.root}
+	return v.checkNode(t.root, nil, nil, 0)
+}
+
+// checkNode recursively checks the node n (and all of its children.) The
+// lo and hi keys are the bounds imposed on the node by the separator keys
+// in the parent node--lo is inclusive, hi is exclusive and a nil bound is
+// unbounded.
+func (v *validator) checkNode(n *node, lo, hi *keyType, depth int) error {
+	if n.numKeys < 0 || n.numKeys > order-1 {
+		return fmt.Errorf("%w: node %s has %d keys", ErrBadFillFactor, describe(n), n.numKeys)
+	}
+	if n != v.root {
+		minKeys := cut(order) - 1
+		if n.isLeaf {
+			minKeys = cut(order - 1)
+		}
+		if n.numKeys < minKeys {
+			return fmt.Errorf(
+				"%w: node %s has %d keys, minimum is %d", ErrBadFillFactor, describe(n), n.numKeys, minKeys,
+			)
+		}
+	} else if !n.isLeaf && n.numKeys < 1 {
+		return fmt.Errorf("%w: internal root has no keys", ErrBadFillFactor)
+	}
+	for i := 0; i < n.numKeys; i++ {
+		k := n.keys[i]
+		if i > 0 && n.keys[i-1].data >= k.data {
+			return fmt.Errorf(
+				"%w: node %s key[%d]=%d >= key[%d]=%d", ErrBadKeyOrder, describe(n), i-1, n.keys[i-1].data, i, k.data,
+			)
+		}
+		if lo != nil && k.data < lo.data {
+			return fmt.Errorf("%w: node %s key %d is below separator %d", ErrBadKeyOrder, describe(n), k.data, lo.data)
+		}
+		if hi != nil && k.data >= hi.data {
+			return fmt.Errorf("%w: node %s key %d is not below separator %d", ErrBadKeyOrder, describe(n), k.data, hi.data)
+		}
+	}
+	if n.isLeaf {
+		return v.checkLeaf(n, depth)
+	}
+	var size int
+	for i := 0; i <= n.numKeys; i++ {
+		child := (*node)(n.ptrs[i])
+		if child == nil {
+			return fmt.Errorf("%w: node %s is missing child %d", ErrBadParent, describe(n), i)
+		}
+		// a child from an older generation is shared with a snapshot, so
+		// its parent pointer belongs to the snapshot and not to this tree
+		if child.gen > n.gen || (child.gen == n.gen && child.parent != n) {
+			return fmt.Errorf("%w: child %d of node %s does not point back to it", ErrBadParent, i, describe(n))
+		}
+		clo, chi := lo, hi
+		if i > 0 {
+			clo = &n.keys[i-1]
+		}
+		if i < n.numKeys {
+			chi = &n.keys[i]
+		}
+		if err := v.checkNode(child, clo, chi, depth+1); err != nil {
+			return err
+		}
+		size += child.count()
+	}
+	if n.size != size {
+		return fmt.Errorf("%w: node %s has size %d, children hold %d", ErrBadCount, describe(n), n.size, size)
+	}
+	return nil
+}
+
+// checkLeaf checks the records of a leaf and records the depth.
+func (v *validator) checkLeaf(n *node, depth int) error {
+	if !v.haveDepth {
+		v.depth, v.haveDepth = depth, true
+	} else if depth != v.depth {
+		return fmt.Errorf("%w: leaf %s is at depth %d, expected %d", ErrBadDepth, describe(n), depth, v.depth)
+	}
+	for i := 0; i < n.numKeys; i++ {
+		r := (*record)(n.ptrs[i])
+		if r == nil {
+			return fmt.Errorf("%w: leaf %s has a nil record at %d", ErrBadRecord, describe(n), i)
+		}
+		if r.Key.data != n.keys[i].data {
+			return fmt.Errorf(
+				"%w: leaf %s key %d points to record with key %d", ErrBadRecord, describe(n), n.keys[i].data, r.Key.data,
+			)
+		}
+	}
+	return nil
+}
+
+// WriteDOT writes a Graphviz (DOT) representation of the tree to the
+// provided writer. Each node is rendered as a record showing its keys
+// and the child pointers are drawn as edges. Like Validate, it keeps no
+// state between calls.
+func (t *Tree) WriteDOT(w io.Writer) error {
+	dw := &dotWriter{w: w, ids: make(map[*node]int)}
+	dw.printf("digraph bplus {\n")
+	dw.printf("\tlabel=\"B+Tree of order %d\";\n", order)
+	dw.printf("\tnode [shape=record];\n")
+	if t.root != nil {
+		dw.writeNode(t.root)
+	}
+	dw.printf("}\n")
+	return dw.err
+}
+
+// dotWriter is a small helper that remembers the first write error
+// so WriteDOT does not have to check the result of every write.
+type dotWriter struct {
+	w   io.Writer
+	ids map[*node]int
+	err error
+}
+
+func (dw *dotWriter) printf(format string, v ...any) {
+	if dw.err != nil {
+		return
+	}
+	_, dw.err = fmt.Fprintf(dw.w, format, v...)
+}
+
+// id returns a stable identifier for the node within this DOT graph
+func (dw *dotWriter) id(n *node) int {
+	id, ok := dw.ids[n]
+	if !ok {
+		id = len(dw.ids)
+		dw.ids[n] = id
+	}
+	return id
+}
+
+func (dw *dotWriter) writeNode(n *node) {
+	id := dw.id(n)
+	label := ""
+	for i := 0; i < n.numKeys; i++ {
+		if n.isLeaf {
+			label += fmt.Sprintf("%d", n.keys[i].data)
+		} else {
+			label += fmt.Sprintf("<p%d> |%d|", i, n.keys[i].data)
+		}
+		if n.isLeaf && i < n.numKeys-1 {
+			label += "|"
+		}
+	}
+	if !n.isLeaf {
+		label += fmt.Sprintf("<p%d> ", n.numKeys)
+	}
+	dw.printf("\tn%d [label=\"%s\"];\n", id, label)
+	if n.isLeaf {
+		return
+	}
+	for i := 0; i <= n.numKeys; i++ {
+		child := (*node)(n.ptrs[i])
+		dw.writeNode(child)
+		dw.printf("\tn%d:p%d -> n%d;\n", id, i, dw.id(child))
+	}
+}
+
+// describe returns a short description of a node for use in error
+// messages. Unlike the printers, it is safe to use on a broken node.
+func describe(n *node) string {
+	keys := make([]uint32, 0, order-1)
+	for i := 0; i < n.numKeys && i < order-1; i++ {
+		keys = append(keys, n.keys[i].data)
+	}
+	return fmt.Sprintf("%p%v", n, keys)
+}
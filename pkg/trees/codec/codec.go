@@ -0,0 +1,142 @@
+// Package codec holds the pieces shared by the binary formats the trees
+// are serialized in: the Codec of the values, the header and the reading
+// of lengths and bytes, which never trusts a length read from the input
+// for the size of an allocation.
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrBadMagic   = errors.New("codec: not a serialized tree of this kind")
+	ErrBadVersion = errors.New("codec: unsupported serialization version")
+	ErrBadFormat  = errors.New("codec: malformed serialized tree")
+)
+
+// MaxLength is the longest length ReadBytes accepts.
+const MaxLength = 1 << 30
+
+// chunkSize is the largest length read in a single allocation, a longer
+// one grows its buffer as the bytes are actually read
+const chunkSize = 1 << 16
+
+// Codec is used to encode and decode the values stored in a tree when it
+// is serialized.
+type Codec[T any] interface {
+	Encode(v T) ([]byte, error)
+	Decode(b []byte) (T, error)
+}
+
+// Gob is a Codec using encoding/gob. It is the codec a tree uses if none
+// has been set. When T is an interface type (such as any) the concrete
+// types stored in the tree must be registered using gob.Register.
+type Gob[T any] struct{}
+
+// Encode encodes the value using encoding/gob
+func (Gob[T]) Encode(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	// encoding a pointer to the value keeps the concrete type of
+	// the value around when T is an interface type
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes the value using encoding/gob
+func (Gob[T]) Decode(b []byte) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v)
+	return v, err
+}
+
+// ByteReader is the reader the serialized trees are read from.
+type ByteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// NewByteReader returns r if it is a ByteReader, otherwise it returns r
+// wrapped in a bufio.Reader, which may read past the end of the tree.
+func NewByteReader(r io.Reader) ByteReader {
+	if br, ok := r.(ByteReader); ok {
+		return br
+	}
+	return bufio.NewReader(r)
+}
+
+// AppendHeader appends the header of a serialized tree, made of the magic
+// bytes, the version of the format and the number of entries, to b.
+func AppendHeader(b []byte, magic string, version byte, n uint64) []byte {
+	b = append(b, magic...)
+	b = append(b, version)
+	return binary.AppendUvarint(b, n)
+}
+
+// ReadHeader reads the header written by AppendHeader and returns the
+// number of entries. It returns ErrBadMagic or ErrBadVersion if the header
+// does not match the magic bytes or the version.
+func ReadHeader(r ByteReader, magic string, version byte) (uint64, error) {
+	hdr := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return 0, Unexpected(err)
+	}
+	if string(hdr[:len(magic)]) != magic {
+		return 0, ErrBadMagic
+	}
+	if hdr[len(magic)] != version {
+		return 0, fmt.Errorf("%w: %d", ErrBadVersion, hdr[len(magic)])
+	}
+	return ReadUvarint(r)
+}
+
+// ReadUvarint reads a uvarint
+func ReadUvarint(r ByteReader) (uint64, error) {
+	x, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, Unexpected(err)
+	}
+	return x, nil
+}
+
+// ReadBytes reads the bytes written as their uvarint length followed by
+// the bytes themselves. The length is not trusted for the size of the
+// allocation, so a few corrupt bytes cannot make it allocate up to
+// MaxLength.
+func ReadBytes(r ByteReader) ([]byte, error) {
+	n, err := ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > MaxLength {
+		return nil, fmt.Errorf("%w: length %d is too long", ErrBadFormat, n)
+	}
+	if n <= chunkSize {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, Unexpected(err)
+		}
+		return b, nil
+	}
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+		return nil, Unexpected(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Unexpected turns running out of input part way through the tree into
+// an io.ErrUnexpectedEOF
+func Unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestReadHeader(t *testing.T) {
+	data := AppendHeader(nil, "TEST", 2, 300)
+	n, err := ReadHeader(bytes.NewReader(data), "TEST", 2)
+	if err != nil || n != 300 {
+		t.Fatalf("read header: got %d, %v", n, err)
+	}
+	if _, err := ReadHeader(bytes.NewReader(data), "NOPE", 2); !errors.Is(err, ErrBadMagic) {
+		t.Fatalf("expected ErrBadMagic, got %v", err)
+	}
+	if _, err := ReadHeader(bytes.NewReader(data), "TEST", 1); !errors.Is(err, ErrBadVersion) {
+		t.Fatalf("expected ErrBadVersion, got %v", err)
+	}
+	for i := 0; i < len(data); i++ {
+		if _, err := ReadHeader(bytes.NewReader(data[:i]), "TEST", 2); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("read header of %d/%d bytes: expected io.ErrUnexpectedEOF, got %v", i, len(data), err)
+		}
+	}
+}
+
+func TestReadBytes(t *testing.T) {
+	for _, n := range []int{0, 1, chunkSize, chunkSize + 1, 3 * chunkSize} {
+		want := []byte(strings.Repeat("x", n))
+		data := append(binary.AppendUvarint(nil, uint64(n)), want...)
+		got, err := ReadBytes(NewByteReader(bytes.NewReader(data)))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("read %d bytes: got %d, %v", n, len(got), err)
+		}
+		if _, err := ReadBytes(bytes.NewReader(data[:len(data)-1])); n > 0 && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("read %d truncated bytes: expected io.ErrUnexpectedEOF, got %v", n, err)
+		}
+	}
+
+	data := binary.AppendUvarint(nil, MaxLength+1)
+	if _, err := ReadBytes(bytes.NewReader(data)); !errors.Is(err, ErrBadFormat) {
+		t.Fatalf("expected ErrBadFormat, got %v", err)
+	}
+
+	// a huge length followed by a few bytes only allocates for the bytes
+	data = append(binary.AppendUvarint(nil, MaxLength), "abc"...)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := ReadBytes(bytes.NewReader(data)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Fatalf("read allocated %d bytes", n)
+	}
+}
+
+func TestGob(t *testing.T) {
+	var c Codec[any] = Gob[any]{}
+	b, err := c.Encode("value")
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	v, err := c.Decode(b)
+	if err != nil || v != "value" {
+		t.Fatalf("decode: got %v, %v", v, err)
+	}
+}
@@ -4,25 +4,27 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/scottcagno/go-scratch/pkg/trees/codec"
 )
 
-type leafNode struct {
+type leafNode[V any] struct {
 	key string
-	val interface{}
+	val V
 }
 
-func (l *leafNode) String() string {
+func (l *leafNode[V]) String() string {
 	return fmt.Sprintf("leaf.key=%s, leaf.val=%v", l.key, l.val)
 }
 
-type edge struct {
+type edge[V any] struct {
 	label byte
-	node  *node
+	node  *node[V]
 }
 
-type node struct {
+type node[V any] struct {
 	// leaf stores a possible leaf
-	leaf *leafNode
+	leaf *leafNode[V]
 
 	// prefix contains a common prefix
 	prefix string
@@ -32,18 +34,18 @@ type node struct {
 	// a fully instantiated array to save memory,
 	// since in most cases we expect the set to
 	// be rather sparse.
-	edges edges
+	edges edges[V]
 }
 
-func (n *node) String() string {
+func (n *node[V]) String() string {
 	return fmt.Sprintf("{prefix=%q, edges=%d, leaf=%s}\n", n.prefix, len(n.edges), n.leaf)
 }
 
-func (n *node) isLeaf() bool {
+func (n *node[V]) isLeaf() bool {
 	return n.leaf != nil
 }
 
-func (n *node) addEdge(e edge) {
+func (n *node[V]) addEdge(e edge[V]) {
 	num := len(n.edges)
 	idx := sort.Search(
 		num, func(i int) bool {
@@ -51,12 +53,12 @@ func (n *node) addEdge(e edge) {
 		},
 	)
 
-	n.edges = append(n.edges, edge{})
+	n.edges = append(n.edges, edge[V]{})
 	copy(n.edges[idx+1:], n.edges[idx:])
 	n.edges[idx] = e
 }
 
-func (n *node) updateEdge(label byte, node *node) {
+func (n *node[V]) updateEdge(label byte, node *node[V]) {
 	num := len(n.edges)
 	idx := sort.Search(
 		num, func(i int) bool {
@@ -70,7 +72,7 @@ func (n *node) updateEdge(label byte, node *node) {
 	panic("replacing missing edge")
 }
 
-func (n *node) getEdge(label byte) *node {
+func (n *node[V]) getEdge(label byte) *node[V] {
 	num := len(n.edges)
 	idx := sort.Search(
 		num, func(i int) bool {
@@ -83,7 +85,7 @@ func (n *node) getEdge(label byte) *node {
 	return nil
 }
 
-func (n *node) delEdge(label byte) {
+func (n *node[V]) delEdge(label byte) {
 	num := len(n.edges)
 	idx := sort.Search(
 		num, func(i int) bool {
@@ -92,12 +94,12 @@ func (n *node) delEdge(label byte) {
 	)
 	if idx < num && n.edges[idx].label == label {
 		copy(n.edges[idx:], n.edges[idx+1:])
-		n.edges[len(n.edges)-1] = edge{}
+		n.edges[len(n.edges)-1] = edge[V]{}
 		n.edges = n.edges[:len(n.edges)-1]
 	}
 }
 
-func (n *node) mergeChild() {
+func (n *node[V]) mergeChild() {
 	e := n.edges[0]
 	child := e.node
 	n.prefix = n.prefix + child.prefix
@@ -105,21 +107,21 @@ func (n *node) mergeChild() {
 	n.edges = child.edges
 }
 
-type edges []edge
+type edges[V any] []edge[V]
 
-func (e edges) Len() int {
+func (e edges[V]) Len() int {
 	return len(e)
 }
 
-func (e edges) Less(i, j int) bool {
+func (e edges[V]) Less(i, j int) bool {
 	return e[i].label < e[j].label
 }
 
-func (e edges) Swap(i, j int) {
+func (e edges[V]) Swap(i, j int) {
 	e[i], e[j] = e[j], e[i]
 }
 
-func (e edges) sortEdges() {
+func (e edges[V]) sortEdges() {
 	sort.Sort(e)
 }
 
@@ -129,16 +131,19 @@ func (e edges) sortEdges() {
 // sorted prefix-based lookups and ordered iteration, but it will
 // not be space or time optimized if the data set does not share
 // many common prefixes--in which case a hashmap or RedBlackTree
-// would be preferred.
-type Tree struct {
-	root *node
-	size int
+// would be preferred. The type parameter V is the type of the
+// values stored in the tree.
+type Tree[V any] struct {
+	root    *node[V]
+	size    int
+	watches watchIndex
+	codec   codec.Codec[V]
 }
 
 // NewTree returns a new pointer to an empty Tree (radix tree)
-func NewTree() *Tree {
-	return &Tree{
-		root: new(node),
+func NewTree[V any]() *Tree[V] {
+	return &Tree[V]{
+		root: new(node[V]),
 		size: 0,
 	}
 }
@@ -161,8 +166,9 @@ func longestPrefix(k1, k2 string) int {
 
 // Insert is used to add a new entry or update an existing entry.
 // Returns a boolean indicating true if an old value was updated.
-func (t *Tree) Insert(k string, v any) (any, bool) {
-	var parent *node
+func (t *Tree[V]) Insert(k string, v V) (V, bool) {
+	defer t.watches.notify(k)
+	var parent *node[V]
 	n := t.root
 	search := k
 	for {
@@ -176,12 +182,12 @@ func (t *Tree) Insert(k string, v any) (any, bool) {
 			}
 			// otherwise, create a
 			// new leaf, and insert
-			n.leaf = &leafNode{
+			n.leaf = &leafNode[V]{
 				key: k,
 				val: v,
 			}
 			t.size++
-			return nil, false
+			return *new(V), false
 		}
 
 		// Look for the edge
@@ -190,10 +196,10 @@ func (t *Tree) Insert(k string, v any) (any, bool) {
 
 		// No edge found, create a new one
 		if n == nil {
-			e := edge{
+			e := edge[V]{
 				label: search[0],
-				node: &node{
-					leaf: &leafNode{
+				node: &node[V]{
+					leaf: &leafNode[V]{
 						key: k,
 						val: v,
 					},
@@ -202,7 +208,7 @@ func (t *Tree) Insert(k string, v any) (any, bool) {
 			}
 			parent.addEdge(e)
 			t.size++
-			return nil, false
+			return *new(V), false
 		}
 
 		// Determine the longest prefix match for the search key
@@ -214,14 +220,14 @@ func (t *Tree) Insert(k string, v any) (any, bool) {
 
 		// Split the node
 		t.size++
-		child := &node{
+		child := &node[V]{
 			prefix: search[:common],
 		}
 		parent.updateEdge(search[0], child)
 
 		// Restore the existing node
 		child.addEdge(
-			edge{
+			edge[V]{
 				label: n.prefix[common],
 				node:  n,
 			},
@@ -229,7 +235,7 @@ func (t *Tree) Insert(k string, v any) (any, bool) {
 		n.prefix = n.prefix[common:]
 
 		// Create a new leaf node
-		leaf := &leafNode{
+		leaf := &leafNode[V]{
 			key: k,
 			val: v,
 		}
@@ -238,27 +244,27 @@ func (t *Tree) Insert(k string, v any) (any, bool) {
 		search = search[common:]
 		if len(search) == 0 {
 			child.leaf = leaf
-			return nil, false
+			return *new(V), false
 		}
 
 		// Create a new edge for the node
 		child.addEdge(
-			edge{
+			edge[V]{
 				label: search[0],
-				node: &node{
+				node: &node[V]{
 					leaf:   leaf,
 					prefix: search,
 				},
 			},
 		)
-		return nil, false
+		return *new(V), false
 	}
 }
 
 // Delete is used to delete a key. It will return the previous
 // value and a boolean indicating true if it was deleted.
-func (t *Tree) Delete(k string) (any, bool) {
-	var parent *node
+func (t *Tree[V]) Delete(k string) (V, bool) {
+	var parent *node[V]
 	var label byte
 	n := t.root
 	search := k
@@ -286,13 +292,14 @@ func (t *Tree) Delete(k string) (any, bool) {
 		}
 		search = search[len(n.prefix):]
 	}
-	return nil, false
+	return *new(V), false
 
 delete:
 	// Delete the leaf
 	leaf := n.leaf
 	n.leaf = nil
 	t.size--
+	defer t.watches.notify(k)
 
 	// Check if we need to delete this node (from the parent)
 	if parent != nil && len(n.edges) == 0 {
@@ -314,19 +321,23 @@ delete:
 // DeletePrefix is used to remove the subtree under a given prefix. It
 // returns the number of nodes were deleted. This method can be used to
 // remove a large subtree efficiently.
-func (t *Tree) DeletePrefix(k string) int {
-	return t.deletePrefixRecursive(nil, t.root, k)
+func (t *Tree[V]) DeletePrefix(k string) int {
+	num := t.deletePrefixRecursive(nil, t.root, k)
+	if num > 0 {
+		t.watches.notifyPrefix(k)
+	}
+	return num
 }
 
 // deletePrefixRecursive does a recursive subtree removal
-func (t *Tree) deletePrefixRecursive(parent, n *node, prefix string) int {
+func (t *Tree[V]) deletePrefixRecursive(parent, n *node[V], prefix string) int {
 	// Check for key exhaustion
 	if len(prefix) == 0 {
 		// Remove leaf node
 		subTreeSize := 0
 		// Recursively walk from all edges of the node (to be deleted)
 		recursiveWalk(
-			n, func(k string, v any) bool {
+			n, func(k string, v V) bool {
 				subTreeSize++
 				return false
 			},
@@ -363,7 +374,7 @@ func (t *Tree) deletePrefixRecursive(parent, n *node, prefix string) int {
 }
 
 // recursiveWalk walks the tree recursively from node n, using the WalkFn fn.
-func recursiveWalk(n *node, fn WalkFn) bool {
+func recursiveWalk[V any](n *node[V], fn WalkFn[V]) bool {
 	// Visit the leaf values, if there are any
 	if n.leaf != nil && fn(n.leaf.key, n.leaf.val) {
 		return true
@@ -379,7 +390,7 @@ func recursiveWalk(n *node, fn WalkFn) bool {
 
 // Find is used to look up a specific key, returning the
 // associated value a boolean indicating true if it was found.
-func (t *Tree) Find(k string) (any, bool) {
+func (t *Tree[V]) Find(k string) (V, bool) {
 	n := t.root
 	search := k
 	for {
@@ -408,15 +419,15 @@ func (t *Tree) Find(k string) (any, bool) {
 		}
 		search = search[len(n.prefix):]
 	}
-	return nil, false
+	return *new(V), false
 }
 
 // FindLongestPrefix is very much like Find, but instead looking
 // for an exact match, it attempts to locate the longest prefix
 // match. Upon success, it will return the last matched key, value
-// and a boolean indicating true, otherwise "", nil and false.
-func (t *Tree) FindLongestPrefix(k string) (string, any, bool) {
-	var last *leafNode
+// and a boolean indicating true, otherwise "", the zero value and false.
+func (t *Tree[V]) FindLongestPrefix(k string) (string, V, bool) {
+	var last *leafNode[V]
 	n := t.root
 	search := k
 	for {
@@ -460,16 +471,16 @@ func (t *Tree) FindLongestPrefix(k string) (string, any, bool) {
 		// )
 		return last.key, last.val, true
 	}
-	return "", nil, false
+	return "", *new(V), false
 }
 
 // Len returns the number of elements in the tree.
-func (t *Tree) Len() int {
+func (t *Tree[V]) Len() int {
 	return t.size
 }
 
 // Min returns the minimum key, and value in the tree.
-func (t *Tree) Min() (string, any, bool) {
+func (t *Tree[V]) Min() (string, V, bool) {
 	n := t.root
 	for {
 		if n.isLeaf() {
@@ -480,11 +491,11 @@ func (t *Tree) Min() (string, any, bool) {
 		}
 		n = n.edges[0].node
 	}
-	return "", nil, false
+	return "", *new(V), false
 }
 
 // Max returns the maximum key, and value in the tree.
-func (t *Tree) Max() (string, any, bool) {
+func (t *Tree[V]) Max() (string, V, bool) {
 	n := t.root
 	for {
 		if num := len(n.edges); num > 0 {
@@ -496,19 +507,21 @@ func (t *Tree) Max() (string, any, bool) {
 		}
 		break
 	}
-	return "", nil, false
+	return "", *new(V), false
 }
 
-type WalkFn func(s string, v any) bool
+// WalkFn is the type of the function called for each key and value visited
+// by the walk functions. Returning true stops the walk.
+type WalkFn[V any] func(s string, v V) bool
 
 // Walk recursively walks the tree using the WalkFn fn.
-func (t *Tree) Walk(fn WalkFn) {
+func (t *Tree[V]) Walk(fn WalkFn[V]) {
 	recursiveWalk(t.root, fn)
 }
 
 // WalkPrefix recursively walks the tree using the supplied WalkFn fn, under
 // a specific prefix supplied by the prefix string.
-func (t *Tree) WalkPrefix(prefix string, fn WalkFn) {
+func (t *Tree[V]) WalkPrefix(prefix string, fn WalkFn[V]) {
 	n := t.root
 	search := prefix
 	for {
@@ -541,7 +554,7 @@ func (t *Tree) WalkPrefix(prefix string, fn WalkFn) {
 // a specific path supplied by the path string. It is like WalkPrefix, but
 // instead of visiting all the entries under a given prefix, this walks the
 // entries above the path.
-func (t *Tree) WalkPath(path string, fn WalkFn) {
+func (t *Tree[V]) WalkPath(path string, fn WalkFn[V]) {
 	n := t.root
 	search := path
 	for {
@@ -0,0 +1,46 @@
+package radix
+
+// The methods in this file mirror the string keyed methods of the Tree,
+// for callers whose keys are byte slices. Keys are stored as strings
+// internally, so the provided slices are never retained by the tree and
+// may be reused by the caller once the method returns.
+
+// InsertBytes is like Insert, but takes a byte slice key.
+func (t *Tree[V]) InsertBytes(k []byte, v V) (V, bool) {
+	return t.Insert(string(k), v)
+}
+
+// DeleteBytes is like Delete, but takes a byte slice key.
+func (t *Tree[V]) DeleteBytes(k []byte) (V, bool) {
+	return t.Delete(string(k))
+}
+
+// DeletePrefixBytes is like DeletePrefix, but takes a byte slice prefix.
+func (t *Tree[V]) DeletePrefixBytes(k []byte) int {
+	return t.DeletePrefix(string(k))
+}
+
+// FindBytes is like Find, but takes a byte slice key.
+func (t *Tree[V]) FindBytes(k []byte) (V, bool) {
+	return t.Find(string(k))
+}
+
+// FindLongestPrefixBytes is like FindLongestPrefix, but takes and
+// returns a byte slice key.
+func (t *Tree[V]) FindLongestPrefixBytes(k []byte) ([]byte, V, bool) {
+	key, val, ok := t.FindLongestPrefix(string(k))
+	if !ok {
+		return nil, val, false
+	}
+	return []byte(key), val, true
+}
+
+// WalkPrefixBytes is like WalkPrefix, but takes a byte slice prefix.
+func (t *Tree[V]) WalkPrefixBytes(prefix []byte, fn WalkFn[V]) {
+	t.WalkPrefix(string(prefix), fn)
+}
+
+// WalkPathBytes is like WalkPath, but takes a byte slice path.
+func (t *Tree[V]) WalkPathBytes(path []byte, fn WalkFn[V]) {
+	t.WalkPath(string(path), fn)
+}
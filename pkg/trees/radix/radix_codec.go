@@ -0,0 +1,265 @@
+package radix
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/scottcagno/go-scratch/pkg/trees/codec"
+)
+
+// The errors returned by ReadFrom for a malformed tree
+var (
+	ErrBadMagic   = codec.ErrBadMagic
+	ErrBadVersion = codec.ErrBadVersion
+	ErrBadFormat  = codec.ErrBadFormat
+)
+
+// SetCodec sets the Codec used for the values of the tree by WriteTo
+// and ReadFrom. By default, a codec.Gob is used.
+func (t *Tree[V]) SetCodec(c codec.Codec[V]) {
+	t.codec = c
+}
+
+func (t *Tree[V]) getCodec() codec.Codec[V] {
+	if t.codec == nil {
+		return codec.Gob[V]{}
+	}
+	return t.codec
+}
+
+// The nodes of a serialized tree are written in depth first order, each
+// one as:
+//
+//	uvarint  length of the prefix
+//	[]byte   the prefix
+//	byte     flags, flagLeaf is set if the node holds a leaf
+//	uvarint  length of the encoded value (leaf nodes only)
+//	[]byte   the encoded value (leaf nodes only)
+//	uvarint  number of children
+//
+// and is directly followed by its children. The keys of the leaves are
+// not written, they are rebuilt from the prefixes along the path to them.
+// ReadFrom reads trees up to maxDepth nodes deep, which bounds its
+// recursion on corrupt input.
+const (
+	magic    = "RDXT"
+	version  = 1
+	flagLeaf = 0x01
+	maxDepth = 1 << 14
+)
+
+// WriteTo writes the tree to w in a compact binary format that keeps
+// the compressed prefix structure of the tree, so it can be loaded
+// without re-inserting every key. It returns the number of bytes
+// written and any error encountered.
+func (t *Tree[V]) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	e := &encoder[V]{w: bw, codec: t.getCodec()}
+	e.write(codec.AppendHeader(nil, magic, version, uint64(t.size)))
+	e.writeNode(t.root)
+	if e.err == nil {
+		e.err = bw.Flush()
+	}
+	return cw.n, e.err
+}
+
+// ReadFrom replaces the contents of the tree with a tree read from r,
+// which must have been written by WriteTo. It returns the number of bytes
+// read and any error encountered. If an error is returned the tree is left
+// as it was. If r is not an io.ByteReader it is buffered, so ReadFrom may
+// read past the end of the serialized tree.
+func (t *Tree[V]) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countReader{r: codec.NewByteReader(r)}
+	d := &decoder[V]{r: cr, codec: t.getCodec()}
+	root, size, err := d.readTree()
+	if err != nil {
+		return cr.n, err
+	}
+	t.root, t.size = root, size
+	// every key in the tree may have changed
+	t.watches.notifyPrefix("")
+	return cr.n, nil
+}
+
+type encoder[V any] struct {
+	w     *bufio.Writer
+	codec codec.Codec[V]
+	buf   [binary.MaxVarintLen64]byte
+	err   error
+}
+
+func (e *encoder[V]) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder[V]) writeByte(b byte) {
+	if e.err == nil {
+		e.err = e.w.WriteByte(b)
+	}
+}
+
+func (e *encoder[V]) writeUvarint(x uint64) {
+	n := binary.PutUvarint(e.buf[:], x)
+	e.write(e.buf[:n])
+}
+
+func (e *encoder[V]) writeNode(n *node[V]) {
+	e.writeUvarint(uint64(len(n.prefix)))
+	e.write([]byte(n.prefix))
+	if n.leaf == nil {
+		e.writeByte(0)
+	} else {
+		e.writeByte(flagLeaf)
+		if e.err != nil {
+			return
+		}
+		b, err := e.codec.Encode(n.leaf.val)
+		if err != nil {
+			e.err = fmt.Errorf("radix: encoding value of %q: %w", n.leaf.key, err)
+			return
+		}
+		e.writeUvarint(uint64(len(b)))
+		e.write(b)
+	}
+	// Delete and DeletePrefix can leave empty nodes behind,
+	// they are not written so they do not end up in the copy
+	var num int
+	for _, edge := range n.edges {
+		if !edge.node.isEmpty() {
+			num++
+		}
+	}
+	e.writeUvarint(uint64(num))
+	for _, edge := range n.edges {
+		if e.err != nil {
+			return
+		}
+		if !edge.node.isEmpty() {
+			e.writeNode(edge.node)
+		}
+	}
+}
+
+// isEmpty reports whether the node holds nothing at all
+func (n *node[V]) isEmpty() bool {
+	return n.leaf == nil && len(n.edges) == 0
+}
+
+type decoder[V any] struct {
+	r     *countReader
+	codec codec.Codec[V]
+	size  int
+}
+
+func (d *decoder[V]) readTree() (*node[V], int, error) {
+	size, err := codec.ReadHeader(d.r, magic, version)
+	if err != nil {
+		return nil, 0, err
+	}
+	root, err := d.readNode("", 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	if uint64(d.size) != size {
+		return nil, 0, fmt.Errorf("%w: header says %d entries, found %d", ErrBadFormat, size, d.size)
+	}
+	return root, d.size, nil
+}
+
+// readNode reads a node, and all of its children. The path is the key
+// leading up to the node, not including the prefix of the node itself,
+// and the depth is the number of nodes above it.
+func (d *decoder[V]) readNode(path string, depth int) (*node[V], error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: nodes are nested more than %d deep", ErrBadFormat, maxDepth)
+	}
+	prefix, err := codec.ReadBytes(d.r)
+	if err != nil {
+		return nil, err
+	}
+	if (depth == 0) != (len(prefix) == 0) {
+		return nil, fmt.Errorf("%w: bad prefix %q under %q", ErrBadFormat, prefix, path)
+	}
+	n := &node[V]{prefix: string(prefix)}
+	path += n.prefix
+
+	flags, err := d.r.ReadByte()
+	if err != nil {
+		return nil, codec.Unexpected(err)
+	}
+	if flags&^flagLeaf != 0 {
+		return nil, fmt.Errorf("%w: bad flags %#x", ErrBadFormat, flags)
+	}
+	if flags&flagLeaf != 0 {
+		b, err := codec.ReadBytes(d.r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.codec.Decode(b)
+		if err != nil {
+			return nil, fmt.Errorf("radix: decoding value of %q: %w", path, err)
+		}
+		n.leaf = &leafNode[V]{key: path, val: v}
+		d.size++
+	}
+
+	num, err := codec.ReadUvarint(d.r)
+	if err != nil {
+		return nil, err
+	}
+	if num > 256 {
+		return nil, fmt.Errorf("%w: node %q has %d children", ErrBadFormat, path, num)
+	}
+	if num > 0 {
+		n.edges = make(edges[V], 0, num)
+	}
+	for i := uint64(0); i < num; i++ {
+		child, err := d.readNode(path, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		label := child.prefix[0]
+		if i > 0 && n.edges[i-1].label >= label {
+			return nil, fmt.Errorf("%w: children of %q are out of order", ErrBadFormat, path)
+		}
+		n.edges = append(n.edges, edge[V]{label: label, node: child})
+	}
+	return n, nil
+}
+
+// countWriter counts the bytes written to the underlying writer
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countReader counts the bytes read from the underlying reader
+type countReader struct {
+	r codec.ByteReader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
@@ -0,0 +1,272 @@
+package radix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/scottcagno/go-scratch/pkg/trees/codec"
+)
+
+// sameNodes fails the test if the two subtrees do not have the same shape
+func sameNodes[V any](t *testing.T, a, b *node[V]) {
+	t.Helper()
+	if a.prefix != b.prefix || len(a.edges) != len(b.edges) || (a.leaf == nil) != (b.leaf == nil) {
+		t.Fatalf("nodes differ: %s vs %s", a, b)
+	}
+	if !reflect.DeepEqual(a.leaf, b.leaf) {
+		t.Fatalf("leaves differ: %s vs %s", a.leaf, b.leaf)
+	}
+	for i := range a.edges {
+		if a.edges[i].label != b.edges[i].label {
+			t.Fatalf("edges differ under %q", a.prefix)
+		}
+		sameNodes(t, a.edges[i].node, b.edges[i].node)
+	}
+}
+
+func roundTrip[V any](t *testing.T, src, dst *Tree[V]) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	n, err := src.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Fatalf("write: reported %d bytes, wrote %d", n, buf.Len())
+	}
+	data := buf.Bytes()
+	m, err := dst.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if m != n {
+		t.Fatalf("read: reported %d bytes, expected %d", m, n)
+	}
+	return data
+}
+
+func TestTree_WriteRead(t *testing.T) {
+	rnd := rand.New(rand.NewSource(35))
+	src := NewTree[int]()
+	for i := 0; i < 2000; i++ {
+		src.Insert(fmt.Sprintf("/api/v%d/%x", rnd.Intn(3), rnd.Intn(1000)), i)
+	}
+	src.Insert("", -1)
+	src.DeletePrefix("/api/v1/1")
+	for i := 0; i < 100; i++ {
+		src.Delete(fmt.Sprintf("/api/v2/%x", i))
+	}
+
+	dst := NewTree[int]()
+	dst.Insert("stale", 1)
+	roundTrip(t, src, dst)
+	if dst.Len() != src.Len() {
+		t.Fatalf("bad length, expected %d, got %d", src.Len(), dst.Len())
+	}
+	if _, ok := dst.Find("stale"); ok {
+		t.Fatalf("read did not replace the tree")
+	}
+	src.Walk(
+		func(k string, v int) bool {
+			if got, ok := dst.Find(k); !ok || got != v {
+				t.Fatalf("find(%q): expected %d, got %d, %v", k, v, got, ok)
+			}
+			return false
+		},
+	)
+
+	// a tree that was never modified in place round trips exactly
+	fresh := NewTree[int]()
+	src.Walk(
+		func(k string, v int) bool {
+			fresh.Insert(k, v)
+			return false
+		},
+	)
+	roundTrip(t, fresh, dst)
+	sameNodes(t, fresh.root, dst.root)
+}
+
+// stringCodec stores string values as they are
+type stringCodec struct{}
+
+func (stringCodec) Encode(v string) ([]byte, error) { return []byte(v), nil }
+func (stringCodec) Decode(b []byte) (string, error) { return string(b), nil }
+
+type point struct{ X, Y int }
+
+func TestTree_WriteReadCodec(t *testing.T) {
+	src := NewTree[string]()
+	src.SetCodec(stringCodec{})
+	for i := 0; i < 100; i++ {
+		src.Insert(strconv.Itoa(i), string(rune('a'+i%26)))
+	}
+	dst := NewTree[string]()
+	dst.SetCodec(stringCodec{})
+	data := roundTrip(t, src, dst)
+	sameNodes(t, src.root, dst.root)
+
+	// the keys are not stored, only the prefixes
+	if bytes.Contains(data, []byte("99")) {
+		t.Fatalf("expected the key %q to be compressed", "99")
+	}
+
+	// the default codec handles interface values, once registered
+	gob.Register(point{})
+	anySrc := NewTree[any]()
+	anySrc.Insert("int", 1)
+	anySrc.Insert("string", "two")
+	anySrc.Insert("point", point{3, 4})
+	anyDst := NewTree[any]()
+	roundTrip(t, anySrc, anyDst)
+	sameNodes(t, anySrc.root, anyDst.root)
+}
+
+type failCodec struct{}
+
+var errFail = errors.New("fail")
+
+func (failCodec) Encode(int) ([]byte, error) { return nil, errFail }
+func (failCodec) Decode([]byte) (int, error) { return 0, errFail }
+
+// limitWriter fails once more than n bytes have been written
+type limitWriter struct{ n int }
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, io.ErrShortWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestTree_WriteReadErrors(t *testing.T) {
+	src := NewTree[int]()
+	for i := 0; i < 100; i++ {
+		src.Insert(strconv.Itoa(i*7), i)
+	}
+	var buf bytes.Buffer
+	if _, err := src.WriteTo(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	data := buf.Bytes()
+
+	// every truncation of the data fails, and leaves the tree alone
+	dst := NewTree[int]()
+	dst.Insert("keep", 1)
+	for i := 0; i < len(data); i++ {
+		if _, err := dst.ReadFrom(bytes.NewReader(data[:i])); err == nil {
+			t.Fatalf("read of %d/%d bytes did not fail", i, len(data))
+		}
+		if _, ok := dst.Find("keep"); !ok || dst.Len() != 1 {
+			t.Fatalf("failed read modified the tree")
+		}
+	}
+
+	if _, err := dst.ReadFrom(bytes.NewReader([]byte("nope!"))); !errors.Is(err, ErrBadMagic) {
+		t.Fatalf("expected ErrBadMagic, got %v", err)
+	}
+	bad := append([]byte(nil), data...)
+	bad[len(magic)] = version + 1
+	if _, err := dst.ReadFrom(bytes.NewReader(bad)); !errors.Is(err, ErrBadVersion) {
+		t.Fatalf("expected ErrBadVersion, got %v", err)
+	}
+	bad = append([]byte(nil), data...)
+	bad[len(magic)+1]++ // the number of entries
+	if _, err := dst.ReadFrom(bytes.NewReader(bad)); !errors.Is(err, ErrBadFormat) {
+		t.Fatalf("expected ErrBadFormat, got %v", err)
+	}
+
+	dst.SetCodec(failCodec{})
+	if _, err := dst.ReadFrom(bytes.NewReader(data)); !errors.Is(err, errFail) {
+		t.Fatalf("expected a decode error, got %v", err)
+	}
+	src.SetCodec(failCodec{})
+	if _, err := src.WriteTo(io.Discard); !errors.Is(err, errFail) {
+		t.Fatalf("expected an encode error, got %v", err)
+	}
+	src.SetCodec(nil)
+	if n, err := src.WriteTo(&limitWriter{n: 10}); err == nil || n != 10 {
+		t.Fatalf("expected a short write of 10 bytes, got %d, %v", n, err)
+	}
+}
+
+func TestTree_ReadCorrupt(t *testing.T) {
+	dst := NewTree[int]()
+
+	// a huge length followed by a few bytes only allocates for the bytes
+	data := binary.AppendUvarint(codec.AppendHeader(nil, magic, version, 0), codec.MaxLength)
+	data = append(data, "abc"...)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := dst.ReadFrom(bytes.NewReader(data)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Fatalf("read allocated %d bytes", n)
+	}
+
+	// a long chain of nodes is cut off at maxDepth
+	data = append(codec.AppendHeader(nil, magic, version, 0), 0, 0, 1)
+	for i := 0; i <= maxDepth; i++ {
+		data = append(data, 1, 'a', 0, 1)
+	}
+	if _, err := dst.ReadFrom(bytes.NewReader(data)); !errors.Is(err, ErrBadFormat) {
+		t.Fatalf("expected ErrBadFormat, got %v", err)
+	}
+	if dst.Len() != 0 {
+		t.Fatalf("failed read modified the tree")
+	}
+}
+
+func FuzzTree_ReadFrom(f *testing.F) {
+	src := NewTree[string]()
+	src.SetCodec(stringCodec{})
+	for _, k := range []string{"", "romane", "romanus", "romulus", "rubens", "ruber", "rubicon"} {
+		src.Insert(k, strings.ToUpper(k))
+	}
+	var buf bytes.Buffer
+	if _, err := src.WriteTo(&buf); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(buf.Bytes())
+	f.Add(buf.Bytes()[:buf.Len()/2])
+	f.Add(binary.AppendUvarint(codec.AppendHeader(nil, magic, version, 1), codec.MaxLength))
+
+	f.Fuzz(
+		func(t *testing.T, data []byte) {
+			dst := NewTree[string]()
+			dst.SetCodec(stringCodec{})
+			if _, err := dst.ReadFrom(bytes.NewReader(data)); err != nil {
+				return
+			}
+			// whatever was read must round trip
+			var n int
+			dst.Walk(
+				func(string, string) bool {
+					n++
+					return false
+				},
+			)
+			if n != dst.Len() {
+				t.Fatalf("walked %d entries, Len is %d", n, dst.Len())
+			}
+			again := NewTree[string]()
+			again.SetCodec(stringCodec{})
+			roundTrip(t, dst, again)
+		},
+	)
+}
@@ -0,0 +1,419 @@
+package radix
+
+import (
+	"strings"
+)
+
+// ImmutableTree is a persistent version of the radix Tree. Every change
+// to an ImmutableTree returns a new tree, leaving the original untouched.
+// The new tree shares every node with the original except for the ones
+// on the path to the change, which are copied (path copying) so taking
+// a "snapshot" of an ImmutableTree is as cheap as keeping a pointer to it.
+//
+// Because a tree never changes once it has been created, any number of
+// goroutines can read from it without any locking. A common pattern is
+// to keep the current tree in an atomic.Pointer, have readers Load it on
+// every request, and have a (single) writer Store the committed result
+// of a Txn whenever the data changes.
+type ImmutableTree[V any] struct {
+	root *node[V]
+	size int
+}
+
+// NewImmutableTree returns a new pointer to an empty ImmutableTree
+func NewImmutableTree[V any]() *ImmutableTree[V] {
+	return &ImmutableTree[V]{
+		root: new(node[V]),
+		size: 0,
+	}
+}
+
+// Txn starts a new transaction that can be used to make a batch of
+// changes to the tree. The changes are not visible to the tree, or to
+// any other reader, until they are committed.
+func (t *ImmutableTree[V]) Txn() *Txn[V] {
+	return &Txn[V]{
+		root: t.root,
+		size: t.size,
+	}
+}
+
+// Insert is used to add a new entry or update an existing entry. It
+// returns the new tree, the previous value and a boolean indicating
+// true if an old value was updated.
+func (t *ImmutableTree[V]) Insert(k string, v V) (*ImmutableTree[V], V, bool) {
+	txn := t.Txn()
+	old, ok := txn.Insert(k, v)
+	return txn.Commit(), old, ok
+}
+
+// Delete is used to delete a key. It returns the new tree, the previous
+// value and a boolean indicating true if it was deleted.
+func (t *ImmutableTree[V]) Delete(k string) (*ImmutableTree[V], V, bool) {
+	txn := t.Txn()
+	old, ok := txn.Delete(k)
+	return txn.Commit(), old, ok
+}
+
+// DeletePrefix is used to remove the subtree under a given prefix. It
+// returns the new tree and the number of entries that were deleted.
+func (t *ImmutableTree[V]) DeletePrefix(k string) (*ImmutableTree[V], int) {
+	txn := t.Txn()
+	num := txn.DeletePrefix(k)
+	return txn.Commit(), num
+}
+
+// view returns a (read only) Tree sharing the root of the ImmutableTree
+// so the lookup and walk functions do not need to be duplicated.
+func (t *ImmutableTree[V]) view() *Tree[V] {
+	return &Tree[V]{root: t.root, size: t.size}
+}
+
+// Len returns the number of elements in the tree.
+func (t *ImmutableTree[V]) Len() int {
+	return t.size
+}
+
+// Find is used to look up a specific key, returning the
+// associated value a boolean indicating true if it was found.
+func (t *ImmutableTree[V]) Find(k string) (V, bool) {
+	return t.view().Find(k)
+}
+
+// FindLongestPrefix is like Tree.FindLongestPrefix
+func (t *ImmutableTree[V]) FindLongestPrefix(k string) (string, V, bool) {
+	return t.view().FindLongestPrefix(k)
+}
+
+// Min returns the minimum key, and value in the tree.
+func (t *ImmutableTree[V]) Min() (string, V, bool) {
+	return t.view().Min()
+}
+
+// Max returns the maximum key, and value in the tree.
+func (t *ImmutableTree[V]) Max() (string, V, bool) {
+	return t.view().Max()
+}
+
+// Walk recursively walks the tree using the WalkFn fn.
+func (t *ImmutableTree[V]) Walk(fn WalkFn[V]) {
+	recursiveWalk(t.root, fn)
+}
+
+// WalkPrefix is like Tree.WalkPrefix
+func (t *ImmutableTree[V]) WalkPrefix(prefix string, fn WalkFn[V]) {
+	t.view().WalkPrefix(prefix, fn)
+}
+
+// WalkPath is like Tree.WalkPath
+func (t *ImmutableTree[V]) WalkPath(path string, fn WalkFn[V]) {
+	t.view().WalkPath(path, fn)
+}
+
+// Txn is a transaction on an ImmutableTree. It batches a set of changes
+// and applies them all at once when it is committed. Nodes are copied
+// the first time the transaction changes them, but once a node belongs
+// to the transaction it is modified in place, so a batch of changes to
+// the same area of the tree is a lot cheaper than the same changes made
+// one at a time with ImmutableTree.Insert. A Txn is not safe for use by
+// more than one goroutine at a time.
+type Txn[V any] struct {
+	root *node[V]
+	size int
+
+	// writable tracks the nodes that have been created by this
+	// transaction, and can therefore be modified in place.
+	writable map[*node[V]]struct{}
+}
+
+// Len returns the number of elements in the transaction's tree.
+func (t *Txn[V]) Len() int {
+	return t.size
+}
+
+// Find is used to look up a specific key in the transaction's tree,
+// including any changes that have not been committed yet.
+func (t *Txn[V]) Find(k string) (V, bool) {
+	return (&Tree[V]{root: t.root, size: t.size}).Find(k)
+}
+
+// Commit finalizes the transaction and returns the new tree. The
+// transaction can still be used after it is committed, but any changes
+// made after that will copy the nodes they touch, leaving the committed
+// tree untouched.
+func (t *Txn[V]) Commit() *ImmutableTree[V] {
+	nt := &ImmutableTree[V]{
+		root: t.root,
+		size: t.size,
+	}
+	t.writable = nil
+	return nt
+}
+
+// track marks a node created by the transaction as writable.
+func (t *Txn[V]) track(n *node[V]) *node[V] {
+	if t.writable == nil {
+		t.writable = make(map[*node[V]]struct{})
+	}
+	t.writable[n] = struct{}{}
+	return n
+}
+
+// writeNode returns a node that is safe to modify. If the node was
+// created by this transaction it is returned as is, otherwise a copy
+// of it is returned.
+func (t *Txn[V]) writeNode(n *node[V]) *node[V] {
+	if _, ok := t.writable[n]; ok {
+		return n
+	}
+	nc := &node[V]{
+		leaf:   n.leaf,
+		prefix: n.prefix,
+	}
+	if len(n.edges) != 0 {
+		nc.edges = make(edges[V], len(n.edges))
+		copy(nc.edges, n.edges)
+	}
+	return t.track(nc)
+}
+
+// mergeChild is like node.mergeChild, but copies the edges of the child
+// instead of sharing them, because the child may belong to another tree.
+func (t *Txn[V]) mergeChild(n *node[V]) {
+	child := n.edges[0].node
+	n.prefix = n.prefix + child.prefix
+	n.leaf = child.leaf
+	n.edges = nil
+	if len(child.edges) != 0 {
+		n.edges = make(edges[V], len(child.edges))
+		copy(n.edges, child.edges)
+	}
+}
+
+// Insert is used to add a new entry or update an existing entry.
+// Returns a boolean indicating true if an old value was updated.
+func (t *Txn[V]) Insert(k string, v V) (V, bool) {
+	root, old, updated := t.insert(t.root, k, k, v)
+	t.root = root
+	if !updated {
+		t.size++
+	}
+	return old, updated
+}
+
+// insert does a recursive insertion, returning the (possibly copied)
+// node that should replace n in its parent.
+func (t *Txn[V]) insert(n *node[V], k, search string, v V) (*node[V], V, bool) {
+	// Handle key exhaustion
+	if len(search) == 0 {
+		var old V
+		var updated bool
+		if n.isLeaf() {
+			old, updated = n.leaf.val, true
+		}
+		// leaves may be shared, so they are always replaced
+		nc := t.writeNode(n)
+		nc.leaf = &leafNode[V]{
+			key: k,
+			val: v,
+		}
+		return nc, old, updated
+	}
+
+	// Look for the edge
+	label := search[0]
+	child := n.getEdge(label)
+
+	// No edge found, create a new one
+	if child == nil {
+		nc := t.writeNode(n)
+		nc.addEdge(
+			edge[V]{
+				label: label,
+				node: t.track(
+					&node[V]{
+						leaf: &leafNode[V]{
+							key: k,
+							val: v,
+						},
+						prefix: search,
+					},
+				),
+			},
+		)
+		return nc, *new(V), false
+	}
+
+	// Determine the longest prefix match for the search key
+	common := longestPrefix(search, child.prefix)
+	if common == len(child.prefix) {
+		newChild, old, updated := t.insert(child, k, search[common:], v)
+		nc := t.writeNode(n)
+		nc.updateEdge(label, newChild)
+		return nc, old, updated
+	}
+
+	// Split the node
+	nc := t.writeNode(n)
+	splitNode := t.track(
+		&node[V]{
+			prefix: search[:common],
+		},
+	)
+	nc.updateEdge(label, splitNode)
+
+	// Restore the existing child, under the split node
+	modChild := t.writeNode(child)
+	splitNode.addEdge(
+		edge[V]{
+			label: modChild.prefix[common],
+			node:  modChild,
+		},
+	)
+	modChild.prefix = modChild.prefix[common:]
+
+	// Create a new leaf node
+	leaf := &leafNode[V]{
+		key: k,
+		val: v,
+	}
+
+	// If the new key is a subset, add it to the split node
+	search = search[common:]
+	if len(search) == 0 {
+		splitNode.leaf = leaf
+		return nc, *new(V), false
+	}
+
+	// Create a new edge for the node
+	splitNode.addEdge(
+		edge[V]{
+			label: search[0],
+			node: t.track(
+				&node[V]{
+					leaf:   leaf,
+					prefix: search,
+				},
+			),
+		},
+	)
+	return nc, *new(V), false
+}
+
+// Delete is used to delete a key. It will return the previous
+// value and a boolean indicating true if it was deleted.
+func (t *Txn[V]) Delete(k string) (V, bool) {
+	root, leaf := t.delete(t.root, k, true)
+	if root == nil {
+		return *new(V), false
+	}
+	t.root = root
+	t.size--
+	return leaf.val, true
+}
+
+// delete does a recursive deletion. It returns nil if the key could
+// not be found, otherwise it returns the node that should replace n
+// in its parent along with the deleted leaf.
+func (t *Txn[V]) delete(n *node[V], search string, isRoot bool) (*node[V], *leafNode[V]) {
+	// Check for key exhaustion
+	if len(search) == 0 {
+		if !n.isLeaf() {
+			return nil, nil
+		}
+		oldLeaf := n.leaf
+
+		// Delete the leaf, and merge this node if we can
+		nc := t.writeNode(n)
+		nc.leaf = nil
+		if !isRoot && len(nc.edges) == 1 {
+			t.mergeChild(nc)
+		}
+		return nc, oldLeaf
+	}
+
+	// Look for an edge
+	label := search[0]
+	child := n.getEdge(label)
+	if child == nil || !strings.HasPrefix(search, child.prefix) {
+		return nil, nil
+	}
+
+	// Consume the search prefix
+	newChild, leaf := t.delete(child, search[len(child.prefix):], false)
+	if newChild == nil {
+		return nil, nil
+	}
+	nc := t.replaceChild(n, label, newChild, isRoot)
+	return nc, leaf
+}
+
+// DeletePrefix is used to remove the subtree under a given prefix. It
+// returns the number of entries that were deleted.
+func (t *Txn[V]) DeletePrefix(prefix string) int {
+	root, num := t.deletePrefix(t.root, prefix, true)
+	if root == nil {
+		return 0
+	}
+	t.root = root
+	t.size -= num
+	return num
+}
+
+// deletePrefix does a recursive subtree removal. It returns nil if there
+// is nothing under the prefix, otherwise it returns the node that should
+// replace n in its parent along with the number of entries removed.
+func (t *Txn[V]) deletePrefix(n *node[V], prefix string, isRoot bool) (*node[V], int) {
+	// Check for key exhaustion
+	if len(prefix) == 0 {
+		num := 0
+		recursiveWalk(
+			n, func(k string, v V) bool {
+				num++
+				return false
+			},
+		)
+		if num == 0 {
+			return nil, 0
+		}
+		nc := t.writeNode(n)
+		nc.leaf = nil
+		nc.edges = nil
+		return nc, num
+	}
+
+	// Look for an edge
+	label := prefix[0]
+	child := n.getEdge(label)
+	if child == nil || (!strings.HasPrefix(child.prefix, prefix) && !strings.HasPrefix(prefix, child.prefix)) {
+		return nil, 0
+	}
+
+	// Consume the search prefix
+	if len(child.prefix) > len(prefix) {
+		prefix = prefix[len(prefix):]
+	} else {
+		prefix = prefix[len(child.prefix):]
+	}
+	newChild, num := t.deletePrefix(child, prefix, false)
+	if newChild == nil {
+		return nil, 0
+	}
+	return t.replaceChild(n, label, newChild, isRoot), num
+}
+
+// replaceChild returns a writable copy of n with the child at label
+// replaced by newChild. If newChild has become empty it is removed
+// instead, and n is merged with its last child if it can be.
+func (t *Txn[V]) replaceChild(n *node[V], label byte, newChild *node[V], isRoot bool) *node[V] {
+	nc := t.writeNode(n)
+	if newChild.leaf == nil && len(newChild.edges) == 0 {
+		nc.delEdge(label)
+		if !isRoot && len(nc.edges) == 1 && !nc.isLeaf() {
+			t.mergeChild(nc)
+		}
+		return nc
+	}
+	nc.updateEdge(label, newChild)
+	return nc
+}
@@ -0,0 +1,180 @@
+package radix
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// contents walks the tree and returns all of its keys and values
+func contents(t *ImmutableTree[int]) ([]string, map[string]int) {
+	var keys []string
+	vals := make(map[string]int)
+	t.Walk(
+		func(k string, v int) bool {
+			keys = append(keys, k)
+			vals[k] = v
+			return false
+		},
+	)
+	return keys, vals
+}
+
+// checkContents fails the test if the tree does not hold exactly the model
+func checkContents(t *testing.T, tree *ImmutableTree[int], model map[string]int) {
+	t.Helper()
+	keys, vals := contents(tree)
+	if !sort.StringsAreSorted(keys) {
+		t.Fatalf("walk is not in sorted order: %v", keys)
+	}
+	if len(keys) != len(model) || tree.Len() != len(model) {
+		t.Fatalf("Bad length, expected %v, got %v (len=%v)", len(model), len(keys), tree.Len())
+	}
+	for k, v := range model {
+		if vals[k] != v {
+			t.Fatalf("walk: expected %q=%d, got %d", k, v, vals[k])
+		}
+		if got, ok := tree.Find(k); !ok || got != v {
+			t.Fatalf("find(%q): expected %d, got %d, %v", k, v, got, ok)
+		}
+	}
+}
+
+func TestImmutableTree_Insert(t *testing.T) {
+	t0 := NewImmutableTree[int]()
+	t1, _, updated := t0.Insert("foo", 1)
+	if updated {
+		t.Fatalf("unexpected update")
+	}
+	t2, _, _ := t1.Insert("foobar", 2)
+	t3, old, updated := t2.Insert("foo", 3)
+	if !updated || old != 1 {
+		t.Fatalf("expected update of %d, got %d, %v", 1, old, updated)
+	}
+	checkContents(t, t0, map[string]int{})
+	checkContents(t, t1, map[string]int{"foo": 1})
+	checkContents(t, t2, map[string]int{"foo": 1, "foobar": 2})
+	checkContents(t, t3, map[string]int{"foo": 3, "foobar": 2})
+
+	t4, old, deleted := t3.Delete("foo")
+	if !deleted || old != 3 {
+		t.Fatalf("expected delete of %d, got %d, %v", 3, old, deleted)
+	}
+	checkContents(t, t3, map[string]int{"foo": 3, "foobar": 2})
+	checkContents(t, t4, map[string]int{"foobar": 2})
+	if _, _, deleted = t4.Delete("foo"); deleted {
+		t.Fatalf("unexpected delete of a missing key")
+	}
+}
+
+func TestImmutableTree_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	tree := NewImmutableTree[int]()
+	model := make(map[string]int)
+	type version struct {
+		tree  *ImmutableTree[int]
+		model map[string]int
+	}
+	var versions []version
+	for i := 0; i < 5000; i++ {
+		k := fmt.Sprintf("%x", r.Intn(1024))
+		switch op := r.Intn(10); {
+		case op < 6:
+			tree, _, _ = tree.Insert(k, i)
+			model[k] = i
+		case op < 9:
+			tree, _, _ = tree.Delete(k)
+			delete(model, k)
+		default:
+			var num int
+			tree, num = tree.DeletePrefix(k[:1])
+			for mk := range model {
+				if mk[0] == k[0] {
+					delete(model, mk)
+					num--
+				}
+			}
+			if num != 0 {
+				t.Fatalf("delete prefix(%q): bad count", k[:1])
+			}
+		}
+		if i%250 == 0 {
+			m := make(map[string]int, len(model))
+			for k, v := range model {
+				m[k] = v
+			}
+			versions = append(versions, version{tree, m})
+		}
+	}
+	checkContents(t, tree, model)
+	for _, v := range versions {
+		checkContents(t, v.tree, v.model)
+	}
+}
+
+func TestTxn_Commit(t *testing.T) {
+	base := NewImmutableTree[int]()
+	for i, w := range []string{"ant", "anti", "antique", "re", "relax", "sub"} {
+		base, _, _ = base.Insert(w, i)
+	}
+	txn := base.Txn()
+	txn.Insert("review", 10)
+	txn.Insert("rewind", 11)
+	txn.Delete("ant")
+	if n := txn.DeletePrefix("sub"); n != 1 {
+		t.Fatalf("delete prefix: expected %d, got %d", 1, n)
+	}
+	if v, ok := txn.Find("review"); !ok || v != 10 {
+		t.Fatalf("txn should see its own writes, got %d, %v", v, ok)
+	}
+	if _, ok := base.Find("review"); ok {
+		t.Fatalf("base tree should not see uncommitted writes")
+	}
+	committed := txn.Commit()
+	checkContents(t, committed, map[string]int{"anti": 1, "antique": 2, "re": 3, "relax": 4, "review": 10, "rewind": 11})
+	checkContents(t, base, map[string]int{"ant": 0, "anti": 1, "antique": 2, "re": 3, "relax": 4, "sub": 5})
+
+	// changes after the commit must not leak into the committed tree
+	txn.Insert("review", 12)
+	txn.Delete("relax")
+	if v, _ := committed.Find("review"); v != 10 {
+		t.Fatalf("committed tree was modified, got %d", v)
+	}
+	checkContents(t, txn.Commit(), map[string]int{"anti": 1, "antique": 2, "re": 3, "review": 12, "rewind": 11})
+}
+
+func TestImmutableTree_ConcurrentReaders(t *testing.T) {
+	var current atomic.Pointer[ImmutableTree[int]]
+	current.Store(NewImmutableTree[int]())
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				tree := current.Load()
+				var n int
+				tree.Walk(
+					func(k string, v int) bool {
+						n++
+						return false
+					},
+				)
+				if n != tree.Len() {
+					t.Errorf("reader saw %d entries, expected %d", n, tree.Len())
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		txn := current.Load().Txn()
+		txn.Insert(fmt.Sprintf("key-%d", i), i)
+		txn.Delete(fmt.Sprintf("key-%d", i/2))
+		current.Store(txn.Commit())
+	}
+	wg.Wait()
+}
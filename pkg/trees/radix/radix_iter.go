@@ -0,0 +1,343 @@
+package radix
+
+import (
+	"sort"
+	"strings"
+)
+
+// Iterator is a pull style iterator that visits the entries of a tree in
+// ascending key order. An iterator starts out positioned at the minimum
+// key of the tree, and can be moved using SeekPrefix or SeekLowerBound.
+//
+// An Iterator over a Tree is only valid as long as the tree is not
+// modified. An Iterator over an ImmutableTree is always valid.
+type Iterator[V any] struct {
+	node  *node[V]
+	stack []edges[V]
+}
+
+// Iterator returns an Iterator positioned at the start of the tree
+func (t *Tree[V]) Iterator() *Iterator[V] {
+	return &Iterator[V]{node: t.root}
+}
+
+// Iterator returns an Iterator positioned at the start of the tree
+func (t *ImmutableTree[V]) Iterator() *Iterator[V] {
+	return &Iterator[V]{node: t.root}
+}
+
+// getLowerBoundEdge returns the index and node of the first edge with
+// a label that is greater than or equal to the provided label, or -1
+// and nil if there is no such edge.
+func (n *node[V]) getLowerBoundEdge(label byte) (int, *node[V]) {
+	num := len(n.edges)
+	idx := sort.Search(
+		num, func(i int) bool {
+			return n.edges[i].label >= label
+		},
+	)
+	if idx < num {
+		return idx, n.edges[idx].node
+	}
+	return -1, nil
+}
+
+// comparePrefix compares the prefix of a node to the search key, only
+// looking at as many bytes of the search key as the prefix has.
+func comparePrefix(prefix, search string) int {
+	if len(prefix) < len(search) {
+		return strings.Compare(prefix, search[:len(prefix)])
+	}
+	return strings.Compare(prefix, search)
+}
+
+// SeekPrefix moves the iterator so that it only visits the entries
+// with keys that begin with the provided prefix.
+func (i *Iterator[V]) SeekPrefix(prefix string) {
+	i.stack = nil
+	n := i.node
+	search := prefix
+	for {
+		// Check for key exhaustion
+		if len(search) == 0 {
+			i.node = n
+			return
+		}
+
+		// Look for an edge
+		n = n.getEdge(search[0])
+		if n == nil {
+			i.node = nil
+			return
+		}
+
+		// Consume the search prefix
+		if strings.HasPrefix(search, n.prefix) {
+			search = search[len(n.prefix):]
+		} else if strings.HasPrefix(n.prefix, search) {
+			// The child is under our search prefix
+			i.node = n
+			return
+		} else {
+			i.node = nil
+			return
+		}
+	}
+}
+
+// recurseMin pushes everything to the right of the minimum path of n on
+// to the stack and returns the node holding the minimum leaf under n.
+func (i *Iterator[V]) recurseMin(n *node[V]) *node[V] {
+	for {
+		if n.leaf != nil {
+			return n
+		}
+		num := len(n.edges)
+		if num == 0 {
+			return nil
+		}
+		if num > 1 {
+			i.stack = append(i.stack, n.edges[1:])
+		}
+		n = n.edges[0].node
+	}
+}
+
+// SeekLowerBound moves the iterator so that the next entry it visits is
+// the smallest key that is greater than or equal to the provided key.
+// Iteration then continues in order to the end of the tree. It must be
+// called on a fresh iterator, it cannot be combined with SeekPrefix.
+func (i *Iterator[V]) SeekLowerBound(key string) {
+	// the stack is explicitly set to be non-nil, so Next will not
+	// start over at i.node
+	i.stack = []edges[V]{}
+	n := i.node
+	i.node = nil
+	if n == nil {
+		return
+	}
+	search := key
+
+	found := func(n *node[V]) {
+		i.stack = append(i.stack, edges[V]{edge[V]{node: n}})
+	}
+
+	for {
+		cmp := comparePrefix(n.prefix, search)
+		if cmp > 0 {
+			// everything under this node is greater than
+			// the key, so the minimum is the lower bound
+			if m := i.recurseMin(n); m != nil {
+				found(m)
+			}
+			return
+		}
+		if cmp < 0 {
+			// everything under this node is less than the key
+			return
+		}
+
+		// the prefix matches, check for an exact match
+		if n.leaf != nil && n.leaf.key == key {
+			found(n)
+			return
+		}
+
+		// Consume the search prefix
+		search = search[len(n.prefix):]
+		if len(search) == 0 {
+			// the key is a prefix of every key under this node, so
+			// once again the minimum is the lower bound
+			if m := i.recurseMin(n); m != nil {
+				found(m)
+			}
+			return
+		}
+
+		// Take the lower bound edge, and keep all the edges
+		// greater than it on the stack for later
+		idx, lb := n.getLowerBoundEdge(search[0])
+		if lb == nil {
+			return
+		}
+		if idx+1 < len(n.edges) {
+			i.stack = append(i.stack, n.edges[idx+1:])
+		}
+		n = lb
+	}
+}
+
+// Next returns the next key and value in ascending order along with a
+// boolean indicating true, or "", the zero value and false once the
+// iterator has been exhausted.
+func (i *Iterator[V]) Next() (string, V, bool) {
+	// Initialize our stack if needed
+	if i.stack == nil && i.node != nil {
+		i.stack = []edges[V]{{edge[V]{node: i.node}}}
+	}
+	for len(i.stack) > 0 {
+		// Pop the first node off of the last set of edges
+		n := len(i.stack)
+		last := i.stack[n-1]
+		elem := last[0].node
+		if len(last) > 1 {
+			i.stack[n-1] = last[1:]
+		} else {
+			i.stack = i.stack[:n-1]
+		}
+
+		// Push the children, they come after the leaf
+		if len(elem.edges) > 0 {
+			i.stack = append(i.stack, elem.edges)
+		}
+		if elem.leaf != nil {
+			return elem.leaf.key, elem.leaf.val, true
+		}
+	}
+	return "", *new(V), false
+}
+
+// ReverseIterator is a pull style iterator that visits the entries of a
+// tree in descending key order. It starts out positioned at the maximum
+// key of the tree, and can be moved using SeekPrefix or SeekReverseLowerBound.
+//
+// Like Iterator, a ReverseIterator over a Tree is only valid as long
+// as the tree is not modified.
+type ReverseIterator[V any] struct {
+	i *Iterator[V]
+
+	// expanded tracks the nodes whose children have already been pushed
+	// on to the stack. A node's leaf comes before its children, so walking
+	// backwards it is only visited once all of its children have been.
+	expanded map[*node[V]]struct{}
+}
+
+// ReverseIterator returns a ReverseIterator positioned at the end of the tree
+func (t *Tree[V]) ReverseIterator() *ReverseIterator[V] {
+	return &ReverseIterator[V]{i: &Iterator[V]{node: t.root}}
+}
+
+// ReverseIterator returns a ReverseIterator positioned at the end of the tree
+func (t *ImmutableTree[V]) ReverseIterator() *ReverseIterator[V] {
+	return &ReverseIterator[V]{i: &Iterator[V]{node: t.root}}
+}
+
+// SeekPrefix moves the iterator so that it only visits the entries
+// with keys that begin with the provided prefix.
+func (ri *ReverseIterator[V]) SeekPrefix(prefix string) {
+	ri.i.SeekPrefix(prefix)
+	ri.expanded = nil
+}
+
+// SeekReverseLowerBound moves the iterator so that the next entry it visits
+// is the largest key that is less than or equal to the provided key.
+// Iteration then continues in descending order to the start of the tree.
+// Like SeekLowerBound, it cannot be combined with SeekPrefix.
+func (ri *ReverseIterator[V]) SeekReverseLowerBound(key string) {
+	ri.i.stack = []edges[V]{}
+	n := ri.i.node
+	ri.i.node = nil
+	ri.expanded = make(map[*node[V]]struct{})
+	if n == nil {
+		return
+	}
+	search := key
+
+	found := func(n *node[V]) {
+		ri.i.stack = append(ri.i.stack, edges[V]{edge[V]{node: n}})
+		// mark the node as expanded, so none of its children
+		// (which are all greater than the key) are visited
+		ri.expanded[n] = struct{}{}
+	}
+
+	for {
+		cmp := comparePrefix(n.prefix, search)
+		if cmp < 0 {
+			// everything under this node is less than the key, so the
+			// maximum is the lower bound. Previous will find it for us
+			// as long as the node is not marked as expanded.
+			ri.i.stack = append(ri.i.stack, edges[V]{edge[V]{node: n}})
+			return
+		}
+		if cmp > 0 {
+			// everything under this node is greater than the key
+			return
+		}
+
+		// the prefix matches, so any leaf here is less than or equal
+		// to the key, it can never be greater
+		if n.leaf != nil {
+			if n.leaf.key == key || len(n.edges) == 0 {
+				found(n)
+				return
+			}
+			// the leaf comes before the children, so it goes on to the
+			// stack first. the children we want are added below
+			found(n)
+		}
+
+		// Consume the search prefix
+		search = search[len(n.prefix):]
+		if len(search) == 0 {
+			// every child is greater than the key
+			return
+		}
+
+		// Keep all the edges less than the lower bound edge
+		// on the stack, then follow the lower bound edge
+		idx, lb := n.getLowerBoundEdge(search[0])
+		if idx == -1 {
+			idx = len(n.edges)
+		}
+		if idx > 0 {
+			ri.i.stack = append(ri.i.stack, n.edges[:idx])
+		}
+		if lb == nil {
+			return
+		}
+		n = lb
+	}
+}
+
+// Previous returns the previous key and value in descending order along
+// with a boolean indicating true, or "", the zero value and false once the
+// iterator has been exhausted.
+func (ri *ReverseIterator[V]) Previous() (string, V, bool) {
+	// Initialize our stack if needed
+	if ri.i.stack == nil && ri.i.node != nil {
+		ri.i.stack = []edges[V]{{edge[V]{node: ri.i.node}}}
+	}
+	if ri.expanded == nil {
+		ri.expanded = make(map[*node[V]]struct{})
+	}
+	for len(ri.i.stack) > 0 {
+		// Inspect the last node of the last set of edges
+		n := len(ri.i.stack)
+		last := ri.i.stack[n-1]
+		m := len(last)
+		elem := last[m-1].node
+
+		// If the node has children we have not visited yet, leave it on
+		// the stack and visit them first
+		_, expanded := ri.expanded[elem]
+		if len(elem.edges) > 0 && !expanded {
+			ri.expanded[elem] = struct{}{}
+			ri.i.stack = append(ri.i.stack, elem.edges)
+			continue
+		}
+
+		// Pop the node off of the stack
+		if m > 1 {
+			ri.i.stack[n-1] = last[:m-1]
+		} else {
+			ri.i.stack = ri.i.stack[:n-1]
+		}
+		if expanded {
+			delete(ri.expanded, elem)
+		}
+		if elem.leaf != nil {
+			return elem.leaf.key, elem.leaf.val, true
+		}
+	}
+	return "", *new(V), false
+}
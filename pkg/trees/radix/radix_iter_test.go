@@ -0,0 +1,239 @@
+package radix
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// collect drains the iterator and returns the keys it visited
+func collect(it *Iterator[int]) []string {
+	var keys []string
+	for k, _, ok := it.Next(); ok; k, _, ok = it.Next() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// collectReverse drains the reverse iterator and returns the keys it visited
+func collectReverse(it *ReverseIterator[int]) []string {
+	var keys []string
+	for k, _, ok := it.Previous(); ok; k, _, ok = it.Previous() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func reversed(keys []string) []string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[len(keys)-1-i] = k
+	}
+	return out
+}
+
+func TestIterator_SeekPrefix(t *testing.T) {
+	r := NewTree[int]()
+	keys := []string{"", "foo", "foo/bar", "foo/baz", "foobar", "zip", "zipzap"}
+	for i, k := range keys {
+		r.Insert(k, i)
+	}
+	cases := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", keys},
+		{"f", []string{"foo", "foo/bar", "foo/baz", "foobar"}},
+		{"foo", []string{"foo", "foo/bar", "foo/baz", "foobar"}},
+		{"foo/", []string{"foo/bar", "foo/baz"}},
+		{"foo/b", []string{"foo/bar", "foo/baz"}},
+		{"foo/bar", []string{"foo/bar"}},
+		{"foo/bart", nil},
+		{"zipz", []string{"zipzap"}},
+		{"nope", nil},
+	}
+	for _, c := range cases {
+		it := r.Iterator()
+		it.SeekPrefix(c.prefix)
+		if got := collect(it); !equalKeys(got, c.want) {
+			t.Fatalf("prefix %q: expected %v, got %v", c.prefix, c.want, got)
+		}
+		ri := r.ReverseIterator()
+		ri.SeekPrefix(c.prefix)
+		if got := collectReverse(ri); !equalKeys(got, reversed(c.want)) {
+			t.Fatalf("reverse prefix %q: expected %v, got %v", c.prefix, reversed(c.want), got)
+		}
+	}
+}
+
+func TestIterator_SeekLowerBound(t *testing.T) {
+	r := NewTree[int]()
+	keys := []string{"00000", "00001", "00004", "00010", "00020", "20020", "a", "ab", "abc", "b"}
+	for i, k := range keys {
+		r.Insert(k, i)
+	}
+	cases := []struct {
+		search string
+		want   []string
+	}{
+		{"", keys},
+		{"00000", keys},
+		{"00003", keys[2:]},
+		{"00010", keys[3:]},
+		{"0002", keys[4:]},
+		{"1", keys[5:]},
+		{"a", keys[6:]},
+		{"aa", keys[7:]},
+		{"abcd", keys[9:]},
+		{"c", nil},
+	}
+	for _, c := range cases {
+		it := r.Iterator()
+		it.SeekLowerBound(c.search)
+		if got := collect(it); !equalKeys(got, c.want) {
+			t.Fatalf("lower bound %q: expected %v, got %v", c.search, c.want, got)
+		}
+	}
+}
+
+func TestIterator_SeekReverseLowerBound(t *testing.T) {
+	r := NewTree[int]()
+	keys := []string{"00000", "00001", "00004", "00010", "00020", "20020", "a", "ab", "abc", "b"}
+	for i, k := range keys {
+		r.Insert(k, i)
+	}
+	cases := []struct {
+		search string
+		want   []string
+	}{
+		{"", nil},
+		{"00000", keys[:1]},
+		{"00003", keys[:2]},
+		{"00010", keys[:4]},
+		{"0002", keys[:4]},
+		{"1", keys[:5]},
+		{"a", keys[:7]},
+		{"aa", keys[:7]},
+		{"abcd", keys[:9]},
+		{"c", keys},
+	}
+	for _, c := range cases {
+		ri := r.ReverseIterator()
+		ri.SeekReverseLowerBound(c.search)
+		if got := collectReverse(ri); !equalKeys(got, reversed(c.want)) {
+			t.Fatalf("reverse lower bound %q: expected %v, got %v", c.search, reversed(c.want), got)
+		}
+	}
+}
+
+func TestIterator_Random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(31))
+	randKey := func() string {
+		b := make([]byte, 1+rnd.Intn(6))
+		for i := range b {
+			b[i] = "abc/"[rnd.Intn(4)]
+		}
+		return string(b)
+	}
+	r := NewTree[int]()
+	model := make(map[string]struct{})
+	for i := 0; i < 500; i++ {
+		k := randKey()
+		r.Insert(k, i)
+		model[k] = struct{}{}
+	}
+	var sorted []string
+	for k := range model {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	if got := collect(r.Iterator()); !equalKeys(got, sorted) {
+		t.Fatalf("iterator does not match the sorted keys")
+	}
+	if got := collectReverse(r.ReverseIterator()); !equalKeys(got, reversed(sorted)) {
+		t.Fatalf("reverse iterator does not match the sorted keys")
+	}
+	for i := 0; i < 500; i++ {
+		search := randKey()
+		lo := sort.SearchStrings(sorted, search)
+		it := r.Iterator()
+		it.SeekLowerBound(search)
+		if got := collect(it); !equalKeys(got, sorted[lo:]) {
+			t.Fatalf("lower bound %q: expected %v, got %v", search, sorted[lo:], got)
+		}
+		hi := lo
+		if hi < len(sorted) && sorted[hi] == search {
+			hi++
+		}
+		ri := r.ReverseIterator()
+		ri.SeekReverseLowerBound(search)
+		if got := collectReverse(ri); !equalKeys(got, reversed(sorted[:hi])) {
+			t.Fatalf("reverse lower bound %q: expected %v, got %v", search, reversed(sorted[:hi]), got)
+		}
+		var want []string
+		for _, k := range sorted {
+			if strings.HasPrefix(k, search) {
+				want = append(want, k)
+			}
+		}
+		it = r.Iterator()
+		it.SeekPrefix(search)
+		if got := collect(it); !equalKeys(got, want) {
+			t.Fatalf("prefix %q: expected %v, got %v", search, want, got)
+		}
+	}
+}
+
+func TestIterator_Paginate(t *testing.T) {
+	r := NewImmutableTree[int]()
+	var keys []string
+	for _, k := range []string{"user/1", "user/10", "user/2", "user/3", "user/4", "group/1", "zone/1"} {
+		r, _, _ = r.Insert(k, len(k))
+		if strings.HasPrefix(k, "user/") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	// fetch pages of two keys under the prefix, starting
+	// each page after the last key of the previous one
+	var pages [][]string
+	last := "user/"
+	for {
+		it := r.Iterator()
+		it.SeekLowerBound(last + "\x00")
+		var page []string
+		for k, _, ok := it.Next(); ok && len(page) < 2; k, _, ok = it.Next() {
+			if !strings.HasPrefix(k, "user/") {
+				break
+			}
+			page = append(page, k)
+		}
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, page)
+		last = page[len(page)-1]
+	}
+	var got []string
+	for _, page := range pages {
+		got = append(got, page...)
+	}
+	if len(pages) != 3 || !equalKeys(got, keys) {
+		t.Fatalf("expected %v in 3 pages, got %v", keys, pages)
+	}
+}
@@ -0,0 +1,148 @@
+package radix
+
+// KeyValue is a single key and value pair found by one of the
+// searches that can match more than one key.
+type KeyValue[V any] struct {
+	Key   string
+	Value V
+}
+
+// FindWithin returns every key in the tree within the provided (Levenshtein)
+// edit distance of the key, along with its value, in sorted key order. The
+// distance is the number of single byte insertions, deletions or substitutions
+// it takes to turn one key into the other.
+//
+// Rather than checking every key, the search walks the tree and carries one
+// row of the edit distance matrix for each byte of the path it is on. Keys
+// that share a prefix share the work done for it, and a subtree is skipped
+// as soon as every entry in the row is over the maximum distance.
+func (t *Tree[V]) FindWithin(key string, maxDist int) []KeyValue[V] {
+	if maxDist < 0 {
+		return nil
+	}
+	// the first row is the distance from the empty string
+	row := make([]int, len(key)+1)
+	for i := range row {
+		row[i] = i
+	}
+	var found []KeyValue[V]
+	fuzzyWalk(t.root, key, maxDist, row, &found)
+	return found
+}
+
+// fuzzyWalk adds every key under n within maxDist of the key to found.
+// The row holds the edit distances between the path leading up to n
+// and every prefix of the key.
+func fuzzyWalk[V any](n *node[V], key string, maxDist int, row []int, found *[]KeyValue[V]) {
+	for i := 0; i < len(n.prefix); i++ {
+		row = nextRow(row, key, n.prefix[i])
+		if minRow(row) > maxDist {
+			return
+		}
+	}
+	if n.leaf != nil && row[len(key)] <= maxDist {
+		*found = append(*found, KeyValue[V]{Key: n.leaf.key, Value: n.leaf.val})
+	}
+	for _, e := range n.edges {
+		fuzzyWalk(e.node, key, maxDist, row, found)
+	}
+}
+
+// nextRow returns the row of the edit distance matrix that follows prev
+// when the byte c is added to the path. The previous row is left as is,
+// because it is shared with the sibling nodes.
+func nextRow(prev []int, key string, c byte) []int {
+	row := make([]int, len(prev))
+	row[0] = prev[0] + 1
+	for i := 1; i < len(row); i++ {
+		cost := 1
+		if key[i-1] == c {
+			cost = 0
+		}
+		row[i] = prev[i-1] + cost
+		if d := prev[i] + 1; d < row[i] {
+			row[i] = d
+		}
+		if d := row[i-1] + 1; d < row[i] {
+			row[i] = d
+		}
+	}
+	return row
+}
+
+func minRow(row []int) int {
+	min := row[0]
+	for _, d := range row[1:] {
+		if d < min {
+			min = d
+		}
+	}
+	return min
+}
+
+// Match returns every key in the tree matching the provided glob pattern,
+// along with its value, in sorted key order. In the pattern, '*' matches
+// any sequence of bytes (including an empty one, and including '/') and
+// '?' matches any single byte. Every other byte only matches itself.
+//
+// The pattern is run as a small state machine alongside a walk of the
+// tree, so a subtree is skipped as soon as no part of the pattern can
+// match the path leading to it.
+func (t *Tree[V]) Match(pattern string) []KeyValue[V] {
+	states := make([]bool, len(pattern)+1)
+	states[0] = true
+	skipStars(pattern, states)
+	var found []KeyValue[V]
+	globWalk(t.root, pattern, states, &found)
+	return found
+}
+
+// globWalk adds every key under n matching the pattern to found. The states
+// mark the positions in the pattern that the path leading up to n can be at.
+func globWalk[V any](n *node[V], pattern string, states []bool, found *[]KeyValue[V]) {
+	for i := 0; i < len(n.prefix); i++ {
+		var ok bool
+		if states, ok = nextStates(pattern, states, n.prefix[i]); !ok {
+			return
+		}
+	}
+	if n.leaf != nil && states[len(pattern)] {
+		*found = append(*found, KeyValue[V]{Key: n.leaf.key, Value: n.leaf.val})
+	}
+	for _, e := range n.edges {
+		globWalk(e.node, pattern, states, found)
+	}
+}
+
+// nextStates returns the positions in the pattern that follow the provided
+// states when the byte c is added to the path, and a boolean indicating
+// false if there are none left.
+func nextStates(pattern string, states []bool, c byte) ([]bool, bool) {
+	next := make([]bool, len(states))
+	var ok bool
+	for p := 0; p < len(pattern); p++ {
+		if !states[p] {
+			continue
+		}
+		switch pattern[p] {
+		case '*':
+			next[p], ok = true, true
+		case '?':
+			next[p+1], ok = true, true
+		case c:
+			next[p+1], ok = true, true
+		}
+	}
+	skipStars(pattern, next)
+	return next, ok
+}
+
+// skipStars marks the position after every '*' that can be reached as
+// reachable too, because a '*' may match an empty sequence.
+func skipStars(pattern string, states []bool) {
+	for p := 0; p < len(pattern); p++ {
+		if states[p] && pattern[p] == '*' {
+			states[p+1] = true
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package radix
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// levenshtein is the plain dynamic programming edit distance
+func levenshtein(a, b string) int {
+	row := make([]int, len(b)+1)
+	for i := range row {
+		row[i] = i
+	}
+	for i := 0; i < len(a); i++ {
+		row = nextRow(row, b, a[i])
+	}
+	return row[len(b)]
+}
+
+// globMatch is a naive recursive glob matcher
+func globMatch(pattern, s string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '*':
+		return globMatch(pattern[1:], s) || (s != "" && globMatch(pattern, s[1:]))
+	case '?':
+		return s != "" && globMatch(pattern[1:], s[1:])
+	}
+	return s != "" && s[0] == pattern[0] && globMatch(pattern[1:], s[1:])
+}
+
+func foundKeys(found []KeyValue[int]) []string {
+	var keys []string
+	for _, kv := range found {
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
+
+func TestTree_FindWithin(t *testing.T) {
+	r := NewTree[int]()
+	for i, k := range []string{"apple", "apply", "ape", "apricot", "banana", "bandana", "app", "maple"} {
+		r.Insert(k, i)
+	}
+	cases := []struct {
+		key  string
+		dist int
+		want []string
+	}{
+		{"apple", 0, []string{"apple"}},
+		{"apple", 1, []string{"apple", "apply"}},
+		{"apple", 2, []string{"ape", "app", "apple", "apply", "maple"}},
+		{"banana", 1, []string{"banana", "bandana"}},
+		{"xyz", 2, nil},
+		{"apple", -1, nil},
+	}
+	for _, c := range cases {
+		if got := foundKeys(r.FindWithin(c.key, c.dist)); !equalKeys(got, c.want) {
+			t.Fatalf("find within(%q, %d): expected %v, got %v", c.key, c.dist, c.want, got)
+		}
+	}
+	if found := r.FindWithin("aple", 1); len(found) != 3 || found[0].Value != 2 || found[1].Value != 0 || found[2].Value != 7 {
+		t.Fatalf("bad values %v", found)
+	}
+}
+
+func TestTree_Match(t *testing.T) {
+	r := NewTree[int]()
+	keys := []string{"", "a", "ab", "abc", "api/v1/users", "api/v1/users/1", "api/v2/groups", "api/v2/users", "b"}
+	for i, k := range keys {
+		r.Insert(k, i)
+	}
+	cases := []struct {
+		pattern string
+		want    []string
+	}{
+		{"", []string{""}},
+		{"*", keys},
+		{"?", []string{"a", "b"}},
+		{"a?", []string{"ab"}},
+		{"a*", keys[1:8]},
+		{"api/v?/users", []string{"api/v1/users", "api/v2/users"}},
+		{"api/*/users", []string{"api/v1/users", "api/v2/users"}},
+		{"api/*s", []string{"api/v1/users", "api/v2/groups", "api/v2/users"}},
+		{"*/1", []string{"api/v1/users/1"}},
+		{"**c", []string{"abc"}},
+		{"x*", nil},
+	}
+	for _, c := range cases {
+		if got := foundKeys(r.Match(c.pattern)); !equalKeys(got, c.want) {
+			t.Fatalf("match(%q): expected %v, got %v", c.pattern, c.want, got)
+		}
+	}
+}
+
+func TestTree_SearchRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(34))
+	randString := func(alphabet string, n int) string {
+		b := make([]byte, rnd.Intn(n))
+		for i := range b {
+			b[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+	r := NewTree[int]()
+	var keys []string
+	for i := 0; i < 1000; i++ {
+		k := randString("abcd", 8)
+		if _, updated := r.Insert(k, i); !updated {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for i := 0; i < 200; i++ {
+		key, dist := randString("abcd", 8), rnd.Intn(4)
+		var want []string
+		for _, k := range keys {
+			if levenshtein(k, key) <= dist {
+				want = append(want, k)
+			}
+		}
+		if got := foundKeys(r.FindWithin(key, dist)); !equalKeys(got, want) {
+			t.Fatalf("find within(%q, %d): expected %v, got %v", key, dist, want, got)
+		}
+
+		pattern := randString("ab*?", 6)
+		want = nil
+		for _, k := range keys {
+			if globMatch(pattern, k) {
+				want = append(want, k)
+			}
+		}
+		if got := foundKeys(r.Match(pattern)); !equalKeys(got, want) {
+			t.Fatalf("match(%q): expected %v, got %v", pattern, want, got)
+		}
+	}
+}
@@ -1,14 +1,16 @@
 package radix
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
 	"testing"
 )
 
 func TestNewTree(t *testing.T) {
 
 	t.Logf("Creating new radix tree...")
-	rt := NewTree()
+	rt := NewTree[any]()
 	if rt.Len() != 0 {
 		t.Fatalf("Bad length, expected %v, got %v", 0, rt.Len())
 	}
@@ -72,3 +74,125 @@ func TestNewTree(t *testing.T) {
 		t.Fatalf("Bad length, expected %v, got %v", len(entries)-1, rt.Len())
 	}
 }
+
+func TestTree_Typed(t *testing.T) {
+	rt := NewTree[int]()
+	words := []string{"ant", "anti", "antique", "antiquate", "re", "relax", "review", "rewind", "sub", "subs"}
+	for i, w := range words {
+		if _, updated := rt.Insert(w, i); updated {
+			t.Fatalf("insert(%q): unexpected update", w)
+		}
+	}
+	if old, updated := rt.Insert("re", 100); !updated || old != 4 {
+		t.Fatalf("insert(%q): expected update of %d, got %d, %v", "re", 4, old, updated)
+	}
+	if v, ok := rt.Find("review"); !ok || v != 6 {
+		t.Fatalf("find(%q): expected %d, got %d, %v", "review", 6, v, ok)
+	}
+	if v, ok := rt.Find("rev"); ok || v != 0 {
+		t.Fatalf("find(%q): expected zero value, got %d, %v", "rev", v, ok)
+	}
+	k, v, ok := rt.FindLongestPrefix("antiques")
+	if !ok || k != "antique" || v != 2 {
+		t.Fatalf("longest prefix: got %q, %d, %v", k, v, ok)
+	}
+	var sum int
+	rt.WalkPrefix(
+		"anti", func(k string, v int) bool {
+			sum += v
+			return false
+		},
+	)
+	if sum != 1+2+3 {
+		t.Fatalf("walk prefix: expected %d, got %d", 1+2+3, sum)
+	}
+	if n := rt.DeletePrefix("sub"); n != 2 {
+		t.Fatalf("delete prefix: expected %d, got %d", 2, n)
+	}
+	if rt.Len() != len(words)-2 {
+		t.Fatalf("Bad length, expected %v, got %v", len(words)-2, rt.Len())
+	}
+}
+
+func TestTree_Bytes(t *testing.T) {
+	rt := NewTree[string]()
+	key := []byte("www.example.com")
+	rt.InsertBytes(key, "example")
+	// the tree must not hold on to the callers slice
+	key[0] = 'x'
+	if v, ok := rt.FindBytes([]byte("www.example.com")); !ok || v != "example" {
+		t.Fatalf("find bytes: got %q, %v", v, ok)
+	}
+	k, _, ok := rt.FindLongestPrefixBytes([]byte("www.example.com/api"))
+	if !ok || string(k) != "www.example.com" {
+		t.Fatalf("longest prefix bytes: got %q, %v", k, ok)
+	}
+	if v, ok := rt.DeleteBytes([]byte("www.example.com")); !ok || v != "example" {
+		t.Fatalf("delete bytes: got %q, %v", v, ok)
+	}
+	if rt.Len() != 0 {
+		t.Fatalf("Bad length, expected %v, got %v", 0, rt.Len())
+	}
+}
+
+func TestTree_Validate(t *testing.T) {
+	rt := NewTree[int]()
+	if err := rt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	keys := make([]string, 2000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%x", r.Int63n(1<<20))
+		rt.Insert(keys[i], i)
+		if err := rt.Validate(); err != nil {
+			t.Fatalf("validate after insert(%q): %v", keys[i], err)
+		}
+	}
+	for _, k := range keys[:len(keys)/2] {
+		rt.Delete(k)
+		if err := rt.Validate(); err != nil {
+			t.Fatalf("validate after delete(%q): %v", k, err)
+		}
+	}
+}
+
+func TestTree_ValidateCorrupt(t *testing.T) {
+	rt := NewTree[int]()
+	for i, k := range []string{"romane", "romanus", "romulus", "rubens", "ruber"} {
+		rt.Insert(k, i)
+	}
+	if err := rt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// swap two edges of the root
+	n := rt.root.edges[0].node
+	n.edges[0], n.edges[1] = n.edges[1], n.edges[0]
+	if err := rt.Validate(); !errors.Is(err, ErrBadEdge) {
+		t.Errorf("expected %v, got %v", ErrBadEdge, err)
+	}
+	n.edges[0], n.edges[1] = n.edges[1], n.edges[0]
+
+	// change the key of a leaf
+	l := rt.root
+	for l.leaf == nil {
+		l = l.edges[0].node
+	}
+	key := l.leaf.key
+	l.leaf.key = "remus"
+	if err := rt.Validate(); !errors.Is(err, ErrBadKey) {
+		t.Errorf("expected %v, got %v", ErrBadKey, err)
+	}
+	l.leaf.key = key
+
+	// miscount the leaves
+	rt.size++
+	if err := rt.Validate(); !errors.Is(err, ErrBadLength) {
+		t.Errorf("expected %v, got %v", ErrBadLength, err)
+	}
+	rt.size--
+	if err := rt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,64 @@
+package radix
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBadEdge   = errors.New("radix: bad edge")
+	ErrBadKey    = errors.New("radix: leaf key does not match its path")
+	ErrBadMerge  = errors.New("radix: node without a leaf has a single edge")
+	ErrBadLength = errors.New("radix: bad tree length")
+)
+
+// Validate walks the entire tree and checks that all the structural
+// invariants of a radix tree hold. It checks that the edges of every node
+// are sorted by label and that each label is the first byte of the prefix
+// of its node, that the key of every leaf is the path leading to it, that
+// the paths are compressed (no node but the root has a single edge and no
+// leaf) and that Len matches the number of leaves. It returns nil if the
+// tree is valid, otherwise it returns an error wrapping one of the ErrBad*
+// errors describing the first violation it encountered.
+func (t *Tree[V]) Validate() error {
+	if t.root == nil {
+		return nil
+	}
+	leaves, err := t.checkNode(t.root, t.root.prefix)
+	if err != nil {
+		return err
+	}
+	if leaves != t.size {
+		return fmt.Errorf("%w: Len is %d, the tree has %d leaves", ErrBadLength, t.size, leaves)
+	}
+	return nil
+}
+
+// checkNode recursively checks the node n (and all of its children,) which
+// is found at the provided path. It returns the number of leaves found.
+func (t *Tree[V]) checkNode(n *node[V], path string) (int, error) {
+	var leaves int
+	if n.leaf != nil {
+		if n.leaf.key != path {
+			return 0, fmt.Errorf("%w: leaf key %q at path %q", ErrBadKey, n.leaf.key, path)
+		}
+		leaves++
+	}
+	if n != t.root && n.leaf == nil && len(n.edges) == 1 {
+		return 0, fmt.Errorf("%w: at path %q", ErrBadMerge, path)
+	}
+	for i, e := range n.edges {
+		if i > 0 && n.edges[i-1].label >= e.label {
+			return 0, fmt.Errorf("%w: labels %q and %q out of order at path %q", ErrBadEdge, n.edges[i-1].label, e.label, path)
+		}
+		if e.node == nil || e.node.prefix == "" || e.node.prefix[0] != e.label {
+			return 0, fmt.Errorf("%w: label %q does not start the prefix of its node at path %q", ErrBadEdge, e.label, path)
+		}
+		num, err := t.checkNode(e.node, path+e.node.prefix)
+		if err != nil {
+			return 0, err
+		}
+		leaves += num
+	}
+	return leaves, nil
+}
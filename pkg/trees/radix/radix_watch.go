@@ -0,0 +1,94 @@
+package radix
+
+import (
+	"sync"
+)
+
+// watchIndex keeps track of the channels handed out by Watch. The channels
+// are stored in a radix tree of their own, keyed by the watched prefix, so
+// a change to a key only has to walk the path of that key through the index
+// to find every interested watcher, no matter how many watchers there are.
+type watchIndex struct {
+	mu  sync.Mutex
+	idx *Tree[[]chan struct{}]
+}
+
+// Watch returns a channel that is closed the next time any key beginning
+// with the provided prefix is inserted, updated or deleted. A watch fires
+// only once; to keep watching, call Watch again after the channel has been
+// closed (before reading the tree, so no change can be missed.)
+//
+// Like the rest of the Tree, Watch must not be called at the same time as
+// a write, but it may be called at the same time as any of the reads and
+// any number of goroutines can wait on the returned channel.
+func (t *Tree[V]) Watch(prefix string) <-chan struct{} {
+	return t.watches.add(prefix)
+}
+
+// WatchBytes is like Watch, but takes a byte slice prefix.
+func (t *Tree[V]) WatchBytes(prefix []byte) <-chan struct{} {
+	return t.watches.add(string(prefix))
+}
+
+// add registers and returns a new channel watching the prefix
+func (w *watchIndex) add(prefix string) <-chan struct{} {
+	ch := make(chan struct{})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.idx == nil {
+		w.idx = &Tree[[]chan struct{}]{root: new(node[[]chan struct{}])}
+	}
+	chans, _ := w.idx.Find(prefix)
+	w.idx.Insert(prefix, append(chans, ch))
+	return ch
+}
+
+// notify fires (and removes) every watch with a prefix of the key k
+func (w *watchIndex) notify(k string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.idx == nil || w.idx.Len() == 0 {
+		return
+	}
+	var fired []string
+	w.idx.WalkPath(
+		k, func(p string, _ []chan struct{}) bool {
+			fired = append(fired, p)
+			return false
+		},
+	)
+	w.fire(fired)
+}
+
+// notifyPrefix fires (and removes) every watch with a prefix of the
+// provided prefix, as well as every watch on a key under the prefix.
+func (w *watchIndex) notifyPrefix(prefix string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.idx == nil || w.idx.Len() == 0 {
+		return
+	}
+	var fired []string
+	collect := func(p string, _ []chan struct{}) bool {
+		fired = append(fired, p)
+		return false
+	}
+	w.idx.WalkPath(prefix, collect)
+	w.idx.WalkPrefix(prefix, collect)
+	w.fire(fired)
+}
+
+// fire closes and removes the channels watching the provided prefixes. The
+// prefixes are collected first, because the index cannot be modified in
+// the middle of a walk. A prefix may be in the list more than once.
+func (w *watchIndex) fire(prefixes []string) {
+	for _, p := range prefixes {
+		chans, ok := w.idx.Delete(p)
+		if !ok {
+			continue
+		}
+		for _, ch := range chans {
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package radix
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func fired(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestTree_Watch(t *testing.T) {
+	r := NewTree[int]()
+	r.Insert("svc/api/port", 80)
+	r.Insert("svc/api/host", 1)
+	r.Insert("svc/db/port", 5432)
+
+	all := r.Watch("")
+	svc := r.Watch("svc/")
+	api := r.Watch("svc/api/")
+	api2 := r.Watch("svc/api/")
+	db := r.Watch("svc/db")
+	port := r.Watch("svc/api/port")
+	missing := r.Watch("svc/cache/")
+
+	// an update under svc/api/ fires everything on its path, and nothing else
+	r.Insert("svc/api/port", 8080)
+	for name, ch := range map[string]<-chan struct{}{"all": all, "svc": svc, "api": api, "api2": api2, "port": port} {
+		if !fired(ch) {
+			t.Fatalf("expected watch %q to fire", name)
+		}
+	}
+	if fired(db) || fired(missing) {
+		t.Fatalf("unexpected watch fired")
+	}
+
+	// a watch only fires once, so a new one is needed
+	api = r.Watch("svc/api/")
+	r.Insert("svc/db/user", 1)
+	if !fired(db) || fired(api) || fired(missing) {
+		t.Fatalf("expected only the db watch to fire")
+	}
+
+	// deleting a key that does not exist is not a change
+	if _, ok := r.Delete("svc/api/nope"); ok {
+		t.Fatalf("unexpected delete")
+	}
+	if fired(api) {
+		t.Fatalf("unexpected watch fired")
+	}
+	r.Delete("svc/api/host")
+	if !fired(api) {
+		t.Fatalf("expected the api watch to fire on delete")
+	}
+
+	// the watched prefix does not have to exist yet
+	r.Insert("svc/cache/size", 64)
+	if !fired(missing) {
+		t.Fatalf("expected the cache watch to fire on insert")
+	}
+}
+
+func TestTree_WatchDeletePrefix(t *testing.T) {
+	r := NewTree[int]()
+	for i := 0; i < 10; i++ {
+		r.Insert(fmt.Sprintf("a/b/%d", i), i)
+	}
+	r.Insert("a/c", 1)
+	above := r.Watch("a/")
+	below := r.Watch("a/b/3")
+	other := r.Watch("a/c")
+
+	if n := r.DeletePrefix("a/x"); n != 0 {
+		t.Fatalf("expected nothing to be deleted, got %d", n)
+	}
+	if fired(above) || fired(below) {
+		t.Fatalf("unexpected watch fired")
+	}
+	if n := r.DeletePrefix("a/b"); n != 10 {
+		t.Fatalf("expected 10 to be deleted, got %d", n)
+	}
+	if !fired(above) || !fired(below) || fired(other) {
+		t.Fatalf("expected the watches above and below the prefix to fire")
+	}
+}
+
+func TestTree_WatchMany(t *testing.T) {
+	r := NewTree[int]()
+	var wg sync.WaitGroup
+	var chans []<-chan struct{}
+	for i := 0; i < 1000; i++ {
+		chans = append(chans, r.Watch(fmt.Sprintf("key/%03d", i)))
+	}
+	for _, ch := range chans[:10] {
+		wg.Add(1)
+		go func(ch <-chan struct{}) {
+			defer wg.Done()
+			<-ch
+		}(ch)
+	}
+	for i := 0; i < 10; i++ {
+		r.Insert(fmt.Sprintf("key/%03d", i), i)
+	}
+	wg.Wait()
+	for i, ch := range chans[10:] {
+		if fired(ch) {
+			t.Fatalf("unexpected watch %d fired", i+10)
+		}
+	}
+	if n := r.watches.idx.Len(); n != 990 {
+		t.Fatalf("expected 990 remaining watches, got %d", n)
+	}
+}
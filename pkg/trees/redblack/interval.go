@@ -0,0 +1,113 @@
+package redblack
+
+import (
+	"errors"
+)
+
+var ErrBadInterval = errors.New("redblack: interval starts after it ends")
+
+// Interval is a closed interval, from Lo up to and including Hi,
+// along with the value stored for it in an IntervalTree.
+type Interval[K, V any] struct {
+	Lo, Hi K
+	Value  V
+}
+
+// IntervalTree is a red-black tree of intervals that can find every
+// interval overlapping a point, or another interval, in O(log n + m)
+// time for m results. The intervals are ordered by their start, then
+// by their end. Each node also keeps the interval ending last in its
+// subtree, which lets a search skip any subtree that ends too early.
+//
+// An interval is identified by its end points, so inserting the same
+// interval twice replaces the value stored for it.
+type IntervalTree[K, V any] struct {
+	tree *RBTree[Interval[K, V]]
+	cmp  func(a, b K) int
+}
+
+// NewIntervalTree creates and returns a new interval tree with the end
+// points ordered by the provided comparison function.
+func NewIntervalTree[K, V any](cmp func(a, b K) int) *IntervalTree[K, V] {
+	t := New[Interval[K, V]](
+		func(a, b Interval[K, V]) int {
+			if c := cmp(a.Lo, b.Lo); c != 0 {
+				return c
+			}
+			return cmp(a.Hi, b.Hi)
+		},
+	)
+	t.end = func(a, b Interval[K, V]) int {
+		return cmp(a.Hi, b.Hi)
+	}
+	return &IntervalTree[K, V]{tree: t, cmp: cmp}
+}
+
+// NewOrderedIntervalTree creates and returns a new interval tree
+// for any ordered type of end points.
+func NewOrderedIntervalTree[K Ordered, V any]() *IntervalTree[K, V] {
+	return NewIntervalTree[K, V](NewOrdered[K]().cmp)
+}
+
+// InsertInterval inserts the interval from lo up to and including hi,
+// replacing the value if the interval is already in the tree. It returns
+// ErrBadInterval if lo is greater than hi.
+func (t *IntervalTree[K, V]) InsertInterval(lo, hi K, value V) error {
+	if t.cmp(lo, hi) > 0 {
+		return ErrBadInterval
+	}
+	t.tree.Put(Interval[K, V]{Lo: lo, Hi: hi, Value: value})
+	return nil
+}
+
+// DeleteInterval removes the interval from lo up to and including hi,
+// and returns its value along with a boolean indicating false if the
+// interval was not in the tree.
+func (t *IntervalTree[K, V]) DeleteInterval(lo, hi K) (V, bool) {
+	iv, ok := t.tree.Del(Interval[K, V]{Lo: lo, Hi: hi})
+	return iv.Value, ok
+}
+
+// Len returns the number of intervals in the tree
+func (t *IntervalTree[K, V]) Len() int {
+	return t.tree.Len()
+}
+
+// Scan iterates the intervals in the tree in order, stopping
+// early if iter returns false
+func (t *IntervalTree[K, V]) Scan(iter func(iv Interval[K, V]) bool) {
+	t.tree.Scan(iter)
+}
+
+// Overlapping returns every interval containing the point, in order
+func (t *IntervalTree[K, V]) Overlapping(point K) []Interval[K, V] {
+	return t.OverlappingRange(point, point)
+}
+
+// OverlappingRange returns every interval sharing at least one point with
+// the interval from lo up to and including hi, in order. It returns nil if
+// lo is greater than hi.
+func (t *IntervalTree[K, V]) OverlappingRange(lo, hi K) []Interval[K, V] {
+	if t.cmp(lo, hi) > 0 {
+		return nil
+	}
+	var found []Interval[K, V]
+	t.overlapping(t.tree.root, lo, hi, &found)
+	return found
+}
+
+func (t *IntervalTree[K, V]) overlapping(n *node[Interval[K, V]], lo, hi K, found *[]Interval[K, V]) {
+	// nothing in the subtree ends at or after lo
+	if n == t.tree.NIL || t.cmp(n.max.Hi, lo) < 0 {
+		return
+	}
+	t.overlapping(n.left, lo, hi, found)
+	// the node, and everything to the right of it, starts after hi
+	if t.cmp(n.item.Lo, hi) > 0 {
+		return
+	}
+	if t.cmp(n.item.Hi, lo) >= 0 {
+		*found = append(*found, n.item)
+	}
+	t.overlapping(n.right, lo, hi, found)
+}
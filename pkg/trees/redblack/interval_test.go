@@ -0,0 +1,133 @@
+package redblack
+
+import (
+	"math/rand"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(38))
+	tree := NewOrderedIntervalTree[int, int]()
+	set := make(map[[2]int]int)
+	for i := 0; i < 4000; i++ {
+		lo := rnd.Intn(1000)
+		hi := lo + rnd.Intn(50)
+		if rnd.Intn(4) == 0 {
+			v, ok := tree.DeleteInterval(lo, hi)
+			if want, has := set[[2]int{lo, hi}]; ok != has || v != want {
+				t.Fatalf("delete(%d, %d): expected %d, %v, got %d, %v", lo, hi, want, has, v, ok)
+			}
+			delete(set, [2]int{lo, hi})
+		} else {
+			if err := tree.InsertInterval(lo, hi, i); err != nil {
+				t.Fatalf("insert(%d, %d): %v", lo, hi, err)
+			}
+			set[[2]int{lo, hi}] = i
+		}
+	}
+	checkTree(t, tree.tree)
+	if tree.Len() != len(set) {
+		t.Fatalf("bad length, expected %d, got %d", len(set), tree.Len())
+	}
+	for i := 0; i < 500; i++ {
+		lo := rnd.Intn(1100) - 50
+		hi := lo + rnd.Intn(20)
+		if i%2 == 0 {
+			hi = lo
+		}
+		var found []Interval[int, int]
+		if lo == hi {
+			found = tree.Overlapping(lo)
+		} else {
+			found = tree.OverlappingRange(lo, hi)
+		}
+		var want int
+		for k := range set {
+			if k[0] <= hi && k[1] >= lo {
+				want++
+			}
+		}
+		if len(found) != want {
+			t.Fatalf("overlapping(%d, %d): expected %d intervals, got %d", lo, hi, want, len(found))
+		}
+		for j, iv := range found {
+			if iv.Lo > hi || iv.Hi < lo || set[[2]int{iv.Lo, iv.Hi}] != iv.Value {
+				t.Fatalf("overlapping(%d, %d): bad interval %v", lo, hi, iv)
+			}
+			if j > 0 && tree.tree.cmp(found[j-1], iv) >= 0 {
+				t.Fatalf("overlapping(%d, %d): out of order", lo, hi)
+			}
+		}
+	}
+	if err := tree.InsertInterval(5, 4, 0); err != ErrBadInterval {
+		t.Fatalf("expected ErrBadInterval, got %v", err)
+	}
+	if found := tree.OverlappingRange(5, 4); found != nil {
+		t.Fatalf("expected no intervals, got %v", found)
+	}
+}
+
+func TestIntervalTree_Time(t *testing.T) {
+	tree := NewIntervalTree[time.Time, string](
+		func(a, b time.Time) int {
+			if a.Before(b) {
+				return -1
+			}
+			if a.After(b) {
+				return 1
+			}
+			return 0
+		},
+	)
+	day := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+	tree.InsertInterval(at(9), at(10), "standup")
+	tree.InsertInterval(at(9), at(17), "office")
+	tree.InsertInterval(at(12), at(13), "lunch")
+	tree.InsertInterval(at(16), at(18), "review")
+
+	names := func(found []Interval[time.Time, string]) []string {
+		var s []string
+		for _, iv := range found {
+			s = append(s, iv.Value)
+		}
+		return s
+	}
+	if got := names(tree.Overlapping(at(12))); len(got) != 2 || got[0] != "office" || got[1] != "lunch" {
+		t.Fatalf("at noon: got %v", got)
+	}
+	if got := names(tree.OverlappingRange(at(17), at(20))); len(got) != 2 || got[0] != "office" || got[1] != "review" {
+		t.Fatalf("evening: got %v", got)
+	}
+	if v, ok := tree.DeleteInterval(at(9), at(17)); !ok || v != "office" {
+		t.Fatalf("delete: got %q, %v", v, ok)
+	}
+	if got := names(tree.Overlapping(at(11))); len(got) != 0 {
+		t.Fatalf("at 11: got %v", got)
+	}
+}
+
+func TestIntervalTree_IP(t *testing.T) {
+	tree := NewIntervalTree[netip.Addr, string](
+		func(a, b netip.Addr) int {
+			return a.Compare(b)
+		},
+	)
+	add := func(lo, hi, name string) {
+		if err := tree.InsertInterval(netip.MustParseAddr(lo), netip.MustParseAddr(hi), name); err != nil {
+			t.Fatalf("insert %s: %v", name, err)
+		}
+	}
+	add("10.0.0.0", "10.255.255.255", "private-a")
+	add("10.1.0.0", "10.1.255.255", "office")
+	add("192.168.0.0", "192.168.255.255", "private-c")
+	found := tree.Overlapping(netip.MustParseAddr("10.1.2.3"))
+	if len(found) != 2 || found[0].Value != "private-a" || found[1].Value != "office" {
+		t.Fatalf("got %v", found)
+	}
+	if found := tree.Overlapping(netip.MustParseAddr("8.8.8.8")); len(found) != 0 {
+		t.Fatalf("got %v", found)
+	}
+}
@@ -41,12 +41,14 @@ func BytesToInt(val []byte) int64 {
 }
 
 func (t *rbTree) ToList() (*list.List, error) {
-	if t.count < 1 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if t.tree.Len() < 1 {
 		return nil, fmt.Errorf("Error: there are not enough entrys in the tree\n")
 	}
 	li := list.New()
-	t.ascend(
-		t.root, t.min(t.root).entry, func(e RBEntry) bool {
+	t.tree.Scan(
+		func(e RBEntry) bool {
 			li.PushBack(e)
 			return true
 		},
@@ -55,6 +57,8 @@ func (t *rbTree) ToList() (*list.List, error) {
 }
 
 func (t *rbTree) FromList(li *list.List) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
 	for e := li.Front(); e != nil; e = e.Next() {
 		ent, ok := e.Value.(RBEntry)
 		if !ok {
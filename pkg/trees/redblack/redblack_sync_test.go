@@ -0,0 +1,118 @@
+package redblack
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestIterator_FailFast(t *testing.T) {
+	tree := NewOrdered[int]()
+	for i := 0; i < 10; i++ {
+		tree.Add(i)
+	}
+	it := tree.Iterator()
+	if k, ok := it.Next(); !ok || k != 1 {
+		t.Fatalf("next: got %d, %v", k, ok)
+	}
+	// updating an existing item is not a modification
+	tree.Put(5)
+	if k, ok := it.Next(); !ok || k != 2 || it.Err() != nil {
+		t.Fatalf("next after put: got %d, %v, %v", k, ok, it.Err())
+	}
+	tree.Del(7)
+	if _, ok := it.Next(); ok {
+		t.Fatalf("next after del did not stop")
+	}
+	if !errors.Is(it.Err(), ErrConcurrentModification) {
+		t.Fatalf("expected ErrConcurrentModification, got %v", it.Err())
+	}
+	if _, ok := it.Prev(); ok {
+		t.Fatalf("prev after del did not stop")
+	}
+	// starting over clears the error
+	var n int
+	for _, ok := it.First(); ok; _, ok = it.Next() {
+		n++
+	}
+	if n != 9 || it.Err() != nil {
+		t.Fatalf("iterated %d items, err %v", n, it.Err())
+	}
+}
+
+func TestRbTree_IterSnapshot(t *testing.T) {
+	tree := newRBTree()
+	for i := 0; i < 32; i++ {
+		tree.Add(entry{fmt.Sprintf("entry-%.3d", i)})
+	}
+	live, snap := tree.Iter(), tree.SnapshotIter()
+	tree.Del(entry{"entry-010"})
+	tree.Add(entry{"entry-100"})
+
+	if e := live.Next(); e != nil || !errors.Is(live.Err(), ErrConcurrentModification) {
+		t.Fatalf("live iterator: got %v, %v", e, live.Err())
+	}
+	if live.HasMore() {
+		t.Fatalf("live iterator has more after a modification")
+	}
+	var got []string
+	for e := snap.First(); e != nil; e = snap.Next() {
+		got = append(got, e.(entry).data)
+	}
+	if len(got) != 32 || got[10] != "entry-010" || snap.Err() != nil {
+		t.Fatalf("snapshot iterator: got %v, %v", got, snap.Err())
+	}
+}
+
+func TestRbTree_Concurrent(t *testing.T) {
+	tree := newRBTree()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				e := entry{fmt.Sprintf("entry-%d-%.3d", g, i)}
+				tree.Put(e)
+				if i%3 == 0 {
+					tree.Del(e)
+				}
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				tree.Len()
+				tree.Percentile(50)
+				tree.Scan(func(RBEntry) bool { return true })
+				if it := tree.SnapshotIter(); it != nil {
+					for e := it.First(); e != nil; e = it.Next() {
+					}
+					if it.Err() != nil {
+						t.Errorf("snapshot iterator: %v", it.Err())
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := tree.Len(); n != 4*333 {
+		t.Fatalf("expected %d entries, got %d", 4*333, n)
+	}
+	tree.View(
+		func(tree *RBTree[RBEntry]) {
+			checkTree(t, tree)
+		},
+	)
+	tree.Update(
+		func(tree *RBTree[RBEntry]) {
+			if min, ok := tree.Min(); ok {
+				tree.Del(min)
+			}
+		},
+	)
+	if n := tree.Len(); n != 4*333-1 {
+		t.Fatalf("expected %d entries, got %d", 4*333-1, n)
+	}
+}
@@ -0,0 +1,851 @@
+package redblack
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/scottcagno/go-scratch/pkg/trees/codec"
+)
+
+// Ordered is a constraint that permits any ordered type, that is
+// any type that supports the operators < <= >= >
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+type nodeColor uint8
+
+const (
+	_ nodeColor = iota
+	red
+	black
+)
+
+// RED and BLACK are the colors of the nodes of the tree
+const (
+	RED   = red
+	BLACK = black
+)
+
+func (c nodeColor) String() string {
+	if c == red {
+		return "red"
+	}
+	return "black"
+}
+
+// node is a node of the red-black tree
+type node[T any] struct {
+	parent *node[T]
+	left   *node[T]
+	right  *node[T]
+	color  nodeColor
+	item   T
+	count  int // the number of nodes in the subtree rooted here
+	max    T   // the item that ends last in the subtree, if the tree has an end function
+}
+
+// RBTree is a generic red-black tree implementation. The items in the tree
+// are kept in the order defined by the comparison function the tree was
+// created with, which must return a negative number if a is less than b,
+// zero if they are equal and a positive number if a is greater than b.
+// Only one of any set of equal items is kept in the tree.
+//
+// An RBTree is not safe for concurrent use. Any number of goroutines may read
+// from a tree at the same time, but writes must be synchronized with every
+// other read and write by the caller, just like with a map. Also see rbTree,
+// which does the locking itself, and Tree, which stores Items.
+type RBTree[T any] struct {
+	NIL    *node[T]
+	root   *node[T]
+	cmp    func(a, b T) int
+	end    func(a, b T) int
+	sizeOf func(item T) int
+	codec  codec.Codec[T]
+	count  int
+	size   int64
+	mods   uint64 // the number of modifications, used to detect stale iterators
+}
+
+// New creates and returns a new red-black tree ordered
+// by the provided comparison function.
+func New[T any](cmp func(a, b T) int) *RBTree[T] {
+	n := &node[T]{
+		parent: nil,
+		left:   nil,
+		right:  nil,
+		color:  black,
+	}
+	return &RBTree[T]{
+		NIL:   n,
+		root:  n,
+		cmp:   cmp,
+		count: 0,
+		size:  0,
+	}
+}
+
+// NewOrdered creates and returns a new red-black tree for
+// any ordered type, using the natural order of the type.
+func NewOrdered[T Ordered]() *RBTree[T] {
+	return New[T](
+		func(a, b T) int {
+			if a < b {
+				return -1
+			}
+			if b < a {
+				return 1
+			}
+			return 0
+		},
+	)
+}
+
+// SetSizeFunc sets the function used to calculate the size (in bytes)
+// of an item. The sizes of all the items in the tree are added up and
+// reported by Size. Without a size function, Size always returns zero.
+func (t *RBTree[T]) SetSizeFunc(fn func(item T) int) {
+	t.sizeOf = fn
+	t.size = 0
+	if fn != nil {
+		t.ascend(
+			t.root, func(item T) bool {
+				t.size += int64(fn(item))
+				return true
+			},
+		)
+	}
+}
+
+// itemSize returns the size of the item, or zero without a size function
+func (t *RBTree[T]) itemSize(item T) int64 {
+	if t.sizeOf == nil {
+		return 0
+	}
+	return int64(t.sizeOf(item))
+}
+
+// newNode returns a new (red) node holding the provided item
+func (t *RBTree[T]) newNode(item T) *node[T] {
+	return &node[T]{
+		parent: t.NIL,
+		left:   t.NIL,
+		right:  t.NIL,
+		color:  red,
+		item:   item,
+		count:  1,
+		max:    item,
+	}
+}
+
+// Has checks and returns a boolean indicating true if the
+// provided item exists in the tree, and false if it cannot
+// be located.
+func (t *RBTree[T]) Has(item T) bool {
+	return t.search(item) != t.NIL
+}
+
+// Add inserts the provided item if and only if the item
+// does not already exist in the tree. It returns a boolean
+// indicating false if the item was already present in the
+// tree and could therefore not be added, otherwise returning
+// true for a successful insertion.
+func (t *RBTree[T]) Add(item T) bool {
+	if t.search(item) != t.NIL {
+		// item already exists in the tree, so we will not add
+		return false
+	}
+	t.insert(t.newNode(item))
+	return true
+}
+
+// Put inserts the provided item. If the item already exists
+// in the tree, put will overwrite the existing item with the
+// newly provided item. It returns the item that was replaced
+// and a boolean indicating true if the item already existed
+// and was therefore updated, and false if there was no prior
+// matching item in the tree.
+func (t *RBTree[T]) Put(item T) (T, bool) {
+	return t.insert(t.newNode(item))
+}
+
+// Get performs a search and attempts to return the item that
+// contains a matching key. It returns the item along with a
+// boolean indicating true if the item was successfully found,
+// and false if the item could not be located.
+func (t *RBTree[T]) Get(item T) (T, bool) {
+	n := t.search(item)
+	if n == t.NIL {
+		return *new(T), false
+	}
+	return n.item, true
+}
+
+// Del locates and removes the item matching the provided item
+// key and returns the removed item and a boolean indicating true
+// if the item was successfully located and removed and false
+// if the item could not be found or removed.
+func (t *RBTree[T]) Del(item T) (T, bool) {
+	n := t.search(item)
+	if n == t.NIL {
+		return *new(T), false
+	}
+	return t.remove(n), true
+}
+
+// Len returns the number of items in the tree
+func (t *RBTree[T]) Len() int {
+	return t.count
+}
+
+// Size returns the size in bytes of all the items in the tree,
+// as calculated by the size function (see SetSizeFunc)
+func (t *RBTree[T]) Size() int64 {
+	return t.size
+}
+
+// Min returns the minimum item in the tree
+func (t *RBTree[T]) Min() (T, bool) {
+	x := t.min(t.root)
+	if x == t.NIL {
+		return *new(T), false
+	}
+	return x.item, true
+}
+
+// Max returns the maximum item in the tree
+func (t *RBTree[T]) Max() (T, bool) {
+	x := t.max(t.root)
+	if x == t.NIL {
+		return *new(T), false
+	}
+	return x.item, true
+}
+
+// GetNearMin performs an approximate search for the specified item
+// key and returns the closest item that is less than (the predecessor)
+// of the searched item key. It returns a boolean indicating true if
+// an exact match was found for the key, and false if it is unknown
+// or an exact match was not found.
+func (t *RBTree[T]) GetNearMin(item T) (T, bool) {
+	ret := t.searchApprox(item)
+	prev := t.predecessor(ret)
+	if prev == t.NIL {
+		prev = t.min(t.root)
+	}
+	return prev.item, t.isMatch(ret, item)
+}
+
+// GetNearMax performs an approximate search for the specified item
+// key and returns the closest item that is greater than (the successor)
+// of the searched item key. It returns a boolean indicating true if
+// an exact match was found for the key, and false if it is unknown
+// or an exact match was not found.
+func (t *RBTree[T]) GetNearMax(item T) (T, bool) {
+	ret := t.searchApprox(item)
+	return t.successor(ret).item, t.isMatch(ret, item)
+}
+
+// GetApproxPrevNext performs an approximate search for the specified item
+// and returns the closest item, the predecessor, and the successor and a
+// boolean reporting true if an exact match was found for the item, and false
+// if it is unknown or and exact match was not found
+func (t *RBTree[T]) GetApproxPrevNext(item T) (T, T, T, bool) {
+	ret := t.searchApprox(item)
+	return ret.item, t.predecessor(ret).item, t.successor(ret).item, t.isMatch(ret, item)
+}
+
+// isMatch reports whether the node n holds an item equal to item
+func (t *RBTree[T]) isMatch(n *node[T], item T) bool {
+	return n != t.NIL && t.cmp(n.item, item) == 0
+}
+
+// Scan iterates the items in the tree from min to max, stopping
+// early if iter returns false
+func (t *RBTree[T]) Scan(iter func(item T) bool) {
+	t.ascend(t.root, iter)
+}
+
+// ScanBack iterates the items in the tree from max to min, stopping
+// early if iter returns false
+func (t *RBTree[T]) ScanBack(iter func(item T) bool) {
+	t.descend(t.root, iter)
+}
+
+// ScanRange iterates the items in the tree from start (inclusive) up to
+// end (exclusive), stopping early if iter returns false
+func (t *RBTree[T]) ScanRange(start, end T, iter func(item T) bool) {
+	t.ascendRange(t.root, start, end, iter)
+}
+
+// Clone returns a copy of the tree. The copy has the same shape as the
+// tree, so it is made in linear time without any comparisons. The items
+// themselves are copied by value.
+func (t *RBTree[T]) Clone() *RBTree[T] {
+	c := New[T](t.cmp)
+	c.end = t.end
+	c.sizeOf = t.sizeOf
+	c.codec = t.codec
+	c.count = t.count
+	c.size = t.size
+	c.root = t.cloneNode(t.root, c.NIL, c.NIL)
+	return c
+}
+
+func (t *RBTree[T]) cloneNode(n, parent, NIL *node[T]) *node[T] {
+	if n == t.NIL {
+		return NIL
+	}
+	c := &node[T]{
+		parent: parent,
+		color:  n.color,
+		item:   n.item,
+		count:  n.count,
+		max:    n.max,
+	}
+	c.left = t.cloneNode(n.left, c, NIL)
+	c.right = t.cloneNode(n.right, c, NIL)
+	return c
+}
+
+// Close releases the nodes of the tree. The tree must not be used
+// after it has been closed, unless Reset is called first.
+func (t *RBTree[T]) Close() {
+	t.mods++
+	t.NIL = nil
+	t.root = nil
+	t.count = 0
+	t.size = 0
+}
+
+// Reset removes all the items from the tree
+func (t *RBTree[T]) Reset() {
+	t.Close()
+	runtime.GC()
+	n := &node[T]{
+		left:   nil,
+		right:  nil,
+		parent: nil,
+		color:  black,
+	}
+	t.NIL = n
+	t.root = n
+}
+
+// Print writes the structure of the tree to stdout. Each item is written
+// on its own line, indented by its depth, and the right subtree of a node
+// is written above it and the left subtree below it, so the output reads
+// like the tree turned on its side.
+func (t *RBTree[T]) Print() {
+	t.print(os.Stdout, t.root, 0)
+}
+
+func (t *RBTree[T]) print(w io.Writer, n *node[T], depth int) {
+	if n == t.NIL {
+		return
+	}
+	t.print(w, n.right, depth+1)
+	fmt.Fprintf(w, "%s%v (%s)\n", strings.Repeat("    ", depth), n.item, n.color)
+	t.print(w, n.left, depth+1)
+}
+
+// insert will insert the provided node into the tree, updating
+// the existing node if it is already present in the tree. It
+// returns the item that was replaced and a boolean indicating
+// true if the node was updated, and false if the node was newly
+// inserted.
+func (t *RBTree[T]) insert(n *node[T]) (T, bool) {
+	x := t.root
+	y := t.NIL
+	for x != t.NIL {
+		y = x
+		if c := t.cmp(n.item, x.item); c < 0 {
+			x = x.left
+		} else if c > 0 {
+			x = x.right
+		} else {
+			t.size -= t.itemSize(x.item)
+			t.size += t.itemSize(n.item)
+			// We support updating much like with a hashmap, so
+			// we need to update any entries that already exist
+			// in the tree before returning.
+			old := x.item
+			x.item = n.item
+			if t.end != nil {
+				t.updatePath(x)
+			}
+			return old, true // true = updated existing item
+			//
+			// It should be noted that we don't need to re-balance
+			// the tree because the keys for the item have not
+			// been changed and the tree is balance is maintained
+			// by the item keys, and not by their values.
+		}
+	}
+	n.parent = y
+	if y == t.NIL {
+		t.root = n
+	} else if t.cmp(n.item, y.item) < 0 {
+		y.left = n
+	} else {
+		y.right = n
+	}
+	t.updatePath(y)
+	// Increase the count and size because we have just inserted new
+	t.count++
+	t.mods++
+	t.size += t.itemSize(n.item)
+	// And now, we run any fix-ups for re-balancing
+	t.insertFixup(n)
+	return *new(T), false
+}
+
+func (t *RBTree[T]) insertFixup(n *node[T]) {
+	for n.parent.color == red {
+		if n.parent == n.parent.parent.left {
+			y := n.parent.parent.right
+			if y.color == red {
+				n.parent.color = black
+				y.color = black
+				n.parent.parent.color = red
+				n = n.parent.parent
+			} else {
+				if n == n.parent.right {
+					n = n.parent
+					t.rotateLeft(n)
+				}
+				n.parent.color = black
+				n.parent.parent.color = red
+				t.rotateRight(n.parent.parent)
+			}
+		} else {
+			y := n.parent.parent.left
+			if y.color == red {
+				n.parent.color = black
+				y.color = black
+				n.parent.parent.color = red
+				n = n.parent.parent
+			} else {
+				if n == n.parent.left {
+					n = n.parent
+					t.rotateRight(n)
+				}
+				n.parent.color = black
+				n.parent.parent.color = red
+				t.rotateLeft(n.parent.parent)
+			}
+		}
+	}
+	t.root.color = black
+}
+
+func (t *RBTree[T]) rotateLeft(n *node[T]) {
+	if n.right == t.NIL {
+		return
+	}
+	y := n.right
+	n.right = y.left
+	if y.left != t.NIL {
+		y.left.parent = n
+	}
+	y.parent = n.parent
+	if n.parent == t.NIL {
+		t.root = y
+	} else if n == n.parent.left {
+		n.parent.left = y
+	} else {
+		n.parent.right = y
+	}
+	y.left = n
+	n.parent = y
+	t.update(n)
+	t.update(y)
+}
+
+func (t *RBTree[T]) rotateRight(n *node[T]) {
+	if n.left == t.NIL {
+		return
+	}
+	y := n.left
+	n.left = y.right
+	if y.right != t.NIL {
+		y.right.parent = n
+	}
+	y.parent = n.parent
+	if n.parent == t.NIL {
+		t.root = y
+	} else if n == n.parent.left {
+		n.parent.left = y
+	} else {
+		n.parent.right = y
+	}
+	y.right = n
+	n.parent = y
+	t.update(n)
+	t.update(y)
+}
+
+// update recalculates the subtree count (and max) of the node from its
+// children. It must be called on a node whenever its children change.
+func (t *RBTree[T]) update(n *node[T]) {
+	n.count = n.left.count + n.right.count + 1
+	if t.end != nil {
+		n.max = n.item
+		if n.left != t.NIL && t.end(n.left.max, n.max) > 0 {
+			n.max = n.left.max
+		}
+		if n.right != t.NIL && t.end(n.right.max, n.max) > 0 {
+			n.max = n.right.max
+		}
+	}
+}
+
+// updatePath updates the node and all of its ancestors, up to the root
+func (t *RBTree[T]) updatePath(n *node[T]) {
+	for ; n != t.NIL; n = n.parent {
+		t.update(n)
+	}
+}
+
+// search attempts to locate the node where the provided item
+// key resides and returns the matching node, or NIL if a node
+// with a matching item could not be located.
+func (t *RBTree[T]) search(item T) *node[T] {
+	p := t.root
+	for p != t.NIL {
+		if c := t.cmp(p.item, item); c < 0 {
+			p = p.right
+		} else if c > 0 {
+			p = p.left
+		} else {
+			break
+		}
+	}
+	return p
+}
+
+// searchApprox attempts to locate the node where the provided
+// item key resides, but if an exact match cannot be found it
+// will return the closest match found.
+func (t *RBTree[T]) searchApprox(item T) *node[T] {
+	p := t.root
+	for p != t.NIL {
+		if c := t.cmp(p.item, item); c < 0 {
+			if p.right == t.NIL {
+				break
+			}
+			p = p.right
+		} else if c > 0 {
+			if p.left == t.NIL {
+				break
+			}
+			p = p.left
+		} else {
+			break
+		}
+	}
+	return p
+}
+
+// min traverses from root to left recursively until left is NIL
+func (t *RBTree[T]) min(n *node[T]) *node[T] {
+	if n == t.NIL {
+		return t.NIL
+	}
+	for n.left != t.NIL {
+		n = n.left
+	}
+	return n
+}
+
+// max traverses from root to right recursively until right is NIL
+func (t *RBTree[T]) max(n *node[T]) *node[T] {
+	if n == t.NIL {
+		return t.NIL
+	}
+	for n.right != t.NIL {
+		n = n.right
+	}
+	return n
+}
+
+// predecessor locates the node that precedes the provided one
+func (t *RBTree[T]) predecessor(n *node[T]) *node[T] {
+	if n == t.NIL {
+		return t.NIL
+	}
+	if n.left != t.NIL {
+		return t.max(n.left)
+	}
+	y := n.parent
+	for y != t.NIL && n == y.left {
+		n = y
+		y = y.parent
+	}
+	return y
+}
+
+// successor locates the node that succeeds the provided one
+func (t *RBTree[T]) successor(n *node[T]) *node[T] {
+	if n == t.NIL {
+		return t.NIL
+	}
+	if n.right != t.NIL {
+		return t.min(n.right)
+	}
+	y := n.parent
+	for y != t.NIL && n == y.right {
+		n = y
+		y = y.parent
+	}
+	return y
+}
+
+// remove is the internal method that deletes the provided node
+// from the tree. It returns the item that the node was holding.
+func (t *RBTree[T]) remove(z *node[T]) T {
+	item := z.item
+	var y, x *node[T]
+	if z.left == t.NIL || z.right == t.NIL {
+		y = z
+	} else {
+		y = t.successor(z)
+	}
+	if y.left != t.NIL {
+		x = y.left
+	} else {
+		x = y.right
+	}
+	x.parent = y.parent
+
+	if y.parent == t.NIL {
+		t.root = x
+	} else if y == y.parent.left {
+		y.parent.left = x
+	} else {
+		y.parent.right = x
+	}
+	if y != z {
+		z.item = y.item
+	}
+	t.updatePath(y.parent)
+	if y.color == black {
+		t.removeFixup(x)
+	}
+	t.size -= t.itemSize(item)
+	t.count--
+	t.mods++
+	return item
+}
+
+func (t *RBTree[T]) removeFixup(n *node[T]) {
+	for n != t.root && n.color == black {
+		if n == n.parent.left {
+			w := n.parent.right
+			if w.color == red {
+				w.color = black
+				n.parent.color = red
+				t.rotateLeft(n.parent)
+				w = n.parent.right
+			}
+			if w.left.color == black && w.right.color == black {
+				w.color = red
+				n = n.parent
+			} else {
+				if w.right.color == black {
+					w.left.color = black
+					w.color = red
+					t.rotateRight(w)
+					w = n.parent.right
+				}
+				w.color = n.parent.color
+				n.parent.color = black
+				w.right.color = black
+				t.rotateLeft(n.parent)
+				// this is to exit while loop
+				n = t.root
+			}
+		} else {
+			w := n.parent.left
+			if w.color == red {
+				w.color = black
+				n.parent.color = red
+				t.rotateRight(n.parent)
+				w = n.parent.left
+			}
+			if w.left.color == black && w.right.color == black {
+				w.color = red
+				n = n.parent
+			} else {
+				if w.left.color == black {
+					w.right.color = black
+					w.color = red
+					t.rotateLeft(w)
+					w = n.parent.left
+				}
+				w.color = n.parent.color
+				n.parent.color = black
+				w.left.color = black
+				t.rotateRight(n.parent)
+				n = t.root
+			}
+		}
+	}
+	n.color = black
+}
+
+func (t *RBTree[T]) ascend(x *node[T], iter func(item T) bool) bool {
+	if x == t.NIL {
+		return true
+	}
+	if !t.ascend(x.left, iter) {
+		return false
+	}
+	if !iter(x.item) {
+		return false
+	}
+	return t.ascend(x.right, iter)
+}
+
+func (t *RBTree[T]) descend(x *node[T], iter func(item T) bool) bool {
+	if x == t.NIL {
+		return true
+	}
+	if !t.descend(x.right, iter) {
+		return false
+	}
+	if !iter(x.item) {
+		return false
+	}
+	return t.descend(x.left, iter)
+}
+
+func (t *RBTree[T]) ascendRange(x *node[T], inf, sup T, iter func(item T) bool) bool {
+	if x == t.NIL {
+		return true
+	}
+	if t.cmp(x.item, sup) >= 0 {
+		return t.ascendRange(x.left, inf, sup, iter)
+	}
+	if t.cmp(x.item, inf) < 0 {
+		return t.ascendRange(x.right, inf, sup, iter)
+	}
+	if !t.ascendRange(x.left, inf, sup, iter) {
+		return false
+	}
+	if !iter(x.item) {
+		return false
+	}
+	return t.ascendRange(x.right, inf, sup, iter)
+}
+
+var ErrConcurrentModification = errors.New("redblack: tree modified during iteration")
+
+// RBIterator is an iteration type for the (red-black) RBTree. The
+// iterator starts out positioned at the minimum item in the tree.
+//
+// The iterator is fail-fast: once an item has been added to or removed
+// from the tree, Next and Prev stop and Err returns an error wrapping
+// ErrConcurrentModification, rather than walking nodes that may no longer
+// be in the tree. First and Last start the iterator over. Updating the
+// item of an existing key with Put does not stop the iterator. To keep
+// iterating while the tree is being modified, iterate over a Clone.
+type RBIterator[T any] struct {
+	tree    *RBTree[T]
+	current *node[T]
+	index   int
+	mods    uint64
+	err     error
+}
+
+// Iterator returns a new iterator positioned at the minimum item
+func (t *RBTree[T]) Iterator() *RBIterator[T] {
+	return &RBIterator[T]{
+		tree:    t,
+		current: t.min(t.root),
+		index:   t.count,
+		mods:    t.mods,
+	}
+}
+
+// First moves the iterator to the minimum item and returns it, along
+// with a boolean indicating false if the tree is empty
+func (it *RBIterator[T]) First() (T, bool) {
+	return it.seek(it.tree.min(it.tree.root))
+}
+
+// Last moves the iterator to the maximum item and returns it, along
+// with a boolean indicating false if the tree is empty
+func (it *RBIterator[T]) Last() (T, bool) {
+	return it.seek(it.tree.max(it.tree.root))
+}
+
+func (it *RBIterator[T]) seek(n *node[T]) (T, bool) {
+	it.current = n
+	it.index = it.tree.count
+	it.mods = it.tree.mods
+	it.err = nil
+	if n == it.tree.NIL {
+		return *new(T), false
+	}
+	return n.item, true
+}
+
+// Next moves the iterator to the successor of the current item and
+// returns it, along with a boolean indicating false if there is none
+// or if the tree has been modified (see Err)
+func (it *RBIterator[T]) Next() (T, bool) {
+	if !it.valid() {
+		return *new(T), false
+	}
+	return it.step(it.tree.successor(it.current))
+}
+
+// Prev moves the iterator to the predecessor of the current item and
+// returns it, along with a boolean indicating false if there is none
+// or if the tree has been modified (see Err)
+func (it *RBIterator[T]) Prev() (T, bool) {
+	if !it.valid() {
+		return *new(T), false
+	}
+	return it.step(it.tree.predecessor(it.current))
+}
+
+// valid checks the tree has not been modified since the iterator was
+// positioned, and records the error if it has
+func (it *RBIterator[T]) valid() bool {
+	if it.err == nil && it.mods != it.tree.mods {
+		it.err = fmt.Errorf(
+			"%w: %d modifications since the iterator was positioned",
+			ErrConcurrentModification, it.tree.mods-it.mods,
+		)
+	}
+	return it.err == nil
+}
+
+// Err returns the error that stopped the iterator, if the tree was
+// modified while iterating, or nil
+func (it *RBIterator[T]) Err() error {
+	return it.err
+}
+
+func (it *RBIterator[T]) step(n *node[T]) (T, bool) {
+	if n == it.tree.NIL {
+		return *new(T), false
+	}
+	it.index--
+	it.current = n
+	return n.item, true
+}
+
+// HasMore reports whether there are any items left to visit, when
+// moving in one direction from the first (or last) item
+func (it *RBIterator[T]) HasMore() bool {
+	return it.err == nil && it.index > 1
+}
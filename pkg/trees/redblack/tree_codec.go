@@ -0,0 +1,171 @@
+package redblack
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/scottcagno/go-scratch/pkg/trees/codec"
+)
+
+// The errors returned by Decode for a malformed tree
+var (
+	ErrBadMagic   = codec.ErrBadMagic
+	ErrBadVersion = codec.ErrBadVersion
+	ErrBadFormat  = codec.ErrBadFormat
+)
+
+// BinaryCodec is a Codec for items implementing encoding.BinaryMarshaler.
+// New must return a new item to decode into, which must implement
+// encoding.BinaryUnmarshaler (usually a pointer).
+type BinaryCodec[T encoding.BinaryMarshaler] struct {
+	New func() T
+}
+
+// Encode encodes the item using its MarshalBinary method
+func (c BinaryCodec[T]) Encode(item T) ([]byte, error) {
+	return item.MarshalBinary()
+}
+
+// Decode decodes a new item using its UnmarshalBinary method
+func (c BinaryCodec[T]) Decode(b []byte) (T, error) {
+	item := c.New()
+	u, ok := any(item).(encoding.BinaryUnmarshaler)
+	if !ok {
+		return item, fmt.Errorf("redblack: %T does not implement encoding.BinaryUnmarshaler", item)
+	}
+	return item, u.UnmarshalBinary(b)
+}
+
+// SetCodec sets the Codec used for the items of the tree by Encode
+// and Decode. By default, a codec.Gob is used.
+func (t *RBTree[T]) SetCodec(c codec.Codec[T]) {
+	t.codec = c
+}
+
+func (t *RBTree[T]) getCodec() codec.Codec[T] {
+	if t.codec == nil {
+		return codec.Gob[T]{}
+	}
+	return t.codec
+}
+
+// The items of a serialized tree are written in sorted order, each one
+// as the uvarint length of the encoded item followed by the encoded item.
+// The shape of the tree is not written, it is rebuilt from the items.
+const (
+	magic   = "RBTR"
+	version = 1
+)
+
+// Encode writes the items of the tree to w, in sorted order, using the
+// codec of the tree (see SetCodec).
+func (t *RBTree[T]) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	c := t.getCodec()
+	var buf [binary.MaxVarintLen64]byte
+	bw.Write(codec.AppendHeader(nil, magic, version, uint64(t.count)))
+	var err error
+	t.ascend(
+		t.root, func(item T) bool {
+			var b []byte
+			if b, err = c.Encode(item); err != nil {
+				err = fmt.Errorf("redblack: encoding %v: %w", item, err)
+				return false
+			}
+			bw.Write(buf[:binary.PutUvarint(buf[:], uint64(len(b)))])
+			// the bufio.Writer keeps the first error, it is checked on Flush
+			bw.Write(b)
+			return true
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Decode replaces the contents of the tree with the items read from r,
+// which must have been written by Encode with the same ordering. As the
+// items are read in sorted order, the tree is built from them directly
+// in linear time rather than by inserting them one at a time. If an error
+// is returned the tree is left as it was. Decode may read past the end
+// of the serialized tree, unless r is an io.ByteReader.
+func (t *RBTree[T]) Decode(r io.Reader) error {
+	items, err := t.readItems(codec.NewByteReader(r))
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, item := range items {
+		size += t.itemSize(item)
+	}
+	// the deepest level of the tree, which is the only level
+	// that may not be full, is colored red
+	depth := bits.Len(uint(len(items))) - 1
+	t.root = t.build(items, t.NIL, 0, depth)
+	t.root.color = black
+	t.count = len(items)
+	t.size = size
+	t.mods++
+	return nil
+}
+
+// readItems reads the header and the items, and checks they are in order
+func (t *RBTree[T]) readItems(r codec.ByteReader) ([]T, error) {
+	count, err := codec.ReadHeader(r, magic, version)
+	if err != nil {
+		return nil, err
+	}
+	if count > codec.MaxLength {
+		return nil, fmt.Errorf("%w: %d items is too many", ErrBadFormat, count)
+	}
+	c := t.getCodec()
+	// the count is not trusted for the size of the allocation, the items
+	// grow as they are actually read
+	items := make([]T, 0, minInt(int(count), 1024))
+	for i := 0; i < int(count); i++ {
+		b, err := codec.ReadBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		item, err := c.Decode(b)
+		if err != nil {
+			return nil, fmt.Errorf("redblack: decoding item %d: %w", i, err)
+		}
+		if i > 0 && t.cmp(items[i-1], item) >= 0 {
+			return nil, fmt.Errorf("%w: item %d is out of order", ErrBadFormat, i)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// build returns a balanced subtree holding the sorted items. Every node
+// is black, except the nodes on the deepest level of the whole tree,
+// which keeps the black height the same along every path.
+func (t *RBTree[T]) build(items []T, parent *node[T], level, depth int) *node[T] {
+	if len(items) == 0 {
+		return t.NIL
+	}
+	mid := len(items) / 2
+	n := t.newNode(items[mid])
+	n.parent = parent
+	if level < depth {
+		n.color = black
+	}
+	n.left = t.build(items[:mid], n, level+1, depth)
+	n.right = t.build(items[mid+1:], n, level+1, depth)
+	t.update(n)
+	return n
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
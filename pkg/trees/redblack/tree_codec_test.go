@@ -0,0 +1,183 @@
+package redblack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/scottcagno/go-scratch/pkg/trees/codec"
+)
+
+func TestTree_EncodeDecode(t *testing.T) {
+	for n := 0; n < 300; n++ {
+		src := NewOrdered[int]()
+		src.SetSizeFunc(func(int) int { return 8 })
+		for i := 0; i < n; i++ {
+			src.Add(i * 3)
+		}
+		var buf bytes.Buffer
+		if err := src.Encode(&buf); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		dst := NewOrdered[int]()
+		dst.SetSizeFunc(func(int) int { return 8 })
+		dst.Add(-1)
+		if err := dst.Decode(&buf); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		checkTree(t, dst)
+		if got, want := scanAll(dst), scanAll(src); !equalInts(got, want) {
+			t.Fatalf("decode of %d items: expected %v, got %v", n, want, got)
+		}
+		if dst.Size() != src.Size() {
+			t.Fatalf("bad size, expected %d, got %d", src.Size(), dst.Size())
+		}
+		// the decoded tree is a regular tree
+		dst.Add(1)
+		dst.Del(0)
+		checkTree(t, dst)
+	}
+}
+
+// point is an entry with exported fields, so it can be gob encoded
+type point struct{ X, Y int }
+
+func (p point) Compare(that RBEntry) int {
+	q := that.(point)
+	if p.X != q.X {
+		return p.X - q.X
+	}
+	return p.Y - q.Y
+}
+
+func (p point) Size() int      { return 16 }
+func (p point) String() string { return fmt.Sprintf("(%d,%d)", p.X, p.Y) }
+
+func TestRbTree_EncodeDecode(t *testing.T) {
+	gob.Register(point{})
+	src := newRBTree()
+	for i := 0; i < 100; i++ {
+		src.Add(point{i % 10, i / 10})
+	}
+	var buf bytes.Buffer
+	if err := src.Encode(&buf); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	dst := newRBTree()
+	if err := dst.Decode(&buf); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	checkTree(t, dst.tree)
+	if dst.String() != src.String() || dst.Size() != src.Size() {
+		t.Fatalf("expected %s, got %s", src, dst)
+	}
+	if e, ok := dst.Get(point{3, 4}); !ok || e != (point{3, 4}) {
+		t.Fatalf("get: got %v, %v", e, ok)
+	}
+}
+
+// word is an item implementing the encoding.Binary(Un)Marshaler interfaces
+type word struct{ s string }
+
+func (w *word) MarshalBinary() ([]byte, error) { return []byte(w.s), nil }
+
+func (w *word) UnmarshalBinary(b []byte) error {
+	w.s = string(b)
+	return nil
+}
+
+func TestTree_BinaryCodec(t *testing.T) {
+	newTree := func() *RBTree[*word] {
+		tree := New(func(a, b *word) int { return strings.Compare(a.s, b.s) })
+		tree.SetCodec(BinaryCodec[*word]{New: func() *word { return new(word) }})
+		return tree
+	}
+	src := newTree()
+	for _, s := range strings.Fields("the quick brown fox jumps over the lazy dog") {
+		src.Add(&word{s})
+	}
+	var buf bytes.Buffer
+	if err := src.Encode(&buf); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	// the words are stored as they are
+	if !bytes.Contains(buf.Bytes(), []byte("\x05brown\x03dog\x03fox")) {
+		t.Fatalf("unexpected encoding %q", buf.Bytes())
+	}
+	dst := newTree()
+	if err := dst.Decode(&buf); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	checkTree(t, dst)
+	if w, ok := dst.Min(); !ok || w.s != "brown" || dst.Len() != 8 {
+		t.Fatalf("min: got %v, %v", w, ok)
+	}
+}
+
+type failCodec struct{}
+
+var errFail = errors.New("fail")
+
+func (failCodec) Encode(int) ([]byte, error) { return nil, errFail }
+func (failCodec) Decode([]byte) (int, error) { return 0, errFail }
+
+func TestTree_DecodeErrors(t *testing.T) {
+	src := NewOrdered[int]()
+	for i := 0; i < 100; i++ {
+		src.Add(i * 7)
+	}
+	var buf bytes.Buffer
+	if err := src.Encode(&buf); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	data := buf.Bytes()
+
+	// every truncation of the data fails, and leaves the tree alone
+	dst := NewOrdered[int]()
+	dst.Add(-1)
+	for i := 0; i < len(data); i++ {
+		if err := dst.Decode(bytes.NewReader(data[:i])); err == nil {
+			t.Fatalf("decode of %d/%d bytes did not fail", i, len(data))
+		}
+		if !dst.Has(-1) || dst.Len() != 1 {
+			t.Fatalf("failed decode modified the tree")
+		}
+	}
+
+	if err := dst.Decode(strings.NewReader("nope!")); !errors.Is(err, ErrBadMagic) {
+		t.Fatalf("expected ErrBadMagic, got %v", err)
+	}
+	bad := append([]byte(nil), data...)
+	bad[len(magic)] = version + 1
+	if err := dst.Decode(bytes.NewReader(bad)); !errors.Is(err, ErrBadVersion) {
+		t.Fatalf("expected ErrBadVersion, got %v", err)
+	}
+	// items in the wrong order for the tree
+	rev := New(func(a, b int) int { return b - a })
+	if err := rev.Decode(bytes.NewReader(data)); !errors.Is(err, ErrBadFormat) {
+		t.Fatalf("expected ErrBadFormat, got %v", err)
+	}
+	huge := codec.AppendHeader(nil, magic, version, codec.MaxLength+1)
+	if err := dst.Decode(bytes.NewReader(huge)); !errors.Is(err, ErrBadFormat) {
+		t.Fatalf("expected ErrBadFormat, got %v", err)
+	}
+	// a huge item length is only read as far as the input goes
+	huge = binary.AppendUvarint(codec.AppendHeader(nil, magic, version, 1), codec.MaxLength)
+	if err := dst.Decode(bytes.NewReader(huge)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	dst.SetCodec(failCodec{})
+	if err := dst.Decode(bytes.NewReader(data)); !errors.Is(err, errFail) {
+		t.Fatalf("expected a decode error, got %v", err)
+	}
+	src.SetCodec(failCodec{})
+	if err := src.Encode(io.Discard); !errors.Is(err, errFail) {
+		t.Fatalf("expected an encode error, got %v", err)
+	}
+}
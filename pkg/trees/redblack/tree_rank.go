@@ -0,0 +1,78 @@
+package redblack
+
+import (
+	"math"
+)
+
+// Every node of the tree keeps a count of the nodes in the subtree rooted
+// at it, which makes it an order-statistic tree: the position of an item in
+// the sorted order, and the item at a given position, can both be found in
+// a single walk down the tree, in O(log n) time.
+
+// Rank returns the number of items in the tree that are less than the
+// provided item, which is the (zero based) position of the item in the
+// sorted order of the tree. It also returns a boolean indicating true if
+// the item exists in the tree, and false if it would be inserted at that
+// position.
+func (t *RBTree[T]) Rank(item T) (int, bool) {
+	var rank int
+	x := t.root
+	for x != t.NIL {
+		if c := t.cmp(x.item, item); c < 0 {
+			rank += x.left.count + 1
+			x = x.right
+		} else if c > 0 {
+			x = x.left
+		} else {
+			return rank + x.left.count, true
+		}
+	}
+	return rank, false
+}
+
+// Select returns the item at the provided (zero based) position in the
+// sorted order of the tree, so Select(0) returns the minimum item. It
+// returns a boolean indicating false if the position is out of range.
+func (t *RBTree[T]) Select(i int) (T, bool) {
+	if i < 0 || i >= t.count {
+		return *new(T), false
+	}
+	x := t.root
+	for {
+		if l := x.left.count; i < l {
+			x = x.left
+		} else if i > l {
+			i -= l + 1
+			x = x.right
+		} else {
+			return x.item, true
+		}
+	}
+}
+
+// CountRange returns the number of items in the tree from lo (inclusive)
+// up to hi (exclusive), which are the items ScanRange would visit.
+func (t *RBTree[T]) CountRange(lo, hi T) int {
+	if t.cmp(lo, hi) >= 0 {
+		return 0
+	}
+	start, _ := t.Rank(lo)
+	end, _ := t.Rank(hi)
+	return end - start
+}
+
+// Percentile returns the item at the provided percentile (from 0 up to
+// and including 100) of the tree, using the nearest-rank method. That is
+// the smallest item that is greater than or equal to at least p percent
+// of the items in the tree. It returns a boolean indicating false if the
+// tree is empty or the percentile is out of range.
+func (t *RBTree[T]) Percentile(p float64) (T, bool) {
+	if t.count == 0 || !(p >= 0 && p <= 100) {
+		return *new(T), false
+	}
+	i := int(math.Ceil(p/100*float64(t.count))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return t.Select(i)
+}
@@ -0,0 +1,112 @@
+package redblack
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestTree_Rank(t *testing.T) {
+	rnd := rand.New(rand.NewSource(37))
+	tree := NewOrdered[int]()
+	set := make(map[int]bool)
+	for i := 0; i < 3000; i++ {
+		k := rnd.Intn(2000)
+		if rnd.Intn(4) == 0 {
+			tree.Del(k)
+			delete(set, k)
+		} else {
+			tree.Put(k)
+			set[k] = true
+		}
+	}
+	checkTree(t, tree)
+	var keys []int
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	for i, k := range keys {
+		if got, ok := tree.Select(i); !ok || got != k {
+			t.Fatalf("select(%d): expected %d, got %d, %v", i, k, got, ok)
+		}
+	}
+	for k := -1; k <= 2000; k++ {
+		rank, found := tree.Rank(k)
+		if want := sort.SearchInts(keys, k); rank != want || found != set[k] {
+			t.Fatalf("rank(%d): expected %d, %v, got %d, %v", k, want, set[k], rank, found)
+		}
+	}
+	if _, ok := tree.Select(-1); ok {
+		t.Fatalf("select(-1) found an item")
+	}
+	if _, ok := tree.Select(len(keys)); ok {
+		t.Fatalf("select(%d) found an item", len(keys))
+	}
+	for i := 0; i < 200; i++ {
+		lo, hi := rnd.Intn(2100)-50, rnd.Intn(2100)-50
+		var want int
+		tree.ScanRange(
+			lo, hi, func(int) bool {
+				want++
+				return true
+			},
+		)
+		if got := tree.CountRange(lo, hi); got != want {
+			t.Fatalf("count range(%d, %d): expected %d, got %d", lo, hi, want, got)
+		}
+	}
+}
+
+func TestTree_Percentile(t *testing.T) {
+	tree := NewOrdered[float64]()
+	if _, ok := tree.Percentile(50); ok {
+		t.Fatalf("percentile of an empty tree")
+	}
+	// latencies of 1ms to 100ms
+	for i := 100; i > 0; i-- {
+		tree.Add(float64(i))
+	}
+	cases := []struct {
+		p, want float64
+	}{
+		{0, 1},
+		{1, 1},
+		{1.5, 2},
+		{50, 50},
+		{90, 90},
+		{99.9, 100},
+		{100, 100},
+	}
+	for _, c := range cases {
+		if got, ok := tree.Percentile(c.p); !ok || got != c.want {
+			t.Fatalf("percentile(%v): expected %v, got %v, %v", c.p, c.want, got, ok)
+		}
+	}
+	for _, p := range []float64{-1, 100.5} {
+		if _, ok := tree.Percentile(p); ok {
+			t.Fatalf("percentile(%v) is out of range", p)
+		}
+	}
+}
+
+func TestRbTree_Rank(t *testing.T) {
+	tree := newRBTree()
+	for i := 0; i < 32; i++ {
+		tree.Add(entry{fmt.Sprintf("entry-%.3d", i)})
+	}
+	if rank, ok := tree.Rank(entry{"entry-010"}); !ok || rank != 10 {
+		t.Fatalf("rank: got %d, %v", rank, ok)
+	}
+	if e, ok := tree.Select(20); !ok || e.(entry).data != "entry-020" {
+		t.Fatalf("select: got %v, %v", e, ok)
+	}
+	if n := tree.CountRange(entry{"entry-005"}, entry{"entry-015"}); n != 10 {
+		t.Fatalf("count range: got %d", n)
+	}
+	if _, ok := tree.Rank(nil); ok {
+		t.Fatalf("rank of a nil entry")
+	}
+}
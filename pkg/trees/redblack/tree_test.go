@@ -0,0 +1,253 @@
+package redblack
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// checkTree fails the test if the tree breaks any of the red-black
+// properties, or if its nodes are out of order
+func checkTree[T any](t *testing.T, tree *RBTree[T]) {
+	t.Helper()
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func scanAll[T any](tree *RBTree[T]) []T {
+	var items []T
+	tree.Scan(
+		func(item T) bool {
+			items = append(items, item)
+			return true
+		},
+	)
+	return items
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTree_Random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(36))
+	tree := NewOrdered[int]()
+	tree.SetSizeFunc(func(int) int { return 8 })
+	set := make(map[int]bool)
+	for i := 0; i < 5000; i++ {
+		k := rnd.Intn(1000)
+		switch rnd.Intn(3) {
+		case 0:
+			if added := tree.Add(k); added == set[k] {
+				t.Fatalf("add(%d): got %v", k, added)
+			}
+			set[k] = true
+		case 1:
+			if _, updated := tree.Put(k); updated != set[k] {
+				t.Fatalf("put(%d): got %v", k, updated)
+			}
+			set[k] = true
+		case 2:
+			if got, ok := tree.Del(k); ok != set[k] || (ok && got != k) {
+				t.Fatalf("del(%d): got %d, %v", k, got, ok)
+			}
+			delete(set, k)
+		}
+		if tree.Has(k) != set[k] {
+			t.Fatalf("has(%d): expected %v", k, set[k])
+		}
+	}
+	checkTree(t, tree)
+	var keys []int
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	if got := scanAll(tree); !equalInts(got, keys) {
+		t.Fatalf("scan: expected %v, got %v", keys, got)
+	}
+	if tree.Size() != int64(8*len(keys)) {
+		t.Fatalf("bad size, expected %d, got %d", 8*len(keys), tree.Size())
+	}
+	if min, _ := tree.Min(); min != keys[0] {
+		t.Fatalf("min: expected %d, got %d", keys[0], min)
+	}
+	if max, _ := tree.Max(); max != keys[len(keys)-1] {
+		t.Fatalf("max: expected %d, got %d", keys[len(keys)-1], max)
+	}
+
+	var back []int
+	tree.ScanBack(
+		func(k int) bool {
+			back = append(back, k)
+			return len(back) < 10
+		},
+	)
+	for i, k := range back {
+		if k != keys[len(keys)-1-i] {
+			t.Fatalf("scan back: got %v", back)
+		}
+	}
+	var inRange []int
+	tree.ScanRange(
+		250, 500, func(k int) bool {
+			inRange = append(inRange, k)
+			return true
+		},
+	)
+	lo, hi := sort.SearchInts(keys, 250), sort.SearchInts(keys, 500)
+	if !equalInts(inRange, keys[lo:hi]) {
+		t.Fatalf("scan range: expected %v, got %v", keys[lo:hi], inRange)
+	}
+
+	for len(keys) > 0 {
+		k := keys[len(keys)-1]
+		tree.Del(k)
+		keys = keys[:len(keys)-1]
+		if len(keys)%100 == 0 {
+			checkTree(t, tree)
+		}
+	}
+	if _, ok := tree.Min(); ok || tree.Size() != 0 {
+		t.Fatalf("expected an empty tree")
+	}
+}
+
+func TestTree_ValidateCorrupt(t *testing.T) {
+	tree := NewOrdered[int]()
+	for i := 0; i < 64; i++ {
+		tree.Add(i)
+	}
+	checkTree(t, tree)
+
+	// swap the items of the root and its left child
+	n := tree.root
+	n.item, n.left.item = n.left.item, n.item
+	if err := tree.Validate(); !errors.Is(err, ErrBadOrder) {
+		t.Errorf("expected %v, got %v", ErrBadOrder, err)
+	}
+	n.item, n.left.item = n.left.item, n.item
+
+	// paint the root red
+	n.color = red
+	if err := tree.Validate(); !errors.Is(err, ErrBadColor) {
+		t.Errorf("expected %v, got %v", ErrBadColor, err)
+	}
+	n.color = black
+
+	// paint a red node black
+	r := n
+	for r.color != red {
+		r = r.right
+	}
+	r.color = black
+	if err := tree.Validate(); !errors.Is(err, ErrBadBlackHeight) {
+		t.Errorf("expected %v, got %v", ErrBadBlackHeight, err)
+	}
+	r.color = red
+
+	// break a parent pointer
+	n.left.parent = tree.NIL
+	if err := tree.Validate(); !errors.Is(err, ErrBadParent) {
+		t.Errorf("expected %v, got %v", ErrBadParent, err)
+	}
+	n.left.parent = n
+
+	// miscount a subtree
+	n.left.count++
+	if err := tree.Validate(); !errors.Is(err, ErrBadCount) {
+		t.Errorf("expected %v, got %v", ErrBadCount, err)
+	}
+	n.left.count--
+	checkTree(t, tree)
+}
+
+func TestTree_Near(t *testing.T) {
+	tree := NewOrdered[int]()
+	if _, ok := tree.GetNearMin(5); ok {
+		t.Fatalf("near min of an empty tree")
+	}
+	for i := 10; i <= 50; i += 10 {
+		tree.Add(i)
+	}
+	cases := []struct {
+		key, near, prev, next, min, max int
+		exact                           bool
+	}{
+		{30, 30, 20, 40, 20, 40, true},
+		{35, 30, 20, 40, 20, 40, false},
+		{5, 10, 0, 20, 10, 20, false},
+		{10, 10, 0, 20, 10, 20, true},
+		{55, 50, 40, 0, 40, 0, false},
+	}
+	for _, c := range cases {
+		near, prev, next, exact := tree.GetApproxPrevNext(c.key)
+		if near != c.near || prev != c.prev || next != c.next || exact != c.exact {
+			t.Fatalf("approx(%d): got %d, %d, %d, %v", c.key, near, prev, next, exact)
+		}
+		if min, exact := tree.GetNearMin(c.key); min != c.min || exact != c.exact {
+			t.Fatalf("near min(%d): got %d, %v", c.key, min, exact)
+		}
+		if max, exact := tree.GetNearMax(c.key); max != c.max || exact != c.exact {
+			t.Fatalf("near max(%d): got %d, %v", c.key, max, exact)
+		}
+	}
+}
+
+func TestTree_Iterator(t *testing.T) {
+	tree := New(func(a, b string) int { return len(a) - len(b) })
+	it := tree.Iterator()
+	if _, ok := it.First(); ok {
+		t.Fatalf("first of an empty tree")
+	}
+	words := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	for i := len(words) - 1; i >= 0; i-- {
+		tree.Add(words[i])
+	}
+	it = tree.Iterator()
+	var got []string
+	for w, ok := it.First(); ok; w, ok = it.Next() {
+		got = append(got, w)
+	}
+	if len(got) != len(words) || got[0] != "a" || got[4] != "eeeee" {
+		t.Fatalf("forward: got %v", got)
+	}
+	got = got[:0]
+	for w, ok := it.Last(); ok; w, ok = it.Prev() {
+		got = append(got, w)
+		if it.HasMore() != (len(got) < len(words)) {
+			t.Fatalf("has more after %v", got)
+		}
+	}
+	if len(got) != len(words) || got[0] != "eeeee" || got[4] != "a" {
+		t.Fatalf("backward: got %v", got)
+	}
+}
+
+func TestTree_Clone(t *testing.T) {
+	tree := NewOrdered[int]()
+	for i := 0; i < 100; i++ {
+		tree.Add(i)
+	}
+	clone := tree.Clone()
+	checkTree(t, clone)
+	for i := 0; i < 100; i += 2 {
+		clone.Del(i)
+	}
+	clone.Add(1000)
+	checkTree(t, tree)
+	checkTree(t, clone)
+	if tree.Len() != 100 || clone.Len() != 51 || tree.Has(1000) || !tree.Has(50) {
+		t.Fatalf("clone is not independent of the tree")
+	}
+}
@@ -0,0 +1,107 @@
+package redblack
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBadOrder       = errors.New("redblack: items are out of order")
+	ErrBadColor       = errors.New("redblack: red node has a red child")
+	ErrBadBlackHeight = errors.New("redblack: paths have a different number of black nodes")
+	ErrBadParent      = errors.New("redblack: bad parent pointer")
+	ErrBadCount       = errors.New("redblack: bad subtree count")
+	ErrBadMax         = errors.New("redblack: bad subtree max")
+)
+
+// Validate walks the entire tree and checks that all the invariants of a
+// red-black tree hold. It checks the order of the items, that the root is
+// black, that a red node has no red children, that every path from a node
+// down to its leaves has the same number of black nodes, the parent
+// pointers, the subtree counts (see Rank) and, for an interval tree, the
+// subtree max ends. It returns nil if the tree is valid, otherwise it
+// returns an error wrapping one of the ErrBad* errors describing the first
+// violation it encountered.
+func (t *RBTree[T]) Validate() error {
+	if t.NIL.color != black {
+		return fmt.Errorf("%w: the sentinel node is red", ErrBadColor)
+	}
+	if t.NIL.count != 0 {
+		return fmt.Errorf("%w: the sentinel node has a count of %d", ErrBadCount, t.NIL.count)
+	}
+	if t.root == t.NIL {
+		if t.count != 0 {
+			return fmt.Errorf("%w: empty tree has a count of %d", ErrBadCount, t.count)
+		}
+		return nil
+	}
+	if t.root.color != black {
+		return fmt.Errorf("%w: the root is red", ErrBadColor)
+	}
+	if t.root.parent != t.NIL {
+		return fmt.Errorf("%w: root has a parent", ErrBadParent)
+	}
+	if _, err := t.checkNode(t.root, nil, nil); err != nil {
+		return err
+	}
+	if t.root.count != t.count {
+		return fmt.Errorf("%w: the tree has a count of %d, the root of %d", ErrBadCount, t.count, t.root.count)
+	}
+	return nil
+}
+
+// checkNode recursively checks the node n (and all of its children.) The
+// lo and hi nodes hold the bounds imposed on the node by its ancestors,
+// both exclusive, and a nil bound is unbounded. It returns the number of
+// black nodes on the paths down from n.
+func (t *RBTree[T]) checkNode(n, lo, hi *node[T]) (int, error) {
+	if n == t.NIL {
+		return 1, nil
+	}
+	if lo != nil && t.cmp(lo.item, n.item) >= 0 {
+		return 0, fmt.Errorf("%w: %v is not after %v", ErrBadOrder, n.item, lo.item)
+	}
+	if hi != nil && t.cmp(n.item, hi.item) >= 0 {
+		return 0, fmt.Errorf("%w: %v is not before %v", ErrBadOrder, n.item, hi.item)
+	}
+	for _, c := range []*node[T]{n.left, n.right} {
+		if c == t.NIL {
+			continue
+		}
+		if c.parent != n {
+			return 0, fmt.Errorf("%w: the child %v of %v has another parent", ErrBadParent, c.item, n.item)
+		}
+		if n.color == red && c.color == red {
+			return 0, fmt.Errorf("%w: %v and its child %v", ErrBadColor, n.item, c.item)
+		}
+	}
+	left, err := t.checkNode(n.left, lo, n)
+	if err != nil {
+		return 0, err
+	}
+	right, err := t.checkNode(n.right, n, hi)
+	if err != nil {
+		return 0, err
+	}
+	if left != right {
+		return 0, fmt.Errorf("%w: below %v, %d on the left and %d on the right", ErrBadBlackHeight, n.item, left, right)
+	}
+	if want := n.left.count + n.right.count + 1; n.count != want {
+		return 0, fmt.Errorf("%w: %v has a count of %d, want %d", ErrBadCount, n.item, n.count, want)
+	}
+	if t.end != nil {
+		max := n.item
+		for _, c := range []*node[T]{n.left, n.right} {
+			if c != t.NIL && t.end(c.max, max) > 0 {
+				max = c.max
+			}
+		}
+		if t.end(n.max, max) != 0 {
+			return 0, fmt.Errorf("%w: %v has a max of %v, want %v", ErrBadMax, n.item, n.max, max)
+		}
+	}
+	if n.color == black {
+		left++
+	}
+	return left, nil
+}
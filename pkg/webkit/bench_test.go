@@ -0,0 +1,808 @@
+package webkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The routing benchmarks compare the RouteMatchers on the route sets of
+// the go-http-routing-benchmark suite: the GitHub API, the Parse API, and
+// the static paths of a source tree. Run them with
+//
+//	go test -run x -bench Routing ./pkg/webkit
+//
+// Each benchmark routes a request for every route of the set, and a single
+// request for a route with params. The matching benchmarks, run with
+//
+//	go test -run x -bench Matching ./pkg/webkit
+//
+// compare Mux.Match with the ways of matching a path by hand: a linear
+// scan of the routes, comparing each one segment by segment, rewriting the
+// path with the params of the route, using a regexp or using a glob.
+
+type benchRoute struct {
+	method, pattern string
+}
+
+var githubAPI = []benchRoute{
+	// OAuth Authorizations
+	{"GET", "/authorizations"},
+	{"GET", "/authorizations/{id}"},
+	{"POST", "/authorizations"},
+	{"PUT", "/authorizations/clients/{client_id}"},
+	{"PATCH", "/authorizations/{id}"},
+	{"DELETE", "/authorizations/{id}"},
+	{"GET", "/applications/{client_id}/tokens/{access_token}"},
+	{"DELETE", "/applications/{client_id}/tokens"},
+	{"DELETE", "/applications/{client_id}/tokens/{access_token}"},
+
+	// Activity
+	{"GET", "/events"},
+	{"GET", "/repos/{owner}/{repo}/events"},
+	{"GET", "/networks/{owner}/{repo}/events"},
+	{"GET", "/orgs/{org}/events"},
+	{"GET", "/users/{user}/received_events"},
+	{"GET", "/users/{user}/received_events/public"},
+	{"GET", "/users/{user}/events"},
+	{"GET", "/users/{user}/events/public"},
+	{"GET", "/users/{user}/events/orgs/{org}"},
+	{"GET", "/feeds"},
+	{"GET", "/notifications"},
+	{"GET", "/repos/{owner}/{repo}/notifications"},
+	{"PUT", "/notifications"},
+	{"PUT", "/repos/{owner}/{repo}/notifications"},
+	{"GET", "/notifications/threads/{id}"},
+	{"PATCH", "/notifications/threads/{id}"},
+	{"GET", "/notifications/threads/{id}/subscription"},
+	{"PUT", "/notifications/threads/{id}/subscription"},
+	{"DELETE", "/notifications/threads/{id}/subscription"},
+	{"GET", "/repos/{owner}/{repo}/stargazers"},
+	{"GET", "/users/{user}/starred"},
+	{"GET", "/user/starred"},
+	{"GET", "/user/starred/{owner}/{repo}"},
+	{"PUT", "/user/starred/{owner}/{repo}"},
+	{"DELETE", "/user/starred/{owner}/{repo}"},
+	{"GET", "/repos/{owner}/{repo}/subscribers"},
+	{"GET", "/users/{user}/subscriptions"},
+	{"GET", "/user/subscriptions"},
+	{"GET", "/repos/{owner}/{repo}/subscription"},
+	{"PUT", "/repos/{owner}/{repo}/subscription"},
+	{"DELETE", "/repos/{owner}/{repo}/subscription"},
+	{"GET", "/user/subscriptions/{owner}/{repo}"},
+	{"PUT", "/user/subscriptions/{owner}/{repo}"},
+	{"DELETE", "/user/subscriptions/{owner}/{repo}"},
+
+	// Gists
+	{"GET", "/users/{user}/gists"},
+	{"GET", "/gists"},
+	{"GET", "/gists/public"},
+	{"GET", "/gists/starred"},
+	{"GET", "/gists/{id}"},
+	{"POST", "/gists"},
+	{"PATCH", "/gists/{id}"},
+	{"PUT", "/gists/{id}/star"},
+	{"DELETE", "/gists/{id}/star"},
+	{"GET", "/gists/{id}/star"},
+	{"POST", "/gists/{id}/forks"},
+	{"DELETE", "/gists/{id}"},
+
+	// Git Data
+	{"GET", "/repos/{owner}/{repo}/git/blobs/{sha}"},
+	{"POST", "/repos/{owner}/{repo}/git/blobs"},
+	{"GET", "/repos/{owner}/{repo}/git/commits/{sha}"},
+	{"POST", "/repos/{owner}/{repo}/git/commits"},
+	{"GET", "/repos/{owner}/{repo}/git/refs/*"},
+	{"POST", "/repos/{owner}/{repo}/git/refs"},
+	{"GET", "/repos/{owner}/{repo}/git/tags/{sha}"},
+	{"POST", "/repos/{owner}/{repo}/git/tags"},
+	{"GET", "/repos/{owner}/{repo}/git/trees/{sha}"},
+	{"POST", "/repos/{owner}/{repo}/git/trees"},
+
+	// Issues
+	{"GET", "/issues"},
+	{"GET", "/user/issues"},
+	{"GET", "/orgs/{org}/issues"},
+	{"GET", "/repos/{owner}/{repo}/issues"},
+	{"GET", "/repos/{owner}/{repo}/issues/{number}"},
+	{"POST", "/repos/{owner}/{repo}/issues"},
+	{"PATCH", "/repos/{owner}/{repo}/issues/{number}"},
+	{"GET", "/repos/{owner}/{repo}/assignees"},
+	{"GET", "/repos/{owner}/{repo}/assignees/{assignee}"},
+	{"GET", "/repos/{owner}/{repo}/issues/{number}/comments"},
+	{"GET", "/repos/{owner}/{repo}/issues/comments"},
+	{"GET", "/repos/{owner}/{repo}/issues/comments/{id}"},
+	{"POST", "/repos/{owner}/{repo}/issues/{number}/comments"},
+	{"PATCH", "/repos/{owner}/{repo}/issues/comments/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/issues/comments/{id}"},
+	{"GET", "/repos/{owner}/{repo}/issues/{number}/events"},
+	{"GET", "/repos/{owner}/{repo}/issues/events"},
+	{"GET", "/repos/{owner}/{repo}/issues/events/{id}"},
+	{"GET", "/repos/{owner}/{repo}/labels"},
+	{"GET", "/repos/{owner}/{repo}/labels/{name}"},
+	{"POST", "/repos/{owner}/{repo}/labels"},
+	{"PATCH", "/repos/{owner}/{repo}/labels/{name}"},
+	{"DELETE", "/repos/{owner}/{repo}/labels/{name}"},
+	{"GET", "/repos/{owner}/{repo}/issues/{number}/labels"},
+	{"POST", "/repos/{owner}/{repo}/issues/{number}/labels"},
+	{"DELETE", "/repos/{owner}/{repo}/issues/{number}/labels/{name}"},
+	{"PUT", "/repos/{owner}/{repo}/issues/{number}/labels"},
+	{"DELETE", "/repos/{owner}/{repo}/issues/{number}/labels"},
+	{"GET", "/repos/{owner}/{repo}/milestones/{number}/labels"},
+	{"GET", "/repos/{owner}/{repo}/milestones"},
+	{"GET", "/repos/{owner}/{repo}/milestones/{number}"},
+	{"POST", "/repos/{owner}/{repo}/milestones"},
+	{"PATCH", "/repos/{owner}/{repo}/milestones/{number}"},
+	{"DELETE", "/repos/{owner}/{repo}/milestones/{number}"},
+
+	// Miscellaneous
+	{"GET", "/emojis"},
+	{"GET", "/gitignore/templates"},
+	{"GET", "/gitignore/templates/{name}"},
+	{"POST", "/markdown"},
+	{"POST", "/markdown/raw"},
+	{"GET", "/meta"},
+	{"GET", "/rate_limit"},
+
+	// Organizations
+	{"GET", "/users/{user}/orgs"},
+	{"GET", "/user/orgs"},
+	{"GET", "/orgs/{org}"},
+	{"PATCH", "/orgs/{org}"},
+	{"GET", "/orgs/{org}/members"},
+	{"GET", "/orgs/{org}/members/{user}"},
+	{"DELETE", "/orgs/{org}/members/{user}"},
+	{"GET", "/orgs/{org}/public_members"},
+	{"GET", "/orgs/{org}/public_members/{user}"},
+	{"PUT", "/orgs/{org}/public_members/{user}"},
+	{"DELETE", "/orgs/{org}/public_members/{user}"},
+	{"GET", "/orgs/{org}/teams"},
+	{"GET", "/teams/{id}"},
+	{"POST", "/orgs/{org}/teams"},
+	{"PATCH", "/teams/{id}"},
+	{"DELETE", "/teams/{id}"},
+	{"GET", "/teams/{id}/members"},
+	{"GET", "/teams/{id}/members/{user}"},
+	{"PUT", "/teams/{id}/members/{user}"},
+	{"DELETE", "/teams/{id}/members/{user}"},
+	{"GET", "/teams/{id}/repos"},
+	{"GET", "/teams/{id}/repos/{owner}/{repo}"},
+	{"PUT", "/teams/{id}/repos/{owner}/{repo}"},
+	{"DELETE", "/teams/{id}/repos/{owner}/{repo}"},
+	{"GET", "/user/teams"},
+
+	// Pull Requests
+	{"GET", "/repos/{owner}/{repo}/pulls"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}"},
+	{"POST", "/repos/{owner}/{repo}/pulls"},
+	{"PATCH", "/repos/{owner}/{repo}/pulls/{number}"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}/commits"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}/files"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}/merge"},
+	{"PUT", "/repos/{owner}/{repo}/pulls/{number}/merge"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}/comments"},
+	{"GET", "/repos/{owner}/{repo}/pulls/comments"},
+	{"GET", "/repos/{owner}/{repo}/pulls/comments/{number}"},
+	{"PUT", "/repos/{owner}/{repo}/pulls/{number}/comments"},
+	{"PATCH", "/repos/{owner}/{repo}/pulls/comments/{number}"},
+	{"DELETE", "/repos/{owner}/{repo}/pulls/comments/{number}"},
+
+	// Repositories
+	{"GET", "/user/repos"},
+	{"GET", "/users/{user}/repos"},
+	{"GET", "/orgs/{org}/repos"},
+	{"GET", "/repositories"},
+	{"POST", "/user/repos"},
+	{"POST", "/orgs/{org}/repos"},
+	{"GET", "/repos/{owner}/{repo}"},
+	{"PATCH", "/repos/{owner}/{repo}"},
+	{"GET", "/repos/{owner}/{repo}/contributors"},
+	{"GET", "/repos/{owner}/{repo}/languages"},
+	{"GET", "/repos/{owner}/{repo}/teams"},
+	{"GET", "/repos/{owner}/{repo}/tags"},
+	{"GET", "/repos/{owner}/{repo}/branches"},
+	{"GET", "/repos/{owner}/{repo}/branches/{branch}"},
+	{"DELETE", "/repos/{owner}/{repo}"},
+	{"GET", "/repos/{owner}/{repo}/collaborators"},
+	{"GET", "/repos/{owner}/{repo}/collaborators/{user}"},
+	{"PUT", "/repos/{owner}/{repo}/collaborators/{user}"},
+	{"DELETE", "/repos/{owner}/{repo}/collaborators/{user}"},
+	{"GET", "/repos/{owner}/{repo}/comments"},
+	{"GET", "/repos/{owner}/{repo}/commits/{sha}/comments"},
+	{"POST", "/repos/{owner}/{repo}/commits/{sha}/comments"},
+	{"GET", "/repos/{owner}/{repo}/comments/{id}"},
+	{"PATCH", "/repos/{owner}/{repo}/comments/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/comments/{id}"},
+	{"GET", "/repos/{owner}/{repo}/commits"},
+	{"GET", "/repos/{owner}/{repo}/commits/{sha}"},
+	{"GET", "/repos/{owner}/{repo}/readme"},
+	{"GET", "/repos/{owner}/{repo}/contents/*"},
+	{"PUT", "/repos/{owner}/{repo}/contents/*"},
+	{"DELETE", "/repos/{owner}/{repo}/contents/*"},
+	{"GET", "/repos/{owner}/{repo}/{archive_format}/{ref}"},
+	{"GET", "/repos/{owner}/{repo}/keys"},
+	{"GET", "/repos/{owner}/{repo}/keys/{id}"},
+	{"POST", "/repos/{owner}/{repo}/keys"},
+	{"PATCH", "/repos/{owner}/{repo}/keys/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/keys/{id}"},
+	{"GET", "/repos/{owner}/{repo}/downloads"},
+	{"GET", "/repos/{owner}/{repo}/downloads/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/downloads/{id}"},
+	{"GET", "/repos/{owner}/{repo}/forks"},
+	{"POST", "/repos/{owner}/{repo}/forks"},
+	{"GET", "/repos/{owner}/{repo}/hooks"},
+	{"GET", "/repos/{owner}/{repo}/hooks/{id}"},
+	{"POST", "/repos/{owner}/{repo}/hooks"},
+	{"PATCH", "/repos/{owner}/{repo}/hooks/{id}"},
+	{"POST", "/repos/{owner}/{repo}/hooks/{id}/tests"},
+	{"DELETE", "/repos/{owner}/{repo}/hooks/{id}"},
+	{"POST", "/repos/{owner}/{repo}/merges"},
+	{"GET", "/repos/{owner}/{repo}/releases"},
+	{"GET", "/repos/{owner}/{repo}/releases/{id}"},
+	{"POST", "/repos/{owner}/{repo}/releases"},
+	{"PATCH", "/repos/{owner}/{repo}/releases/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/releases/{id}"},
+	{"GET", "/repos/{owner}/{repo}/releases/{id}/assets"},
+	{"GET", "/repos/{owner}/{repo}/stats/contributors"},
+	{"GET", "/repos/{owner}/{repo}/stats/commit_activity"},
+	{"GET", "/repos/{owner}/{repo}/stats/code_frequency"},
+	{"GET", "/repos/{owner}/{repo}/stats/participation"},
+	{"GET", "/repos/{owner}/{repo}/stats/punch_card"},
+	{"GET", "/repos/{owner}/{repo}/statuses/{ref}"},
+	{"POST", "/repos/{owner}/{repo}/statuses/{ref}"},
+
+	// Search
+	{"GET", "/search/repositories"},
+	{"GET", "/search/code"},
+	{"GET", "/search/issues"},
+	{"GET", "/search/users"},
+	{"GET", "/legacy/issues/search/{owner}/{repository}/{state}/{keyword}"},
+	{"GET", "/legacy/repos/search/{keyword}"},
+	{"GET", "/legacy/user/search/{keyword}"},
+	{"GET", "/legacy/user/email/{email}"},
+
+	// Users
+	{"GET", "/users/{user}"},
+	{"GET", "/user"},
+	{"PATCH", "/user"},
+	{"GET", "/users"},
+	{"GET", "/user/emails"},
+	{"POST", "/user/emails"},
+	{"DELETE", "/user/emails"},
+	{"GET", "/users/{user}/followers"},
+	{"GET", "/user/followers"},
+	{"GET", "/users/{user}/following"},
+	{"GET", "/user/following"},
+	{"GET", "/user/following/{user}"},
+	{"GET", "/users/{user}/following/{target_user}"},
+	{"PUT", "/user/following/{user}"},
+	{"DELETE", "/user/following/{user}"},
+	{"GET", "/users/{user}/keys"},
+	{"GET", "/user/keys"},
+	{"GET", "/user/keys/{id}"},
+	{"POST", "/user/keys"},
+	{"PATCH", "/user/keys/{id}"},
+	{"DELETE", "/user/keys/{id}"},
+}
+
+var parseAPI = []benchRoute{
+	// Objects
+	{"POST", "/1/classes/{className}"},
+	{"GET", "/1/classes/{className}/{objectId}"},
+	{"PUT", "/1/classes/{className}/{objectId}"},
+	{"GET", "/1/classes/{className}"},
+	{"DELETE", "/1/classes/{className}/{objectId}"},
+
+	// Users
+	{"POST", "/1/users"},
+	{"GET", "/1/login"},
+	{"GET", "/1/users/{objectId}"},
+	{"PUT", "/1/users/{objectId}"},
+	{"GET", "/1/users"},
+	{"DELETE", "/1/users/{objectId}"},
+	{"POST", "/1/requestPasswordReset"},
+
+	// Roles
+	{"POST", "/1/roles"},
+	{"GET", "/1/roles/{objectId}"},
+	{"PUT", "/1/roles/{objectId}"},
+	{"GET", "/1/roles"},
+	{"DELETE", "/1/roles/{objectId}"},
+
+	// Files
+	{"POST", "/1/files/{fileName}"},
+
+	// Analytics
+	{"POST", "/1/events/{eventName}"},
+
+	// Push Notifications
+	{"POST", "/1/push"},
+
+	// Installations
+	{"POST", "/1/installations"},
+	{"GET", "/1/installations/{objectId}"},
+	{"PUT", "/1/installations/{objectId}"},
+	{"GET", "/1/installations"},
+	{"DELETE", "/1/installations/{objectId}"},
+
+	// Cloud Functions
+	{"POST", "/1/functions"},
+}
+
+var staticRoutes = []benchRoute{
+	{"GET", "/"},
+	{"GET", "/cmd.html"},
+	{"GET", "/code.html"},
+	{"GET", "/contrib.html"},
+	{"GET", "/contribute.html"},
+	{"GET", "/debugging_with_gdb.html"},
+	{"GET", "/docs.html"},
+	{"GET", "/effective_go.html"},
+	{"GET", "/files.log"},
+	{"GET", "/gccgo_contribute.html"},
+	{"GET", "/gccgo_install.html"},
+	{"GET", "/go-logo-black.png"},
+	{"GET", "/go-logo-blue.png"},
+	{"GET", "/go-logo-white.png"},
+	{"GET", "/go1.1.html"},
+	{"GET", "/go1.2.html"},
+	{"GET", "/go1.html"},
+	{"GET", "/go1compat.html"},
+	{"GET", "/go_faq.html"},
+	{"GET", "/go_mem.html"},
+	{"GET", "/go_spec.html"},
+	{"GET", "/help.html"},
+	{"GET", "/ie.css"},
+	{"GET", "/install-source.html"},
+	{"GET", "/install.html"},
+	{"GET", "/logo-153x55.png"},
+	{"GET", "/Makefile"},
+	{"GET", "/root.html"},
+	{"GET", "/share.png"},
+	{"GET", "/sieve.gif"},
+	{"GET", "/tos.html"},
+	{"GET", "/articles/"},
+	{"GET", "/articles/go_command.html"},
+	{"GET", "/articles/index.html"},
+	{"GET", "/articles/wiki/"},
+	{"GET", "/articles/wiki/edit.html"},
+	{"GET", "/articles/wiki/final-noclosure.go"},
+	{"GET", "/articles/wiki/final-noerror.go"},
+	{"GET", "/articles/wiki/final-parsetemplate.go"},
+	{"GET", "/articles/wiki/final-template.go"},
+	{"GET", "/articles/wiki/final.go"},
+	{"GET", "/articles/wiki/get.go"},
+	{"GET", "/articles/wiki/http-sample.go"},
+	{"GET", "/articles/wiki/index.html"},
+	{"GET", "/articles/wiki/Makefile"},
+	{"GET", "/articles/wiki/notemplate.go"},
+	{"GET", "/articles/wiki/part1-noerror.go"},
+	{"GET", "/articles/wiki/part1.go"},
+	{"GET", "/articles/wiki/part2.go"},
+	{"GET", "/articles/wiki/part3-errorhandling.go"},
+	{"GET", "/articles/wiki/part3.go"},
+	{"GET", "/articles/wiki/test.bash"},
+	{"GET", "/articles/wiki/test_edit.good"},
+	{"GET", "/articles/wiki/test_Test.txt.good"},
+	{"GET", "/articles/wiki/test_view.good"},
+	{"GET", "/articles/wiki/view.html"},
+	{"GET", "/codewalk/"},
+	{"GET", "/codewalk/codewalk.css"},
+	{"GET", "/codewalk/codewalk.js"},
+	{"GET", "/codewalk/codewalk.xml"},
+	{"GET", "/codewalk/functions.xml"},
+	{"GET", "/codewalk/markov.go"},
+	{"GET", "/codewalk/markov.xml"},
+	{"GET", "/codewalk/pig.go"},
+	{"GET", "/codewalk/popout.png"},
+	{"GET", "/codewalk/run"},
+	{"GET", "/codewalk/sharemem.xml"},
+	{"GET", "/codewalk/urlpoll.go"},
+	{"GET", "/devel/"},
+	{"GET", "/devel/release.html"},
+	{"GET", "/devel/weekly.html"},
+	{"GET", "/gopher/"},
+	{"GET", "/gopher/appenginegopher.jpg"},
+	{"GET", "/gopher/appenginegophercolor.jpg"},
+	{"GET", "/gopher/appenginelogo.gif"},
+	{"GET", "/gopher/bumper.png"},
+	{"GET", "/gopher/doc.png"},
+	{"GET", "/gopher/frontpage.png"},
+	{"GET", "/gopher/gopherbw.png"},
+	{"GET", "/gopher/gophercolor.png"},
+	{"GET", "/gopher/help.png"},
+	{"GET", "/gopher/pencil/"},
+	{"GET", "/gopher/pencil/gopherhat.jpg"},
+	{"GET", "/gopher/pencil/gopherhelmet.jpg"},
+	{"GET", "/gopher/pencil/gophermega.jpg"},
+	{"GET", "/gopher/pencil/gopherrunning.jpg"},
+	{"GET", "/gopher/pencil/gopherswim.jpg"},
+	{"GET", "/gopher/pencil/gopherswrench.jpg"},
+	{"GET", "/gopher/pkg.png"},
+	{"GET", "/gopher/project.png"},
+	{"GET", "/gopher/ref.png"},
+	{"GET", "/gopher/run.png"},
+	{"GET", "/gopher/talks.png"},
+	{"GET", "/play/"},
+	{"GET", "/play/fib.go"},
+	{"GET", "/play/hello.go"},
+	{"GET", "/play/life.go"},
+	{"GET", "/play/peano.go"},
+	{"GET", "/play/pi.go"},
+	{"GET", "/play/sieve.go"},
+	{"GET", "/play/solitaire.go"},
+	{"GET", "/play/tree.go"},
+	{"GET", "/progs/"},
+	{"GET", "/progs/cgo1.go"},
+	{"GET", "/progs/cgo2.go"},
+	{"GET", "/progs/cgo3.go"},
+	{"GET", "/progs/cgo4.go"},
+	{"GET", "/progs/defer.go"},
+	{"GET", "/progs/defer.out"},
+	{"GET", "/progs/defer2.go"},
+	{"GET", "/progs/eff_bytesize.go"},
+	{"GET", "/progs/eff_qr.go"},
+	{"GET", "/progs/eff_sequence.go"},
+	{"GET", "/progs/error.go"},
+	{"GET", "/progs/error2.go"},
+	{"GET", "/progs/error3.go"},
+	{"GET", "/progs/error4.go"},
+	{"GET", "/progs/go1.go"},
+	{"GET", "/progs/gobs1.go"},
+	{"GET", "/progs/gobs2.go"},
+	{"GET", "/progs/image_draw.go"},
+	{"GET", "/progs/image_package1.go"},
+	{"GET", "/progs/interface.go"},
+	{"GET", "/progs/interface2.go"},
+	{"GET", "/progs/json1.go"},
+	{"GET", "/progs/run"},
+	{"GET", "/progs/slices.go"},
+	{"GET", "/progs/timeout1.go"},
+	{"GET", "/progs/update.bash"},
+}
+
+// benchMatchers are the RouteMatchers compared by the benchmarks
+var benchMatchers = []struct {
+	name       string
+	newMatcher func() RouteMatcher
+}{
+	{"chi", NewChiMatcher},
+	{"radix", NewRadixMatcher},
+}
+
+// benchWriter is a http.ResponseWriter that discards the response, so the
+// benchmarks only measure the routing
+type benchWriter struct {
+	header http.Header
+}
+
+func (w *benchWriter) Header() http.Header         { return w.header }
+func (w *benchWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *benchWriter) WriteHeader(int)             {}
+
+// benchMux returns a Mux with the routes, backed by the RouteMatcher
+func benchMux(routes []benchRoute, m RouteMatcher) *Mux {
+	mux := NewMuxMatcher(m)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, rt := range routes {
+		mux.Method(rt.method, rt.pattern, h)
+	}
+	return mux
+}
+
+// benchPath returns a path for the routing pattern, with the name of each
+// param as its value
+func benchPath(pattern string) string {
+	var sb strings.Builder
+	for pat := pattern; ; {
+		typ, key, _, _, ps, pe := patNextSegment(pat)
+		sb.WriteString(pat[:ps])
+		if typ == ntStatic {
+			sb.WriteString(pat)
+			return sb.String()
+		}
+		if typ == ntCatchAll {
+			key = "path/to/file"
+		}
+		sb.WriteString(key)
+		pat = pat[pe:]
+	}
+}
+
+func benchRequests(routes []benchRoute) []*http.Request {
+	reqs := make([]*http.Request, len(routes))
+	for i, rt := range routes {
+		reqs[i] = httptest.NewRequest(rt.method, benchPath(rt.pattern), nil)
+	}
+	return reqs
+}
+
+func benchRouting(b *testing.B, mux *Mux, reqs []*http.Request) {
+	w := &benchWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range reqs {
+			mux.ServeHTTP(w, r)
+		}
+	}
+}
+
+// benchSets are the route sets of the benchmarks, each with a single route
+// with params
+var benchSets = []struct {
+	name   string
+	routes []benchRoute
+	single benchRoute
+}{
+	{"GitHub", githubAPI, benchRoute{"GET", "/repos/{owner}/{repo}/issues/{number}/labels"}},
+	{"Parse", parseAPI, benchRoute{"GET", "/1/classes/{className}/{objectId}"}},
+	{"Static", staticRoutes, benchRoute{"GET", "/gopher/pencil/gopherswim.jpg"}},
+}
+
+func BenchmarkRouting(b *testing.B) {
+	for _, set := range benchSets {
+		for _, m := range benchMatchers {
+			mux := benchMux(set.routes, m.newMatcher())
+			b.Run(set.name+"/All/"+m.name, func(b *testing.B) {
+				benchRouting(b, mux, benchRequests(set.routes))
+			})
+			b.Run(set.name+"/Single/"+m.name, func(b *testing.B) {
+				benchRouting(b, mux, benchRequests([]benchRoute{set.single}))
+			})
+		}
+	}
+}
+
+// pathMatcher returns the pattern of the route matching the method and
+// the path, or "" if there is none
+type pathMatcher func(method, path string) string
+
+// namedPathMatcher is a pathMatcher along with the name of its benchmarks
+type namedPathMatcher struct {
+	name  string
+	match pathMatcher
+}
+
+// pathMatchers are the ways of matching a path compared by the matching
+// benchmarks, the Mux with each of the RouteMatchers and the ones by hand
+func pathMatchers(routes []benchRoute) []namedPathMatcher {
+	var ms []namedPathMatcher
+	for _, m := range benchMatchers {
+		ms = append(ms, namedPathMatcher{m.name, muxPathMatcher(benchMux(routes, m.newMatcher()))})
+	}
+	return append(ms,
+		namedPathMatcher{"segments", segmentPathMatcher(routes)},
+		namedPathMatcher{"replacer", replacerPathMatcher(routes)},
+		namedPathMatcher{"regexp", regexpPathMatcher(routes)},
+		namedPathMatcher{"glob", globPathMatcher(routes)},
+	)
+}
+
+// muxPathMatcher matches the path using Mux.Match
+func muxPathMatcher(mux *Mux) pathMatcher {
+	rctx := NewRouteContext()
+	return func(method, path string) string {
+		rctx.Reset()
+		if !mux.Match(rctx, method, path) {
+			return ""
+		}
+		return rctx.routePattern
+	}
+}
+
+// scanRoutes returns the routes with the fewest params first, so that the
+// linear scans by hand prefer static segments like the RouteMatchers do
+func scanRoutes(routes []benchRoute) []benchRoute {
+	scan := append([]benchRoute(nil), routes...)
+	sort.SliceStable(scan, func(i, j int) bool {
+		return strings.Count(scan[i].pattern, "{") < strings.Count(scan[j].pattern, "{")
+	})
+	return scan
+}
+
+// isParam reports whether the segment of a pattern is a param
+func isParam(seg string) bool {
+	return strings.HasPrefix(seg, "{")
+}
+
+// cutSegments cuts the path after its first n segments, the empty one
+// before the leading slash included
+func cutSegments(path string, n int) (head, tail string, ok bool) {
+	i := 0
+	for ; n > 0; n-- {
+		j := strings.IndexByte(path[i:], '/')
+		if j < 0 {
+			return "", "", false
+		}
+		i += j + 1
+	}
+	return path[:i-1], path[i:], true
+}
+
+// segmentPathMatcher compares the path with each route segment by
+// segment, a param matching any segment that is not empty and a catch-all
+// matching the rest of the path
+func segmentPathMatcher(routes []benchRoute) pathMatcher {
+	routes = scanRoutes(routes)
+	return func(method, path string) string {
+		for _, rt := range routes {
+			if rt.method == method && matchSegments(rt.pattern, path) {
+				return rt.pattern
+			}
+		}
+		return ""
+	}
+}
+
+func matchSegments(pattern, path string) bool {
+	for {
+		pseg, prest, pmore := strings.Cut(pattern, "/")
+		seg, rest, more := strings.Cut(path, "/")
+		if pseg == "*" && !pmore {
+			return true
+		}
+		if pmore != more || (pseg != seg && (!isParam(pseg) || seg == "")) {
+			return false
+		}
+		if !more {
+			return true
+		}
+		pattern, path = prest, rest
+	}
+}
+
+// replacerPathMatcher replaces the values of the params in the path with
+// the params of each route, and compares the result with its pattern
+func replacerPathMatcher(routes []benchRoute) pathMatcher {
+	routes = scanRoutes(routes)
+	segs := make([][]string, len(routes))
+	for i, rt := range routes {
+		segs[i] = strings.Split(rt.pattern, "/")
+	}
+	return func(method, path string) string {
+		var sb strings.Builder
+		for i, rt := range routes {
+			if rt.method != method {
+				continue
+			}
+			values := strings.Split(path, "/")
+			if n := len(segs[i]) - 1; segs[i][n] == "*" && len(values) > n {
+				values = append(values[:n], "*")
+			}
+			if len(segs[i]) != len(values) {
+				continue
+			}
+			sb.Reset()
+			for j, v := range values {
+				if j > 0 {
+					sb.WriteByte('/')
+				}
+				if isParam(segs[i][j]) && v != "" {
+					v = segs[i][j]
+				}
+				sb.WriteString(v)
+			}
+			if sb.String() == rt.pattern {
+				return rt.pattern
+			}
+		}
+		return ""
+	}
+}
+
+// regexpPathMatcher matches the path against a regexp compiled from each
+// route
+func regexpPathMatcher(routes []benchRoute) pathMatcher {
+	routes = scanRoutes(routes)
+	rexs := make([]*regexp.Regexp, len(routes))
+	for i, rt := range routes {
+		segs := strings.Split(rt.pattern, "/")
+		for j, seg := range segs {
+			switch {
+			case isParam(seg):
+				segs[j] = `[^/]+`
+			case seg == "*" && j == len(segs)-1:
+				segs[j] = `.*`
+			default:
+				segs[j] = regexp.QuoteMeta(seg)
+			}
+		}
+		rexs[i] = regexp.MustCompile("^" + strings.Join(segs, "/") + "$")
+	}
+	return func(method, path string) string {
+		for i, rt := range routes {
+			if rt.method == method && rexs[i].MatchString(path) {
+				return rt.pattern
+			}
+		}
+		return ""
+	}
+}
+
+// globPathMatcher matches the path using path.Match, with every param of
+// each route turned into a *. Since a * does not match a slash, only the
+// segments before the catch-all of a route are matched.
+func globPathMatcher(routes []benchRoute) pathMatcher {
+	routes = scanRoutes(routes)
+	globs := make([]string, len(routes))
+	catchAll := make([]int, len(routes))
+	for i, rt := range routes {
+		segs := strings.Split(rt.pattern, "/")
+		if n := len(segs) - 1; segs[n] == "*" {
+			segs, catchAll[i] = segs[:n], n
+		}
+		for j, seg := range segs {
+			if isParam(seg) {
+				segs[j] = "*"
+			}
+		}
+		globs[i] = strings.Join(segs, "/")
+	}
+	return func(method, p string) string {
+		for i, rt := range routes {
+			if rt.method != method {
+				continue
+			}
+			head := p
+			if catchAll[i] > 0 {
+				var ok bool
+				if head, _, ok = cutSegments(p, catchAll[i]); !ok {
+					continue
+				}
+			}
+			if ok, _ := path.Match(globs[i], head); ok {
+				return rt.pattern
+			}
+		}
+		return ""
+	}
+}
+
+func benchMatching(b *testing.B, match pathMatcher, routes []benchRoute) {
+	paths := make([]string, len(routes))
+	for i, rt := range routes {
+		paths[i] = benchPath(rt.pattern)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, rt := range routes {
+			if match(rt.method, paths[j]) == "" {
+				b.Fatalf("%s %s is not found", rt.method, paths[j])
+			}
+		}
+	}
+}
+
+func BenchmarkMatching(b *testing.B) {
+	for _, set := range benchSets {
+		for _, m := range pathMatchers(set.routes) {
+			b.Run(set.name+"/All/"+m.name, func(b *testing.B) {
+				benchMatching(b, m.match, set.routes)
+			})
+			b.Run(set.name+"/Single/"+m.name, func(b *testing.B) {
+				benchMatching(b, m.match, []benchRoute{set.single})
+			})
+		}
+	}
+}
+
+// TestBenchRoutes checks that every route of the benchmarks is found, by
+// both RouteMatchers and by the matchers by hand
+func TestBenchRoutes(t *testing.T) {
+	for _, set := range benchSets {
+		for _, m := range pathMatchers(set.routes) {
+			for _, rt := range set.routes {
+				path := benchPath(rt.pattern)
+				if got := m.match(rt.method, path); got != rt.pattern {
+					t.Errorf("%s: %s %s: got pattern %q, want %q", m.name, rt.method, path, got, rt.pattern)
+				}
+			}
+		}
+	}
+}
@@ -76,6 +76,14 @@ type Context struct {
 
 	// methodNotAllowed hint
 	methodNotAllowed bool
+
+	// methodsAllowed are the methods of the routes that matched the
+	// path, but not the method, used for the Allow header of a 405
+	methodsAllowed []methodTyp
+
+	// request is the request being routed, which the conditional routes
+	// (see When) are matched against
+	request *http.Request
 }
 
 // Reset a routing context to its initial state.
@@ -91,6 +99,8 @@ func (x *Context) Reset() {
 	x.routeParams.Keys = x.routeParams.Keys[:0]
 	x.routeParams.Values = x.routeParams.Values[:0]
 	x.methodNotAllowed = false
+	x.methodsAllowed = x.methodsAllowed[:0]
+	x.request = nil
 	x.parentCtx = nil
 }
 
@@ -124,6 +134,9 @@ func (x *Context) RoutePattern() string {
 	routePattern = replaceWildcards(routePattern)
 	routePattern = strings.TrimSuffix(routePattern, "//")
 	routePattern = strings.TrimSuffix(routePattern, "/")
+	if routePattern == "" {
+		routePattern = "/"
+	}
 	return routePattern
 }
 
@@ -157,3 +170,23 @@ type contextKey struct {
 func (k *contextKey) String() string {
 	return "chi context value " + k.name
 }
+
+// addMethodsAllowed records the methods that have a handler in the
+// endpoints, skipping any that have already been recorded.
+func (x *Context) addMethodsAllowed(eps endpoints) {
+	for mt, ep := range eps {
+		if mt == mALL || mt == mSTUB || ep.handler == nil {
+			continue
+		}
+		var seen bool
+		for _, m := range x.methodsAllowed {
+			if m == mt {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			x.methodsAllowed = append(x.methodsAllowed, mt)
+		}
+	}
+}
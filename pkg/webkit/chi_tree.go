@@ -334,25 +334,29 @@ func (n *chiTreeNode) setEndpoint(method methodTyp, handler http.Handler, patter
 	if n.endpoints == nil {
 		n.endpoints = make(endpoints)
 	}
+	n.endpoints.set(method, handler, pattern)
+}
 
+// set sets the handler for the method type
+func (s endpoints) set(method methodTyp, handler http.Handler, pattern string) {
 	paramKeys := patParamKeys(pattern)
 
 	if method&mSTUB == mSTUB {
-		n.endpoints.Value(mSTUB).handler = handler
+		s.Value(mSTUB).handler = handler
 	}
 	if method&mALL == mALL {
-		h := n.endpoints.Value(mALL)
+		h := s.Value(mALL)
 		h.handler = handler
 		h.pattern = pattern
 		h.paramKeys = paramKeys
 		for _, m := range methodMap {
-			h := n.endpoints.Value(m)
+			h := s.Value(m)
 			h.handler = handler
 			h.pattern = pattern
 			h.paramKeys = paramKeys
 		}
 	} else {
-		h := n.endpoints.Value(method)
+		h := s.Value(method)
 		h.handler = handler
 		h.pattern = pattern
 		h.paramKeys = paramKeys
@@ -366,7 +370,7 @@ func (n *chiTreeNode) FindRoute(rctx *Context, method methodTyp, path string) (*
 	rctx.routeParams.Values = rctx.routeParams.Values[:0]
 
 	// Find the routing handlers for the path
-	rn := n.findRoute(rctx, method, path)
+	rn, h := n.findRoute(rctx, method, path)
 	if rn == nil {
 		return nil, nil, nil
 	}
@@ -381,12 +385,14 @@ func (n *chiTreeNode) FindRoute(rctx *Context, method methodTyp, path string) (*
 		rctx.RoutePatterns = append(rctx.RoutePatterns, rctx.routePattern)
 	}
 
-	return rn, rn.endpoints, rn.endpoints[method].handler
+	return rn, rn.endpoints, h
 }
 
 // Recursive edge traversal by checking all nodeTyp groups along the way.
-// It's like searching through a multi-dimensional radix trie.
-func (n *chiTreeNode) findRoute(rctx *Context, method methodTyp, path string) *chiTreeNode {
+// It's like searching through a multi-dimensional radix trie. It returns
+// the node of the route and the handler of its endpoint for the method
+// (see endpointHandler).
+func (n *chiTreeNode) findRoute(rctx *Context, method methodTyp, path string) (*chiTreeNode, http.Handler) {
 	nn := n
 	search := path
 
@@ -450,22 +456,16 @@ func (n *chiTreeNode) findRoute(rctx *Context, method methodTyp, path string) *c
 
 				if len(xsearch) == 0 {
 					if xn.isLeaf() {
-						h := xn.endpoints[method]
-						if h != nil && h.handler != nil {
-							rctx.routeParams.Keys = append(rctx.routeParams.Keys, h.paramKeys...)
-							return xn
+						if h := xn.endpointHandler(rctx, method); h != nil {
+							return xn, h
 						}
-
-						// flag that the routing context found a route, but not a corresponding
-						// supported method
-						rctx.methodNotAllowed = true
 					}
 				}
 
 				// recursively find the next chiTreeNode on this branch
-				fin := xn.findRoute(rctx, method, xsearch)
+				fin, h := xn.findRoute(rctx, method, xsearch)
 				if fin != nil {
-					return fin
+					return fin, h
 				}
 
 				// not found on this branch, reset vars
@@ -489,22 +489,16 @@ func (n *chiTreeNode) findRoute(rctx *Context, method methodTyp, path string) *c
 		// did we find it yet?
 		if len(xsearch) == 0 {
 			if xn.isLeaf() {
-				h := xn.endpoints[method]
-				if h != nil && h.handler != nil {
-					rctx.routeParams.Keys = append(rctx.routeParams.Keys, h.paramKeys...)
-					return xn
+				if h := xn.endpointHandler(rctx, method); h != nil {
+					return xn, h
 				}
-
-				// flag that the routing context found a route, but not a corresponding
-				// supported method
-				rctx.methodNotAllowed = true
 			}
 		}
 
 		// recursively find the next chiTreeNode..
-		fin := xn.findRoute(rctx, method, xsearch)
+		fin, h := xn.findRoute(rctx, method, xsearch)
 		if fin != nil {
-			return fin
+			return fin, h
 		}
 
 		// Did not find final handler, let's remove the param here if it was set
@@ -516,7 +510,26 @@ func (n *chiTreeNode) findRoute(rctx *Context, method methodTyp, path string) *c
 
 	}
 
-	return nil
+	return nil, nil
+}
+
+// endpointHandler returns the handler of the leaf node for the method, or
+// nil if it has none, in which case the routing context is flagged with the
+// methods it allows, or if the conditions of its routes do not match
+func (n *chiTreeNode) endpointHandler(rctx *Context, method methodTyp) http.Handler {
+	h := n.endpoints[method]
+	if h == nil || h.handler == nil {
+		// flag that the routing context found a route, but not a corresponding
+		// supported method
+		rctx.methodNotAllowed = true
+		rctx.addMethodsAllowed(n.endpoints)
+		return nil
+	}
+	eh := endpointHandler(rctx, h.handler)
+	if eh != nil {
+		rctx.routeParams.Keys = append(rctx.routeParams.Keys, h.paramKeys...)
+	}
+	return eh
 }
 
 func (n *chiTreeNode) findEdge(ntyp nodeTyp, label byte) *chiTreeNode {
@@ -598,50 +611,55 @@ func (n *chiTreeNode) routes() []Route {
 
 	n.walk(
 		func(eps endpoints, subroutes Routes) bool {
-			if eps[mSTUB] != nil && eps[mSTUB].handler != nil && subroutes == nil {
-				return false
-			}
+			rts = appendRoutes(rts, eps, subroutes)
+			return false
+		},
+	)
 
-			// Group methodHandlers by unique patterns
-			pats := make(map[string]endpoints)
+	return rts
+}
 
-			for mt, h := range eps {
-				if h.pattern == "" {
-					continue
-				}
-				p, ok := pats[h.pattern]
-				if !ok {
-					p = endpoints{}
-					pats[h.pattern] = p
-				}
-				p[mt] = h
-			}
+// appendRoutes appends the routes of the endpoints, grouped by pattern
+func appendRoutes(rts []Route, eps endpoints, subroutes Routes) []Route {
+	if eps[mSTUB] != nil && eps[mSTUB].handler != nil && subroutes == nil {
+		return rts
+	}
 
-			for p, mh := range pats {
-				hs := make(map[string]http.Handler)
-				if mh[mALL] != nil && mh[mALL].handler != nil {
-					hs["*"] = mh[mALL].handler
-				}
+	// Group methodHandlers by unique patterns
+	pats := make(map[string]endpoints)
 
-				for mt, h := range mh {
-					if h.handler == nil {
-						continue
-					}
-					m := methodTypString(mt)
-					if m == "" {
-						continue
-					}
-					hs[m] = h.handler
-				}
+	for mt, h := range eps {
+		if h.pattern == "" {
+			continue
+		}
+		p, ok := pats[h.pattern]
+		if !ok {
+			p = endpoints{}
+			pats[h.pattern] = p
+		}
+		p[mt] = h
+	}
 
-				rt := Route{subroutes, hs, p}
-				rts = append(rts, rt)
-			}
+	for p, mh := range pats {
+		hs := make(map[string]http.Handler)
+		if mh[mALL] != nil && mh[mALL].handler != nil {
+			hs["*"] = mh[mALL].handler
+		}
 
-			return false
-		},
-	)
+		for mt, h := range mh {
+			if h.handler == nil {
+				continue
+			}
+			m := methodTypString(mt)
+			if m == "" {
+				continue
+			}
+			hs[m] = h.handler
+		}
 
+		rt := Route{subroutes, hs, p}
+		rts = append(rts, rt)
+	}
 	return rts
 }
 
@@ -715,7 +733,10 @@ func patNextSegment(pattern string) (nodeTyp, string, string, byte, int, int) {
 			key = key[:idx]
 		}
 
-		if len(rexpat) > 0 {
+		if pat, ok := paramTypePattern(rexpat); ok {
+			// Named param type, as in {id:int}
+			rexpat = pat
+		} else if len(rexpat) > 0 {
 			if rexpat[0] != '^' {
 				rexpat = "^" + rexpat
 			}
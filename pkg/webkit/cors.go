@@ -0,0 +1,252 @@
+package webkit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins are the origins a cross-domain request can be made
+	// from. An origin may contain one "*" as a wildcard, as in
+	// "https://*.example.com", and "*" alone allows every origin. The
+	// default is "*".
+	AllowedOrigins []string
+
+	// AllowOriginFunc is a custom function to validate the origin. It is
+	// used instead of AllowedOrigins when it is set.
+	AllowOriginFunc func(r *http.Request, origin string) bool
+
+	// AllowedMethods are the methods a cross-domain request can use. The
+	// default is GET, HEAD and POST.
+	AllowedMethods []string
+
+	// AllowedHeaders are the non simple headers a cross-domain request can
+	// use, and "*" allows every header. The default is Accept, Content-Type
+	// and X-Requested-With.
+	AllowedHeaders []string
+
+	// ExposedHeaders are the headers that are safe to expose to the client.
+	ExposedHeaders []string
+
+	// AllowCredentials reports whether the request can include credentials
+	// like cookies, HTTP authentication or client side certificates.
+	AllowCredentials bool
+
+	// MaxAge is the number of seconds the result of a preflight request can
+	// be cached for, if it is greater than zero.
+	MaxAge int
+
+	// OptionsPassthrough passes preflight requests on to the next handler,
+	// instead of answering them with a 204.
+	OptionsPassthrough bool
+}
+
+// cors is the CORS middleware built from CORSOptions
+type cors struct {
+	origins     []string
+	allOrigins  bool
+	originFunc  func(r *http.Request, origin string) bool
+	methods     []string
+	headers     []string
+	allHeaders  bool
+	exposed     string
+	credentials bool
+	maxAge      string
+	passthrough bool
+}
+
+// CORS returns a middleware that handles Cross-Origin Resource Sharing,
+// answering preflight requests and adding the CORS headers to the cross
+// domain requests that are allowed. It composes with Chain and Use like
+// any other middleware, and it should be used before the routes so that
+// it sees the preflight requests.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	c := &cors{
+		originFunc:  opts.AllowOriginFunc,
+		exposed:     strings.Join(canonicalHeaders(opts.ExposedHeaders), ", "),
+		credentials: opts.AllowCredentials,
+		passthrough: opts.OptionsPassthrough,
+	}
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(opts.MaxAge)
+	}
+
+	origins := opts.AllowedOrigins
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+	for _, o := range origins {
+		if o == "*" {
+			c.allOrigins = true
+			break
+		}
+		c.origins = append(c.origins, strings.ToLower(o))
+	}
+
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	for _, m := range methods {
+		c.methods = append(c.methods, strings.ToUpper(m))
+	}
+
+	headers := opts.AllowedHeaders
+	if len(headers) == 0 {
+		headers = []string{"Accept", "Content-Type", "X-Requested-With"}
+	}
+	for _, h := range headers {
+		if h == "*" {
+			c.allHeaders = true
+			break
+		}
+	}
+	c.headers = canonicalHeaders(headers)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				c.preflight(w, r)
+				if c.passthrough {
+					next.ServeHTTP(w, r)
+				} else {
+					w.WriteHeader(http.StatusNoContent)
+				}
+				return
+			}
+			c.actual(w, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// preflight sets the headers answering a preflight request
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	origin := r.Header.Get("Origin")
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	if origin == "" || !c.allowedOrigin(r, origin) {
+		return
+	}
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if !c.allowedMethod(method) {
+		return
+	}
+	headers := parseHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+	if !c.allowedHeaders(headers) {
+		return
+	}
+	c.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", method)
+	if len(headers) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if c.maxAge != "" {
+		h.Set("Access-Control-Max-Age", c.maxAge)
+	}
+}
+
+// actual sets the headers of an allowed cross-domain request
+func (c *cors) actual(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	origin := r.Header.Get("Origin")
+	h.Add("Vary", "Origin")
+	if origin == "" || !c.allowedOrigin(r, origin) || !c.allowedMethod(r.Method) {
+		return
+	}
+	c.setOrigin(h, origin)
+	if c.exposed != "" {
+		h.Set("Access-Control-Expose-Headers", c.exposed)
+	}
+}
+
+// setOrigin sets the allowed origin, and whether credentials are allowed.
+// The origin is echoed back, rather than "*", when credentials are allowed,
+// as browsers reject a wildcard along with credentials.
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.allOrigins && !c.credentials && c.originFunc == nil {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) allowedOrigin(r *http.Request, origin string) bool {
+	if c.originFunc != nil {
+		return c.originFunc(r, origin)
+	}
+	if c.allOrigins {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, o := range c.origins {
+		if i := strings.IndexByte(o, '*'); i >= 0 {
+			prefix, suffix := o[:i], o[i+1:]
+			if len(origin) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		} else if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) allowedMethod(method string) bool {
+	// preflight requests are always allowed
+	if method == http.MethodOptions {
+		return true
+	}
+	for _, m := range c.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) allowedHeaders(headers []string) bool {
+	if c.allHeaders {
+		return true
+	}
+	for _, h := range headers {
+		found := false
+		for _, a := range c.headers {
+			if a == h {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// parseHeaderList returns the canonical header names in a comma
+// separated list
+func parseHeaderList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return canonicalHeaders(strings.Split(list, ","))
+}
+
+func canonicalHeaders(headers []string) []string {
+	canonical := make([]string, 0, len(headers))
+	for _, h := range headers {
+		if h = strings.TrimSpace(h); h != "" {
+			canonical = append(canonical, http.CanonicalHeaderKey(h))
+		}
+	}
+	return canonical
+}
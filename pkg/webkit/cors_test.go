@@ -0,0 +1,115 @@
+package webkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func corsRequest(t *testing.T, h http.Handler, method, origin string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, "/users", nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCORS(t *testing.T) {
+	m := NewMux()
+	m.Use(CORS(CORSOptions{
+		AllowedOrigins:   []string{"https://example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"get", "post", "delete"},
+		AllowedHeaders:   []string{"content-type", "x-token"},
+		ExposedHeaders:   []string{"x-total"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}))
+	m.Get("/users", writeString("users"))
+	m.Delete("/users", writeString("deleted"))
+
+	// preflight
+	w := corsRequest(t, m, "OPTIONS", "https://api.example.org",
+		"Access-Control-Request-Method", "DELETE",
+		"Access-Control-Request-Headers", "x-token, Content-Type")
+	h := w.Header()
+	if w.Code != 204 || h.Get("Access-Control-Allow-Origin") != "https://api.example.org" ||
+		h.Get("Access-Control-Allow-Methods") != "DELETE" ||
+		h.Get("Access-Control-Allow-Headers") != "X-Token, Content-Type" ||
+		h.Get("Access-Control-Allow-Credentials") != "true" ||
+		h.Get("Access-Control-Max-Age") != "600" || len(h.Values("Vary")) != 3 {
+		t.Fatalf("preflight: got %d %v", w.Code, h)
+	}
+
+	// preflight rejections
+	for _, c := range [][]string{
+		{"https://evil.com", "DELETE", ""},
+		{"https://example.org", "DELETE", ""},
+		{"https://example.com", "PUT", ""},
+		{"https://example.com", "GET", "X-Other"},
+	} {
+		w = corsRequest(t, m, "OPTIONS", c[0],
+			"Access-Control-Request-Method", c[1],
+			"Access-Control-Request-Headers", c[2])
+		if w.Code != 204 || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Fatalf("preflight %v: got %d %v", c, w.Code, w.Header())
+		}
+	}
+
+	// actual requests
+	w = corsRequest(t, m, "GET", "https://example.com")
+	h = w.Header()
+	if w.Body.String() != "users" || h.Get("Access-Control-Allow-Origin") != "https://example.com" ||
+		h.Get("Access-Control-Expose-Headers") != "X-Total" || h.Get("Vary") != "Origin" {
+		t.Fatalf("actual: got %q %v", w.Body.String(), h)
+	}
+	w = corsRequest(t, m, "GET", "https://evil.com")
+	if w.Body.String() != "users" || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("actual from a disallowed origin: got %q %v", w.Body.String(), w.Header())
+	}
+	w = corsRequest(t, m, "GET", "")
+	if w.Body.String() != "users" || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("same origin: got %q %v", w.Body.String(), w.Header())
+	}
+
+	// a plain OPTIONS request is not a preflight, and reaches the router
+	w = corsRequest(t, m, "OPTIONS", "https://example.com")
+	if w.Code != 204 || w.Header().Get("Allow") != "DELETE, GET, HEAD, OPTIONS" {
+		t.Fatalf("options: got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestCORS_Defaults(t *testing.T) {
+	var passed bool
+	h := Chain(CORS(CORSOptions{OptionsPassthrough: true})).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		passed = true
+		w.WriteHeader(http.StatusOK)
+	})
+	w := corsRequest(t, h, "OPTIONS", "https://anywhere.com",
+		"Access-Control-Request-Method", "POST",
+		"Access-Control-Request-Headers", "content-type")
+	if !passed || w.Code != 200 || w.Header().Get("Access-Control-Allow-Origin") != "*" ||
+		w.Header().Get("Access-Control-Max-Age") != "" {
+		t.Fatalf("preflight: got %v %d %v", passed, w.Code, w.Header())
+	}
+	w = corsRequest(t, h, "PUT", "https://anywhere.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected PUT to not be allowed by default, got %v", w.Header())
+	}
+
+	h = Chain(CORS(CORSOptions{
+		AllowOriginFunc: func(r *http.Request, origin string) bool { return origin == "null" },
+		AllowedHeaders:  []string{"*"},
+	})).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	w = corsRequest(t, h, "OPTIONS", "null",
+		"Access-Control-Request-Method", "GET",
+		"Access-Control-Request-Headers", "x-anything")
+	if w.Header().Get("Access-Control-Allow-Origin") != "null" || w.Header().Get("Access-Control-Allow-Headers") != "X-Anything" {
+		t.Fatalf("origin func: got %v", w.Header())
+	}
+}
@@ -0,0 +1,329 @@
+package webkit
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileServerOptions configures the file servers of FileServer and
+// NewFileHandler.
+type FileServerOptions struct {
+	// Browse lists the files of the directories without an index.html,
+	// which are not found otherwise.
+	Browse bool
+
+	// SPA serves the index.html of the root for the paths without a file
+	// extension that are not found, so that a single page app can route
+	// them itself. The index.html is served with Cache-Control: no-cache.
+	SPA bool
+
+	// MaxAge is how long the files can be cached for, if it is greater
+	// than zero.
+	MaxAge time.Duration
+
+	// Dotfiles serves the files and directories whose name starts with a
+	// dot, such as .env or .git, which are not found and not listed
+	// otherwise.
+	Dotfiles bool
+}
+
+// precompressed are the encodings of the precompressed files, in order of
+// preference, with the extension of the files
+var precompressed = []struct {
+	encoding, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// fileHandler serves the files of a fs.FS
+type fileHandler struct {
+	fsys     fs.FS
+	opts     FileServerOptions
+	notFound http.HandlerFunc
+
+	// etags of the files without a modification time, such as those of
+	// an embed.FS, by name
+	etags sync.Map
+}
+
+// FileServer serves the files of fsys along the `pattern`, which must not
+// have any URL params, as in
+//
+//	mux.FileServer("/static", os.DirFS("public"), webkit.FileServerOptions{})
+//
+// The pattern is routed with a catch-all, and a request for the pattern
+// without its trailing slash is redirected to it. Use fs.Sub to serve a
+// directory of an embed.FS.
+//
+// The files are served with http.ServeContent, which handles range and
+// conditional requests. A file with a ".br" or ".gz" sibling is served
+// precompressed to the clients accepting the encoding. Paths that would
+// leave the root of fsys are not found, and neither are dotfiles unless
+// FileServerOptions.Dotfiles is set.
+func (mx *Mux) FileServer(pattern string, fsys fs.FS, opts FileServerOptions) {
+	if strings.ContainsAny(pattern, "{}*") {
+		panic(fmt.Sprintf("webkit: FileServer does not permit any URL params in '%s'", pattern))
+	}
+	root := mx.root()
+	fh := &fileHandler{fsys: fsys, opts: opts, notFound: func(w http.ResponseWriter, r *http.Request) {
+		root.NotFoundHandler().ServeHTTP(w, r)
+	}}
+
+	if pattern == "" || pattern[len(pattern)-1] != '/' {
+		mx.Get(pattern, func(w http.ResponseWriter, r *http.Request) {
+			localRedirect(w, r, path.Base(r.URL.Path)+"/")
+		})
+		pattern += "/"
+	}
+	mx.Get(pattern+"*", func(w http.ResponseWriter, r *http.Request) {
+		fh.serve(w, r, URLParam(r, "*"))
+	})
+}
+
+// NewFileHandler returns a handler serving the files of fsys, like the
+// routes of FileServer, to be mounted with Mount or used along with
+// http.StripPrefix. It serves the routing path of a mounted handler, or
+// else the path of the URL.
+func NewFileHandler(fsys fs.FS, opts FileServerOptions) http.Handler {
+	return &fileHandler{fsys: fsys, opts: opts, notFound: http.NotFound}
+}
+
+func (h *fileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.URL.Path
+	if r.URL.RawPath != "" {
+		name = r.URL.RawPath
+	}
+	if rctx := RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+		name = rctx.RoutePath
+	}
+	h.serve(w, r, name)
+}
+
+// serve serves the file of the URL path `name`
+func (h *fileHandler) serve(w http.ResponseWriter, r *http.Request, name string) {
+	// a routing path taken from the raw path is still escaped
+	if r.URL.RawPath != "" {
+		var err error
+		if name, err = url.PathUnescape(name); err != nil {
+			h.notFound(w, r)
+			return
+		}
+	}
+	name, ok := fileName(name)
+	if !ok || (!h.opts.Dotfiles && isDotfile(name)) {
+		h.notFound(w, r)
+		return
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	if err == nil && info.IsDir() {
+		if r.URL.Path != "" && !strings.HasSuffix(r.URL.Path, "/") {
+			localRedirect(w, r, path.Base(r.URL.Path)+"/")
+			return
+		}
+		index := path.Join(name, "index.html")
+		if info, err := fs.Stat(h.fsys, index); err == nil && !info.IsDir() {
+			h.serveFile(w, r, index, info)
+			return
+		}
+		if h.opts.Browse {
+			h.serveDir(w, r, name)
+			return
+		}
+	} else if err == nil {
+		h.serveFile(w, r, name, info)
+		return
+	}
+
+	if h.opts.SPA && path.Ext(name) == "" {
+		if info, err := fs.Stat(h.fsys, "index.html"); err == nil && !info.IsDir() {
+			w.Header().Set("Cache-Control", "no-cache")
+			h.serveFile(w, r, "index.html", info)
+			return
+		}
+	}
+	h.notFound(w, r)
+}
+
+// serveFile serves the file, or its precompressed sibling for an encoding
+// the client accepts
+func (h *fileHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, info fs.FileInfo) {
+	header := w.Header()
+	ctype := mime.TypeByExtension(path.Ext(name))
+
+	file, suffix, vary := name, "", false
+	for _, pc := range precompressed {
+		cinfo, err := fs.Stat(h.fsys, name+pc.ext)
+		if err != nil || cinfo.IsDir() {
+			continue
+		}
+		if !vary {
+			header.Add("Vary", "Accept-Encoding")
+			vary = true
+		}
+		if file == name && acceptsEncoding(r, pc.encoding) {
+			file, suffix, info = name+pc.ext, "-"+pc.ext[1:], cinfo
+			header.Set("Content-Encoding", pc.encoding)
+		}
+	}
+	if file != name && ctype == "" {
+		// the content of the compressed file can not be sniffed
+		ctype = "application/octet-stream"
+	}
+	if ctype != "" {
+		header.Set("Content-Type", ctype)
+	}
+
+	f, err := h.fsys.Open(file)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+
+	etag, err := h.etag(file, info, content)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	header.Set("ETag", `"`+etag+suffix+`"`)
+	if h.opts.MaxAge > 0 && header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(h.opts.MaxAge/time.Second)))
+	}
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// etag returns the entity tag of the file, made of its modification time
+// and size, or else of a hash of its content
+func (h *fileHandler) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !info.ModTime().IsZero() {
+		return strconv.FormatInt(info.ModTime().UnixNano(), 36) + "-" + strconv.FormatInt(info.Size(), 36), nil
+	}
+	if etag, ok := h.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+	hash := fnv.New64a()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := strconv.FormatUint(hash.Sum64(), 36)
+	h.etags.Store(name, etag)
+	return etag, nil
+}
+
+// serveDir serves a listing of the files of the directory
+func (h *fileHandler) serveDir(w http.ResponseWriter, r *http.Request, name string) {
+	entries, err := fs.ReadDir(h.fsys, name)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+	fmt.Fprintf(w, "<!doctype html>\n<title>%s</title>\n<pre>\n", html.EscapeString(path.Join("/", name)))
+	for _, e := range entries {
+		n := e.Name()
+		if !h.opts.Dotfiles && strings.HasPrefix(n, ".") {
+			continue
+		}
+		if e.IsDir() {
+			n += "/"
+		}
+		href := url.URL{Path: n}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", html.EscapeString(href.String()), html.EscapeString(n))
+	}
+	fmt.Fprintf(w, "</pre>\n")
+}
+
+// fileName returns the name in the fs.FS of the URL path, which is cleaned
+// so that it can not leave the root, and reports whether it is valid
+func fileName(urlPath string) (string, bool) {
+	if strings.ContainsAny(urlPath, "\\\x00") {
+		return "", false
+	}
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		name = "."
+	}
+	return name, fs.ValidPath(name)
+}
+
+// isDotfile reports whether any element of the name in the fs.FS starts
+// with a dot
+func isDotfile(name string) bool {
+	if name == "." {
+		return false
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if strings.HasPrefix(elem, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsEncoding reports whether the Accept-Encoding of the request
+// accepts the encoding
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(v, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), encoding) {
+			continue
+		}
+		key, value, _ := strings.Cut(strings.TrimSpace(params), "=")
+		if strings.TrimSpace(key) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// localRedirect redirects the request to the relative path, keeping its
+// query
+func localRedirect(w http.ResponseWriter, r *http.Request, target string) {
+	if q := r.URL.RawQuery; q != "" {
+		target += "?" + q
+	}
+	w.Header().Set("Location", target)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
@@ -0,0 +1,384 @@
+package webkit
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+//go:embed testdata/static
+var embedded embed.FS
+
+var modTime = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// testFS has a public directory to serve, and a secret file beside it
+var testFS = fstest.MapFS{
+	"secret.txt":             {Data: []byte("secret"), ModTime: modTime},
+	"public/index.html":      {Data: []byte("<p>home</p>"), ModTime: modTime},
+	"public/hello.txt":       {Data: []byte("hello, world"), ModTime: modTime},
+	"public/app.js":          {Data: []byte("plain js"), ModTime: modTime},
+	"public/app.js.gz":       {Data: []byte("gzip js"), ModTime: modTime},
+	"public/app.js.br":       {Data: []byte("brotli js"), ModTime: modTime},
+	"public/docs/a.txt":      {Data: []byte("a"), ModTime: modTime},
+	"public/docs/<b>.txt":    {Data: []byte("b"), ModTime: modTime},
+	"public/docs/sub/c.txt":  {Data: []byte("c"), ModTime: modTime},
+	"public/blog/index.html": {Data: []byte("<p>blog</p>"), ModTime: modTime},
+	"public/.env":            {Data: []byte("TOKEN=secret"), ModTime: modTime},
+	"public/.git/config":     {Data: []byte("[core]"), ModTime: modTime},
+	"public/docs/.hidden":    {Data: []byte("hidden"), ModTime: modTime},
+}
+
+func publicFS(t *testing.T) fs.FS {
+	t.Helper()
+	fsys, err := fs.Sub(testFS, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsys
+}
+
+func fileRequest(t *testing.T, h http.Handler, method, path string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMux_FileServer(t *testing.T) {
+	m := NewMux()
+	m.FileServer("/static", publicFS(t), FileServerOptions{MaxAge: time.Hour})
+
+	tests := []struct {
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		{"/static", 301, "", "static/"},
+		{"/static?v=1", 301, "", "static/?v=1"},
+		{"/static/", 200, "<p>home</p>", ""},
+		{"/static/hello.txt", 200, "hello, world", ""},
+		{"/static/blog", 301, "", "blog/"},
+		{"/static/blog/", 200, "<p>blog</p>", ""},
+		{"/static/docs/", 404, "", ""},
+		{"/static/missing.txt", 404, "", ""},
+		{"/static/hello%20world.txt", 404, "", ""},
+	}
+	for _, tt := range tests {
+		w := fileRequest(t, m, "GET", tt.path)
+		if w.Code != tt.status {
+			t.Errorf("%s: got status %d, want %d", tt.path, w.Code, tt.status)
+			continue
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s: got body %q, want %q", tt.path, w.Body.String(), tt.body)
+		}
+		if got := w.Header().Get("Location"); got != tt.location {
+			t.Errorf("%s: got location %q, want %q", tt.path, got, tt.location)
+		}
+	}
+
+	w := fileRequest(t, m, "GET", "/static/hello.txt")
+	h := w.Header()
+	if got := h.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("got content type %q", got)
+	}
+	if got := h.Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("got cache control %q", got)
+	}
+	if got := h.Get("Last-Modified"); got != modTime.Format(http.TimeFormat) {
+		t.Errorf("got last modified %q", got)
+	}
+	etag := h.Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an etag")
+	}
+
+	// conditional requests
+	w = fileRequest(t, m, "GET", "/static/hello.txt", "If-None-Match", etag)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("if-none-match: got status %d, want 304", w.Code)
+	}
+	w = fileRequest(t, m, "GET", "/static/hello.txt", "If-Modified-Since", modTime.Format(http.TimeFormat))
+	if w.Code != http.StatusNotModified {
+		t.Errorf("if-modified-since: got status %d, want 304", w.Code)
+	}
+
+	// HEAD is answered with the GET route, without a body
+	w = fileRequest(t, m, "HEAD", "/static/hello.txt")
+	if w.Code != 200 || w.Body.Len() != 0 || w.Header().Get("Content-Length") != "12" {
+		t.Errorf("head: got %d %q, length %q", w.Code, w.Body.String(), w.Header().Get("Content-Length"))
+	}
+
+	w = fileRequest(t, m, "POST", "/static/hello.txt")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("post: got status %d, want 405", w.Code)
+	}
+}
+
+func TestMux_FileServerRanges(t *testing.T) {
+	m := NewMux()
+	m.FileServer("/", publicFS(t), FileServerOptions{})
+
+	w := fileRequest(t, m, "GET", "/hello.txt", "Range", "bytes=7-")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "world" {
+		t.Errorf("range: got %d %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes 7-11/12" {
+		t.Errorf("range: got content range %q", got)
+	}
+
+	w = fileRequest(t, m, "GET", "/hello.txt", "Range", "bytes=20-30")
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("bad range: got status %d, want 416", w.Code)
+	}
+
+	// a stale If-Range serves the whole file
+	w = fileRequest(t, m, "GET", "/hello.txt", "Range", "bytes=0-4", "If-Range", `"stale"`)
+	if w.Code != 200 || w.Body.String() != "hello, world" {
+		t.Errorf("if-range: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestMux_FileServerPrecompressed(t *testing.T) {
+	m := NewMux()
+	m.FileServer("/", publicFS(t), FileServerOptions{})
+
+	tests := []struct {
+		accept   string
+		body     string
+		encoding string
+	}{
+		{"", "plain js", ""},
+		{"gzip", "gzip js", "gzip"},
+		{"gzip, deflate, br", "brotli js", "br"},
+		{"br;q=0, gzip", "gzip js", "gzip"},
+		{"identity", "plain js", ""},
+	}
+	var etags []string
+	for _, tt := range tests {
+		w := fileRequest(t, m, "GET", "/app.js", "Accept-Encoding", tt.accept)
+		h := w.Header()
+		if w.Body.String() != tt.body || h.Get("Content-Encoding") != tt.encoding {
+			t.Errorf("%q: got %q, encoding %q", tt.accept, w.Body.String(), h.Get("Content-Encoding"))
+		}
+		if got := h.Get("Content-Type"); got != "text/javascript; charset=utf-8" && got != "application/javascript" {
+			t.Errorf("%q: got content type %q", tt.accept, got)
+		}
+		if got := h.Values("Vary"); len(got) != 1 || got[0] != "Accept-Encoding" {
+			t.Errorf("%q: got vary %q", tt.accept, got)
+		}
+		etags = append(etags, h.Get("ETag"))
+	}
+	if etags[0] == etags[1] || etags[1] == etags[2] {
+		t.Errorf("expected an etag per encoding, got %q", etags)
+	}
+
+	// files without a precompressed sibling do not vary
+	w := fileRequest(t, m, "GET", "/hello.txt", "Accept-Encoding", "gzip")
+	if w.Header().Get("Vary") != "" || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("hello.txt: got vary %q, encoding %q", w.Header().Get("Vary"), w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestMux_FileServerBrowse(t *testing.T) {
+	m := NewMux()
+	m.FileServer("/files", publicFS(t), FileServerOptions{Browse: true})
+
+	w := fileRequest(t, m, "GET", "/files/docs/")
+	if w.Code != 200 || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("got %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`<a href="%3Cb%3E.txt">&lt;b&gt;.txt</a>`,
+		`<a href="a.txt">a.txt</a>`,
+		`<a href="sub/">sub/</a>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in listing:\n%s", want, body)
+		}
+	}
+	if strings.Index(body, "&lt;b&gt;") > strings.Index(body, "a.txt") {
+		t.Errorf("expected the listing to be sorted:\n%s", body)
+	}
+
+	// directories with an index.html are not listed
+	w = fileRequest(t, m, "GET", "/files/blog/")
+	if w.Body.String() != "<p>blog</p>" {
+		t.Errorf("got %q, want the index", w.Body.String())
+	}
+}
+
+func TestMux_FileServerDotfiles(t *testing.T) {
+	m := NewMux()
+	m.FileServer("/", publicFS(t), FileServerOptions{Browse: true})
+	for _, p := range []string{"/.env", "/.git/config", "/.git/", "/docs/.hidden", "/docs/../.env"} {
+		if w := fileRequest(t, m, "GET", p); w.Code != 404 {
+			t.Errorf("%s: got %d, want 404", p, w.Code)
+		}
+	}
+	if body := fileRequest(t, m, "GET", "/docs/").Body.String(); strings.Contains(body, ".hidden") {
+		t.Errorf("expected the dotfile not to be listed:\n%s", body)
+	}
+
+	m = NewMux()
+	m.FileServer("/files", publicFS(t), FileServerOptions{Browse: true, Dotfiles: true})
+	if w := fileRequest(t, m, "GET", "/files/.env"); w.Code != 200 || w.Body.String() != "TOKEN=secret" {
+		t.Errorf("got %d %q, want the dotfile", w.Code, w.Body.String())
+	}
+	if body := fileRequest(t, m, "GET", "/files/docs/").Body.String(); !strings.Contains(body, ".hidden") {
+		t.Errorf("expected the dotfile to be listed:\n%s", body)
+	}
+}
+
+func TestMux_FileServerSPA(t *testing.T) {
+	m := NewMux()
+	m.Get("/api/users", writeString("users"))
+	m.FileServer("/", publicFS(t), FileServerOptions{SPA: true, MaxAge: time.Hour})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/users/42", 200, "<p>home</p>"},
+		{"/settings/profile", 200, "<p>home</p>"},
+		{"/hello.txt", 200, "hello, world"},
+		{"/missing.js", 404, ""},
+		{"/api/users", 200, "users"},
+	}
+	for _, tt := range tests {
+		w := fileRequest(t, m, "GET", tt.path)
+		if w.Code != tt.status || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+
+	w := fileRequest(t, m, "GET", "/users/42")
+	if got := w.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("fallback: got cache control %q, want no-cache", got)
+	}
+}
+
+func TestMux_FileServerNotFound(t *testing.T) {
+	m := NewMux()
+	m.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		w.Write([]byte("custom not found"))
+	})
+	m.FileServer("/static", publicFS(t), FileServerOptions{})
+
+	w := fileRequest(t, m, "GET", "/static/missing.txt")
+	if w.Code != 404 || w.Body.String() != "custom not found" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestMux_FileServerTraversal(t *testing.T) {
+	paths := []string{
+		"/static/../secret.txt",
+		"/static/../../secret.txt",
+		"/static/..%2fsecret.txt",
+		"/static/%2e%2e/secret.txt",
+		"/static/%2e%2e%2fsecret.txt",
+		"/static/docs/../../secret.txt",
+		"/static/..%5csecret.txt",
+		"/static/..\\secret.txt",
+		"/static//../secret.txt",
+		"/static/%00secret.txt",
+	}
+
+	// a subtree of a fs.FS with a secret file beside it
+	m := NewMux()
+	m.FileServer("/static", publicFS(t), FileServerOptions{Browse: true, SPA: true})
+	for _, p := range paths {
+		w := fileRequest(t, m, "GET", p)
+		if strings.Contains(w.Body.String(), "secret") {
+			t.Errorf("%s: served the secret file", p)
+		}
+	}
+
+	// a directory on disk, with the source files beside it
+	m = NewMux()
+	m.FileServer("/static", os.DirFS("testdata/static"), FileServerOptions{Browse: true})
+	for _, p := range []string{
+		"/static/../../file_server.go",
+		"/static/..%2f..%2ffile_server.go",
+		"/static/%2e%2e/%2e%2e/file_server.go",
+	} {
+		w := fileRequest(t, m, "GET", p)
+		if w.Code != 404 || strings.Contains(w.Body.String(), "package webkit") {
+			t.Errorf("%s: got %d, served a file outside the root", p, w.Code)
+		}
+	}
+}
+
+func TestMux_FileServerEmbed(t *testing.T) {
+	static, err := fs.Sub(embedded, "testdata/static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMux()
+	m.FileServer("/assets", static, FileServerOptions{})
+
+	w := fileRequest(t, m, "GET", "/assets/")
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "<title>app</title>") {
+		t.Fatalf("index: got %d %q", w.Code, w.Body.String())
+	}
+
+	// embedded files have no modification time, so their etag is a hash
+	// of their content
+	w = fileRequest(t, m, "GET", "/assets/css/app.css")
+	etag := w.Header().Get("ETag")
+	if w.Code != 200 || etag == "" || w.Header().Get("Last-Modified") != "" {
+		t.Fatalf("app.css: got %d, etag %q, last modified %q", w.Code, etag, w.Header().Get("Last-Modified"))
+	}
+	w = fileRequest(t, m, "GET", "/assets/css/app.css", "If-None-Match", etag)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("if-none-match: got status %d, want 304", w.Code)
+	}
+
+	w = fileRequest(t, m, "GET", "/assets/css/app.css", "Accept-Encoding", "gzip")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Type") != "text/css; charset=utf-8" {
+		t.Errorf("gzip: got encoding %q, content type %q", w.Header().Get("Content-Encoding"), w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Errorf("gzip: expected an etag of its own")
+	}
+}
+
+func TestNewFileHandler(t *testing.T) {
+	h := NewFileHandler(publicFS(t), FileServerOptions{})
+
+	m := NewMux()
+	m.Mount("/mounted", h)
+	m.Handle("/stripped/*", http.StripPrefix("/stripped", h))
+
+	for _, p := range []string{"/mounted/hello.txt", "/stripped/hello.txt"} {
+		w := fileRequest(t, m, "GET", p)
+		if w.Code != 200 || w.Body.String() != "hello, world" {
+			t.Errorf("%s: got %d %q", p, w.Code, w.Body.String())
+		}
+	}
+
+	w := fileRequest(t, m, "GET", "/mounted/../secret.txt")
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("mounted: served the secret file")
+	}
+
+	w = fileRequest(t, m, "DELETE", "/mounted/hello.txt")
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("delete: got %d, allow %q", w.Code, w.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,289 @@
+package webkit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matcher is a condition on a request, besides its method and path, that
+// a route registered with When must meet to be served. Match may add URL
+// params to the routing context, but only when it returns true.
+type Matcher interface {
+	Match(r *http.Request, rctx *Context) bool
+
+	// String describes the condition, and is used to tell the conditions
+	// of two routes apart
+	String() string
+}
+
+// hostMatcher matches the host of the request
+type hostMatcher struct {
+	pattern string
+	rex     *regexp.Regexp
+	keys    []string
+}
+
+// MatchHost returns a Matcher for the host of the request, ignoring the
+// port and the case. The pattern may capture the labels of the host as URL
+// params, as in "{tenant}.example.com", or match them with a regexp, as in
+// "{tenant:[a-z]+}.example.com", and a "*" label matches any one label.
+func MatchHost(pattern string) Matcher {
+	var sb strings.Builder
+	var keys []string
+	sb.WriteString("^")
+	for _, label := range strings.Split(strings.ToLower(pattern), ".") {
+		if sb.Len() > 1 {
+			sb.WriteString(`\.`)
+		}
+		switch {
+		case label == "*":
+			sb.WriteString(`[^.]+`)
+		case len(label) >= 2 && label[0] == '{' && label[len(label)-1] == '}':
+			key, rex, _ := strings.Cut(label[1:len(label)-1], ":")
+			if key == "" {
+				panic(fmt.Sprintf("webkit: host param name is missing in '%s'", pattern))
+			}
+			if rex == "" {
+				rex = `[^.]+`
+			}
+			sb.WriteString("(" + rex + ")")
+			keys = append(keys, key)
+		default:
+			sb.WriteString(regexp.QuoteMeta(label))
+		}
+	}
+	sb.WriteString("$")
+	rex, err := regexp.Compile(sb.String())
+	if err != nil {
+		panic(fmt.Sprintf("webkit: invalid host pattern '%s': %s", pattern, err))
+	}
+	if rex.NumSubexp() != len(keys) {
+		panic(fmt.Sprintf("webkit: host param regexps must not have groups in '%s'", pattern))
+	}
+	return &hostMatcher{pattern: pattern, rex: rex, keys: keys}
+}
+
+func (m *hostMatcher) Match(r *http.Request, rctx *Context) bool {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	values := m.rex.FindStringSubmatch(strings.ToLower(host))
+	if values == nil {
+		return false
+	}
+	if rctx != nil {
+		for i, key := range m.keys {
+			rctx.URLParams.Add(key, values[i+1])
+		}
+	}
+	return true
+}
+
+func (m *hostMatcher) String() string {
+	return "host=" + m.pattern
+}
+
+// valueMatcher matches a header or query value of the request
+type valueMatcher struct {
+	kind  string
+	key   string
+	value string
+	rex   *regexp.Regexp
+	get   func(r *http.Request, key string) (string, bool)
+}
+
+// MatchHeader returns a Matcher for a header of the request, as in
+// MatchHeader("Accept-Version", "v2"). An empty value matches any request
+// with the header.
+func MatchHeader(key, value string) Matcher {
+	return &valueMatcher{kind: "header", key: http.CanonicalHeaderKey(key), value: value, get: headerValue}
+}
+
+// MatchHeaderRegexp returns a Matcher for a header of the request with a
+// value matching the regexp.
+func MatchHeaderRegexp(key, pattern string) Matcher {
+	return &valueMatcher{kind: "header", key: http.CanonicalHeaderKey(key), value: pattern, rex: mustCompile(pattern), get: headerValue}
+}
+
+// MatchQuery returns a Matcher for a query param of the request, as in
+// MatchQuery("format", "json"). An empty value matches any request with
+// the query param.
+func MatchQuery(key, value string) Matcher {
+	return &valueMatcher{kind: "query", key: key, value: value, get: queryValue}
+}
+
+// MatchQueryRegexp returns a Matcher for a query param of the request with
+// a value matching the regexp.
+func MatchQueryRegexp(key, pattern string) Matcher {
+	return &valueMatcher{kind: "query", key: key, value: pattern, rex: mustCompile(pattern), get: queryValue}
+}
+
+func (m *valueMatcher) Match(r *http.Request, rctx *Context) bool {
+	value, ok := m.get(r, m.key)
+	switch {
+	case !ok:
+		return false
+	case m.rex != nil:
+		return m.rex.MatchString(value)
+	default:
+		return m.value == "" || m.value == value
+	}
+}
+
+func (m *valueMatcher) String() string {
+	if m.rex != nil {
+		return m.kind + ":" + m.key + "~" + m.value
+	}
+	return m.kind + ":" + m.key + "=" + m.value
+}
+
+func headerValue(r *http.Request, key string) (string, bool) {
+	values, ok := r.Header[key]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+func queryValue(r *http.Request, key string) (string, bool) {
+	values, ok := r.URL.Query()[key]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+func mustCompile(pattern string) *regexp.Regexp {
+	rex, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("webkit: invalid matcher regexp '%s': %s", pattern, err))
+	}
+	return rex
+}
+
+// matchersString describes the matchers, in the same way whatever
+// their order
+func matchersString(matchers []Matcher) string {
+	s := make([]string, len(matchers))
+	for i, m := range matchers {
+		s[i] = m.String()
+	}
+	sort.Strings(s)
+	return strings.Join(s, " ")
+}
+
+// matchRoute is a route registered with When
+type matchRoute struct {
+	matchers []Matcher
+	conds    string
+	handler  http.Handler
+}
+
+// matchHandler is the handler of the endpoints with conditional routes.
+// It serves the first route whose matchers all match the request, or else
+// the route without conditions of the method, or else the routes of the
+// pattern for any method (see Handle). When none of them does, the tree
+// goes on searching for a route, which may be found on a less specific
+// pattern.
+type matchHandler struct {
+	mux    *Mux
+	routes []matchRoute
+
+	// reg holds the other routes of the pattern, registered for the
+	// method or for any method, before or after the conditional ones
+	reg    registration
+	method methodTyp
+}
+
+func (h *matchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler := h.pick(r, RouteContext(r.Context())); handler != nil {
+		handler.ServeHTTP(w, r)
+		return
+	}
+	h.mux.NotFoundHandler().ServeHTTP(w, r)
+}
+
+// pick returns the handler of the route serving the request, or nil if
+// there is none. With a nil request, only the routes without conditions
+// are picked from.
+func (h *matchHandler) pick(r *http.Request, rctx *Context) http.Handler {
+	for _, rt := range h.routes {
+		if r != nil && matchAll(rt.matchers, r, rctx) {
+			return rt.handler
+		}
+	}
+	if e := h.reg[h.method]; e != nil && e.handler != nil {
+		return e.handler
+	}
+	if h.method == mALL {
+		return nil
+	}
+	e := h.reg[mALL]
+	switch {
+	case e == nil:
+		return nil
+	case e.matched != nil:
+		return e.matched.pick(r, rctx)
+	default:
+		return e.handler
+	}
+}
+
+// each calls fn with the conditions and the handler of the routes pick
+// chooses from, in the order it tries them
+func (h *matchHandler) each(fn func(conds string, handler http.Handler)) {
+	for _, rt := range h.routes {
+		fn(rt.conds, rt.handler)
+	}
+	if e := h.reg[h.method]; e != nil && e.handler != nil {
+		fn("", e.handler)
+		return
+	}
+	if h.method == mALL {
+		return
+	}
+	e := h.reg[mALL]
+	switch {
+	case e == nil:
+	case e.matched != nil:
+		e.matched.each(fn)
+	case e.handler != nil:
+		fn("", e.handler)
+	}
+}
+
+// endpointHandler returns the handler of an endpoint for the request being
+// routed. For the conditional routes, it is the route picked for the
+// request, or nil if there is none and the search for a route must go on.
+// Without a request (see Mux.Match) the conditional routes never match.
+func endpointHandler(rctx *Context, h http.Handler) http.Handler {
+	mh, ok := h.(*matchHandler)
+	if !ok {
+		return h
+	}
+	return mh.pick(rctx.request, rctx)
+}
+
+// matchAll reports whether all the matchers match the request, undoing
+// the URL params added by the matchers otherwise
+func matchAll(matchers []Matcher, r *http.Request, rctx *Context) bool {
+	var n int
+	if rctx != nil {
+		n = len(rctx.URLParams.Keys)
+	}
+	for _, m := range matchers {
+		if !m.Match(r, rctx) {
+			if rctx != nil {
+				rctx.URLParams.Keys = rctx.URLParams.Keys[:n]
+				rctx.URLParams.Values = rctx.URLParams.Values[:n]
+			}
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,249 @@
+package webkit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	cases := []struct {
+		matcher Matcher
+		host    string
+		headers []string
+		query   string
+		match   bool
+		params  string
+	}{
+		{MatchHost("example.com"), "example.com", nil, "", true, ""},
+		{MatchHost("example.com"), "EXAMPLE.com:8080", nil, "", true, ""},
+		{MatchHost("example.com"), "api.example.com", nil, "", false, ""},
+		{MatchHost("{tenant}.example.com"), "acme.example.com", nil, "", true, "tenant=acme"},
+		{MatchHost("{tenant}.example.com"), "a.b.example.com", nil, "", false, ""},
+		{MatchHost("{tenant:[a-z]+}.{region}.example.com"), "acme.eu.example.com:443", nil, "", true, "tenant=acme region=eu"},
+		{MatchHost("{tenant:[a-z]+}.example.com"), "acme1.example.com", nil, "", false, ""},
+		{MatchHost("*.example.com"), "www.example.com", nil, "", true, ""},
+		{MatchHeader("accept-version", "v2"), "", []string{"Accept-Version", "v2"}, "", true, ""},
+		{MatchHeader("Accept-Version", "v2"), "", []string{"Accept-Version", "v1"}, "", false, ""},
+		{MatchHeader("X-Debug", ""), "", []string{"X-Debug", "1"}, "", true, ""},
+		{MatchHeader("X-Debug", ""), "", nil, "", false, ""},
+		{MatchHeaderRegexp("Accept-Version", `^v[23]$`), "", []string{"Accept-Version", "v3"}, "", true, ""},
+		{MatchQuery("format", "json"), "", nil, "format=json", true, ""},
+		{MatchQuery("format", "json"), "", nil, "format=xml", false, ""},
+		{MatchQuery("debug", ""), "", nil, "debug", true, ""},
+		{MatchQueryRegexp("page", `^[0-9]+$`), "", nil, "page=x", false, ""},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/?"+c.query, nil)
+		r.Host = c.host
+		for i := 0; i < len(c.headers); i += 2 {
+			r.Header.Set(c.headers[i], c.headers[i+1])
+		}
+		rctx := NewRouteContext()
+		if got := c.matcher.Match(r, rctx); got != c.match {
+			t.Fatalf("%s %v: expected %v, got %v", c.matcher, c, c.match, got)
+		}
+		var params string
+		for i, k := range rctx.URLParams.Keys {
+			if i > 0 {
+				params += " "
+			}
+			params += k + "=" + rctx.URLParams.Values[i]
+		}
+		if params != c.params {
+			t.Fatalf("%s: expected params %q, got %q", c.matcher, c.params, params)
+		}
+	}
+
+	for _, pattern := range []string{"{}.example.com", "{a:[}.example.com", "{a:(x)}.example.com"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s: expected a panic", pattern)
+				}
+			}()
+			MatchHost(pattern)
+		}()
+	}
+}
+
+func TestMux_When(t *testing.T) {
+	m := NewMux()
+	tenants := m.When(MatchHost("{tenant}.example.com"))
+	tenants.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "tenant "+URLParam(r, "tenant"))
+	})
+	m.When(MatchHost("example.com")).Get("/", writeString("home"))
+
+	m.Route("/api", func(r Router) {
+		r.When(MatchHeader("Accept-Version", "v2")).Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "v2 user "+URLParam(r, "id"))
+		})
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "v1 user "+URLParam(r, "id"))
+		})
+		r.When(MatchQuery("format", "csv")).Get("/users/{id}", writeString("csv"))
+		r.When(MatchHeader("Accept-Version", "v2"), MatchQuery("format", "csv")).Get("/users", writeString("v2 csv"))
+	})
+	// the conditions of nested inline muxes add up
+	v3 := m.When(MatchHeader("Accept-Version", "v3"))
+	v3.Group(func(r Router) {
+		r.When(MatchQuery("beta", "")).Get("/beta", writeString("v3 beta"))
+	})
+
+	cases := []struct {
+		host, path, version string
+		code                int
+		body                string
+	}{
+		{"acme.example.com", "/", "", 200, "tenant acme"},
+		{"example.com", "/", "", 200, "home"},
+		{"other.com", "/", "", 404, "404 page not found\n"},
+		{"example.com", "/api/users/1", "", 200, "v1 user 1"},
+		{"example.com", "/api/users/1", "v2", 200, "v2 user 1"},
+		{"example.com", "/api/users/1?format=csv", "v2", 200, "v2 user 1"},
+		{"example.com", "/api/users/1?format=csv", "", 200, "csv"},
+		{"example.com", "/api/users?format=csv", "v2", 200, "v2 csv"},
+		{"example.com", "/api/users?format=csv", "", 404, "404 page not found\n"},
+		{"example.com", "/beta?beta", "v3", 200, "v3 beta"},
+		{"example.com", "/beta?beta", "", 404, "404 page not found\n"},
+		{"example.com", "/beta", "v3", 404, "404 page not found\n"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", c.path, nil)
+		r.Host = c.host
+		if c.version != "" {
+			r.Header.Set("Accept-Version", c.version)
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+		if w.Code != c.code || w.Body.String() != c.body {
+			t.Fatalf("%s%s %s: expected %d %q, got %d %q", c.host, c.path, c.version, c.code, c.body, w.Code, w.Body.String())
+		}
+	}
+
+	// the same conditions can not be registered twice, in any order
+	if err := registerPanic(func() {
+		m.When(MatchQuery("format", "csv"), MatchHeader("Accept-Version", "v2")).Get("/users", writeString(""))
+	}); err != nil {
+		t.Fatalf("expected no conflict on another router, got %v", err)
+	}
+	err := registerPanic(func() {
+		m.When(MatchQuery("format", "csv"), MatchHeader("Accept-Version", "v2")).Get("/users", writeString(""))
+	})
+	if !errors.Is(err, ErrRouteConflict) {
+		t.Fatalf("expected ErrRouteConflict, got %v", err)
+	}
+	if err := registerPanic(func() { tenants.Get("/", writeString("")) }); !errors.Is(err, ErrRouteConflict) {
+		t.Fatalf("expected ErrRouteConflict, got %v", err)
+	}
+	if err := registerPanic(func() { m.Get("/", writeString("fallback")) }); err != nil {
+		t.Fatalf("expected no conflict, got %v", err)
+	}
+	if err := registerPanic(func() { m.Get("/", writeString("")) }); !errors.Is(err, ErrRouteConflict) {
+		t.Fatalf("expected ErrRouteConflict, got %v", err)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Host = "other.com"
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+	if w.Body.String() != "fallback" {
+		t.Fatalf("expected the route without conditions, got %q", w.Body.String())
+	}
+}
+
+func TestMux_WhenFallback(t *testing.T) {
+	m := NewMux()
+	m.Handle("/x", writeString("all"))
+	m.When(MatchHost("a.example.com")).Get("/x", writeString("a"))
+	m.When(MatchHost("a.example.com")).Get("/y", writeString("a"))
+	m.Handle("/y", writeString("all"))
+	m.Get("/u/*", writeString("wild"))
+	m.When(MatchHeader("Accept-Version", "2")).Get("/u/{id}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "v2 "+URLParam(r, "id"))
+	})
+	m.When(MatchHeader("Accept-Version", "2")).Get("/only", writeString("only"))
+
+	cases := []struct {
+		method, host, path, version string
+		code                        int
+		body                        string
+	}{
+		// the route for any method serves the requests the conditions do not match
+		{"GET", "a.example.com", "/x", "", 200, "a"},
+		{"GET", "b.example.com", "/x", "", 200, "all"},
+		{"POST", "a.example.com", "/x", "", 200, "all"},
+		// in whatever order they are registered
+		{"GET", "a.example.com", "/y", "", 200, "a"},
+		{"GET", "b.example.com", "/y", "", 200, "all"},
+		// the search goes on to the less specific patterns
+		{"GET", "a.example.com", "/u/1", "2", 200, "v2 1"},
+		{"GET", "a.example.com", "/u/1", "", 200, "wild"},
+		{"GET", "a.example.com", "/only", "2", 200, "only"},
+		{"GET", "a.example.com", "/only", "", 404, "404 page not found\n"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.path, nil)
+		r.Host = c.host
+		if c.version != "" {
+			r.Header.Set("Accept-Version", c.version)
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+		if w.Code != c.code || w.Body.String() != c.body {
+			t.Fatalf("%s %s%s %s: expected %d %q, got %d %q", c.method, c.host, c.path, c.version, c.code, c.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestMux_MatchWhen(t *testing.T) {
+	m := NewMux()
+	m.When(MatchHeader("Accept-Version", "v2")).Get("/users/{id}", writeString("v2"))
+	m.Route("/api", func(r Router) {
+		r.When(MatchHost("{tenant}.example.com")).Get("/home", writeString("tenant"))
+		r.When(MatchQuery("format", "csv")).Get("/users", writeString("csv"))
+		r.Get("/users", writeString("users"))
+	})
+
+	// without a request, Match only matches the routes without conditions
+	cases := []struct {
+		path  string
+		match bool
+	}{
+		{"/users/1", false},
+		{"/api/home", false},
+		{"/api/users", true},
+	}
+	for _, c := range cases {
+		if got := m.Match(NewRouteContext(), "GET", c.path); got != c.match {
+			t.Fatalf("Match %s: expected %v, got %v", c.path, c.match, got)
+		}
+	}
+
+	requests := []struct {
+		host, path, version string
+		match               bool
+	}{
+		{"example.com", "/users/1", "v2", true},
+		{"example.com", "/users/1", "", false},
+		{"acme.example.com", "/api/home", "", true},
+		{"example.com", "/api/home", "", false},
+		{"example.com", "/api/users?format=csv", "", true},
+	}
+	for _, c := range requests {
+		r := httptest.NewRequest("GET", c.path, nil)
+		r.Host = c.host
+		if c.version != "" {
+			r.Header.Set("Accept-Version", c.version)
+		}
+		rctx := NewRouteContext()
+		if got := m.MatchRequest(rctx, r); got != c.match {
+			t.Fatalf("MatchRequest %s%s %s: expected %v, got %v", c.host, c.path, c.version, c.match, got)
+		}
+		if c.match && c.host == "acme.example.com" && rctx.URLParam("tenant") != "acme" {
+			t.Fatalf("MatchRequest %s%s: expected the tenant param, got %v", c.host, c.path, rctx.URLParams)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// BodyLimit returns a middleware that limits the size of the request body
+// to n bytes. Requests with a larger Content-Length get a 413, Request
+// Entity Too Large, and reading more than n bytes of the body returns an
+// error (a *http.MaxBytesError).
+func BodyLimit(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	h := BodyLimit(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write(b)
+	}))
+
+	if w := testRequest(t, h, httptest.NewRequest("POST", "/", strings.NewReader("small"))); w.Code != 200 || w.Body.String() != "small" {
+		t.Fatalf("expected a 200, got %d %q", w.Code, w.Body.String())
+	}
+	if w := testRequest(t, h, httptest.NewRequest("POST", "/", strings.NewReader("far too large"))); w.Code != 413 || w.Body.String() != "Request Entity Too Large\n" {
+		t.Fatalf("expected a 413 from the Content-Length, got %d %q", w.Code, w.Body.String())
+	}
+
+	// without a Content-Length the limit applies when reading
+	r := httptest.NewRequest("POST", "/", io.NopCloser(strings.NewReader("far too large")))
+	r.ContentLength = -1
+	if w := testRequest(t, h, r); w.Code != 413 || w.Body.Len() != 0 {
+		t.Fatalf("expected a 413 from the handler, got %d %q", w.Code, w.Body.String())
+	}
+	if w := testRequest(t, h, httptest.NewRequest("GET", "/", nil)); w.Code != 200 {
+		t.Fatalf("expected a 200 without a body, got %d", w.Code)
+	}
+}
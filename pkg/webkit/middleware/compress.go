@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultCompressibleTypes are the content types compressed by Gzip when
+// no types are given
+var defaultCompressibleTypes = []string{
+	"text/*",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"application/rss+xml",
+	"application/atom+xml",
+	"image/svg+xml",
+}
+
+// Gzip returns a middleware that compresses the response bodies of the
+// content types with gzip, at the compression level, when the client
+// accepts it. A type may end with "/*" to match every subtype, as in
+// "text/*". Without types, text, javascript, json, xml and svg content is
+// compressed. Responses that have a Content-Encoding already, or that have
+// no body, are left alone.
+func Gzip(level int, types ...string) func(http.Handler) http.Handler {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		panic(fmt.Sprintf("middleware: invalid gzip compression level %d", level))
+	}
+	if len(types) == 0 {
+		types = defaultCompressibleTypes
+	}
+	allowed := make(map[string]bool, len(types))
+	for _, t := range types {
+		allowed[strings.ToLower(t)] = true
+	}
+	pool := &sync.Pool{
+		New: func() interface{} {
+			gz, _ := gzip.NewWriterLevel(nil, level)
+			return gz
+		},
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			gw := &gzipWriter{ResponseWriter: w, allowed: allowed, pool: pool}
+			defer gw.Close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header accepts gzip
+func acceptsGzip(accept string) bool {
+	for _, enc := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(enc, ";")
+		name = strings.TrimSpace(name)
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		params = strings.TrimSpace(params)
+		if !strings.HasPrefix(params, "q=") {
+			return true
+		}
+		q := params[len("q="):]
+		if f, err := strconv.ParseFloat(q, 64); err == nil && f > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipWriter compresses the body, once the first write (or flush) shows
+// the content type of the response can be compressed
+type gzipWriter struct {
+	http.ResponseWriter
+	allowed     map[string]bool
+	pool        *sync.Pool
+	gz          *gzip.Writer
+	status      int
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		w.writeHeader(b, true)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// writeHeader decides if the body is compressed, and writes the header.
+// The first bytes of the body, if any, are used to sniff a missing content
+// type, and body reports whether a body may follow at all.
+func (w *gzipWriter) writeHeader(b []byte, body bool) {
+	w.wroteHeader = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	h := w.Header()
+	if h.Get("Content-Type") == "" && b != nil {
+		h.Set("Content-Type", http.DetectContentType(b))
+	}
+	if body && h.Get("Content-Encoding") == "" && w.compressible(h.Get("Content-Type")) {
+		h.Add("Vary", "Accept-Encoding")
+		if bodyAllowed(w.status) {
+			h.Set("Content-Encoding", "gzip")
+			h.Del("Content-Length")
+			w.gz = w.pool.Get().(*gzip.Writer)
+			w.gz.Reset(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *gzipWriter) compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if w.allowed[mediaType] {
+		return true
+	}
+	if i := strings.IndexByte(mediaType, '/'); i >= 0 {
+		return w.allowed[mediaType[:i]+"/*"]
+	}
+	return false
+}
+
+// Flush writes the header if nothing has been written yet, as a streamed
+// response may flush before its first write. The content type cannot be
+// sniffed then, so the body is only compressed if it was set.
+func (w *gzipWriter) Flush() {
+	if w.hijacked {
+		return
+	}
+	if !w.wroteHeader {
+		w.writeHeader(nil, true)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the gzip stream, or writes the header of a response
+// without a body
+func (w *gzipWriter) Close() error {
+	if w.hijacked {
+		return nil
+	}
+	if !w.wroteHeader {
+		if w.status != 0 {
+			w.writeHeader(nil, false)
+		}
+		return nil
+	}
+	if w.gz == nil {
+		return nil
+	}
+	err := w.gz.Close()
+	w.gz.Reset(nil)
+	w.pool.Put(w.gz)
+	w.gz = nil
+	return err
+}
+
+// Hijack hands the connection over to the handler, such as for a
+// websocket upgrade, which leaves the response alone
+func (w *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("middleware: the http.ResponseWriter does not support Hijack")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// bodyAllowed reports whether a response with the status can have a body
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	cases := map[string]bool{
+		"":                      false,
+		"gzip":                  true,
+		"deflate, gzip;q=0.5":   true,
+		"gzip;q=0":              false,
+		"br, *":                 true,
+		"identity, gzip ; q=1":  true,
+		"deflate, gzip;q=0.000": false,
+	}
+	for accept, want := range cases {
+		if got := acceptsGzip(accept); got != want {
+			t.Fatalf("%q: expected %v, got %v", accept, want, got)
+		}
+	}
+}
+
+func TestGzip(t *testing.T) {
+	body := strings.Repeat("hello, world! ", 100)
+	h := Gzip(gzip.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("Content-Length", "12345")
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, body)
+		case "/png":
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, body)
+		case "/encoded":
+			w.Header().Set("Content-Encoding", "br")
+			io.WriteString(w, body)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			io.WriteString(w, body[:len(body)/2])
+			w.(http.Flusher).Flush()
+			io.WriteString(w, body[len(body)/2:])
+		}
+	}))
+	request := func(path string, gz bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		if gz {
+			r.Header.Set("Accept-Encoding", "gzip, deflate")
+		}
+		return testRequest(t, h, r)
+	}
+	gunzip := func(w *httptest.ResponseRecorder) string {
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	// sniffed text, with a flush half way
+	w := request("/", true)
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") || w.Body.Len() >= len(body) {
+		t.Fatalf("expected a gzip response, got %v with %d bytes", w.Header(), w.Body.Len())
+	}
+	if got := gunzip(w); got != body {
+		t.Fatalf("bad body %q", got)
+	}
+
+	w = request("/json", true)
+	if w.Code != http.StatusCreated || w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" {
+		t.Fatalf("expected a gzip 201, got %d %v", w.Code, w.Header())
+	}
+	if got := gunzip(w); got != body {
+		t.Fatalf("bad body %q", got)
+	}
+
+	for _, path := range []string{"/png", "/encoded"} {
+		if w = request(path, true); w.Header().Get("Content-Encoding") == "gzip" || w.Body.String() != body {
+			t.Fatalf("%s: expected an uncompressed response, got %v", path, w.Header())
+		}
+	}
+	if w = request("/", false); w.Header().Get("Content-Encoding") != "" || w.Body.String() != body {
+		t.Fatalf("expected an uncompressed response, got %v", w.Header())
+	}
+	if w = request("/empty", true); w.Code != http.StatusNoContent || w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 0 {
+		t.Fatalf("expected an empty 204, got %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+}
+
+func TestGzip_Types(t *testing.T) {
+	h := Gzip(gzip.BestSpeed, "image/*")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		io.WriteString(w, "data")
+	}))
+	for typ, want := range map[string]string{"image/bmp": "gzip", "text/html": ""} {
+		r := httptest.NewRequest("GET", "/?type="+typ, nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		if got := testRequest(t, h, r).Header().Get("Content-Encoding"); got != want {
+			t.Fatalf("%s: expected %q, got %q", typ, want, got)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for an invalid level")
+		}
+	}()
+	Gzip(42)
+}
+
+func TestGzip_FlushFirst(t *testing.T) {
+	h := Gzip(gzip.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "data: hello\n\n")
+	}))
+	for typ, want := range map[string]string{"text/event-stream": "gzip", "": ""} {
+		r := httptest.NewRequest("GET", "/?type="+typ, nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := testRequest(t, h, r)
+		if !w.Flushed || w.Header().Get("Content-Encoding") != want {
+			t.Fatalf("%q: expected a flushed response encoded as %q, got %v %v", typ, want, w.Flushed, w.Header())
+		}
+	}
+}
+
+// hijackRecorder is a ResponseRecorder supporting Hijack
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	c, _ := net.Pipe()
+	return c, bufio.NewReadWriter(bufio.NewReader(c), bufio.NewWriter(c)), nil
+}
+
+func TestGzip_Hijack(t *testing.T) {
+	h := Gzip(gzip.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusSwitchingProtocols)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatalf("hijack: %v", err)
+		}
+		conn.Close()
+	}))
+	r := httptest.NewRequest("GET", "/ws", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(w, r)
+	// the recorder keeps its default code unless a header was written
+	if !w.hijacked || w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected the connection to be hijacked untouched, got %v %d %v", w.hijacked, w.Code, w.Header())
+	}
+
+	// a writer that cannot be hijacked fails to
+	h = Gzip(gzip.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			t.Fatalf("expected an error hijacking a recorder")
+		}
+	}))
+	testRequest(t, h, r)
+}
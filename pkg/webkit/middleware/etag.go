@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultETagLimit is the size of the largest body ETag buffers
+const DefaultETagLimit = 1 << 20
+
+// ETag is a middleware for conditional GET requests, buffering bodies of
+// up to DefaultETagLimit bytes. See ETagLimit.
+func ETag(next http.Handler) http.Handler {
+	return ETagLimit(DefaultETagLimit)(next)
+}
+
+// ETagLimit returns a middleware for conditional GET requests. It buffers
+// the body of successful GET responses and sets a strong ETag computed
+// from it. Requests with a matching If-None-Match header, or without one
+// and with an If-Modified-Since header not older than the Last-Modified
+// header of the response, get a 304, Not Modified, without the body.
+//
+// Only the bodies that can be hashed are buffered. A response is passed
+// through as it is written if the handler sets an ETag itself (which is
+// matched against the request before the body is written), sets a
+// Content-Length over limit, writes more than limit bytes or flushes it.
+// Other methods pass through, since the body of a HEAD response is not
+// known.
+func ETagLimit(limit int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ew := &etagWriter{ResponseWriter: w, r: r, limit: limit}
+			next.ServeHTTP(ew, r)
+			ew.finish()
+		})
+	}
+}
+
+// notModified reports whether the conditional headers of the request
+// match the response
+func notModified(r *http.Request, h http.Header, etag string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || weakMatch(tag, etag) {
+				return true
+			}
+		}
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lm, err := http.ParseTime(h.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	return !lm.Truncate(time.Second).After(ims)
+}
+
+// weakMatch compares the entity tags ignoring the weak indicator, as
+// RFC 9110 requires for If-None-Match
+func weakMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// etagWriter buffers the body of the response until it either has all
+// of it or has to pass the response through
+type etagWriter struct {
+	http.ResponseWriter
+	r       *http.Request
+	limit   int
+	buf     bytes.Buffer
+	status  int
+	started bool // the response has been looked at, see start
+	through bool // the body is written straight to the ResponseWriter
+	discard bool // a 304 has been sent, so the body is dropped
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.start()
+	}
+	switch {
+	case w.discard:
+		return len(b), nil
+	case !w.through && w.buf.Len()+len(b) > w.limit:
+		w.passThrough()
+	}
+	if w.through {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// start decides, once the header is complete, whether the response can
+// be buffered, or has to be passed through, or is not modified
+func (w *etagWriter) start() {
+	w.started = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	h := w.Header()
+	if w.status != http.StatusOK {
+		w.passThrough()
+		return
+	}
+	if etag := h.Get("ETag"); etag != "" {
+		if notModified(w.r, h, etag) {
+			w.notModified()
+			return
+		}
+		w.passThrough()
+		return
+	}
+	if n, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err == nil && n > int64(w.limit) {
+		w.passThrough()
+	}
+}
+
+// passThrough writes the header and whatever has been buffered so far,
+// after which the body is written straight to the ResponseWriter
+func (w *etagWriter) passThrough() {
+	w.through = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+// notModified sends a 304 in place of the response
+func (w *etagWriter) notModified() {
+	w.discard = true
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(http.StatusNotModified)
+}
+
+// finish sets the ETag of a buffered response and writes it, unless the
+// request matches it
+func (w *etagWriter) finish() {
+	if !w.started {
+		w.start()
+	}
+	if w.through || w.discard {
+		return
+	}
+	h := w.Header()
+	sum := sha256.Sum256(w.buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	h.Set("ETag", etag)
+	if notModified(w.r, h, etag) {
+		w.notModified()
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.buf.Bytes())
+}
+
+// Flush passes the response through, since a handler only flushes a
+// response it is streaming
+func (w *etagWriter) Flush() {
+	if !w.started {
+		w.start()
+	}
+	if w.discard {
+		return
+	}
+	if !w.through {
+		w.passThrough()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	modified := time.Date(2022, 10, 17, 12, 0, 0, 0, time.UTC)
+	h := ETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tagged":
+			w.Header().Set("ETag", `W/"v1"`)
+		case "/modified":
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "hello, world")
+	}))
+	request := func(method, path string, headers ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		for i := 0; i < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		return testRequest(t, h, r)
+	}
+
+	w := request("GET", "/")
+	etag := w.Header().Get("ETag")
+	if w.Code != 200 || w.Body.String() != "hello, world" || len(etag) != 34 {
+		t.Fatalf("expected a 200 with an etag, got %d %q %q", w.Code, w.Body.String(), etag)
+	}
+	if again := request("GET", "/").Header().Get("ETag"); again != etag {
+		t.Fatalf("expected a stable etag, got %q and %q", etag, again)
+	}
+
+	cases := []struct {
+		path    string
+		headers []string
+		code    int
+	}{
+		{"/", []string{"If-None-Match", etag}, 304},
+		{"/", []string{"If-None-Match", `"other", W/` + etag}, 304},
+		{"/", []string{"If-None-Match", "*"}, 304},
+		{"/", []string{"If-None-Match", `"other"`}, 200},
+		{"/tagged", []string{"If-None-Match", `"v1"`}, 304},
+		{"/tagged", []string{"If-None-Match", `"v2"`}, 200},
+		{"/modified", []string{"If-Modified-Since", modified.Format(http.TimeFormat)}, 304},
+		{"/modified", []string{"If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat)}, 200},
+		{"/modified", []string{"If-None-Match", `"other"`, "If-Modified-Since", modified.Format(http.TimeFormat)}, 200},
+		{"/", []string{"If-Modified-Since", modified.Format(http.TimeFormat)}, 200},
+		{"/missing", []string{"If-None-Match", "*"}, 404},
+	}
+	for _, c := range cases {
+		w = request("GET", c.path, c.headers...)
+		if w.Code != c.code {
+			t.Fatalf("%s %v: expected %d, got %d", c.path, c.headers, c.code, w.Code)
+		}
+		if c.code == 304 && (w.Body.Len() != 0 || w.Header().Get("ETag") == "" || w.Header().Get("Content-Type") != "") {
+			t.Fatalf("%s %v: bad 304 %v %q", c.path, c.headers, w.Header(), w.Body.String())
+		}
+		if c.code != 304 && w.Body.Len() == 0 {
+			t.Fatalf("%s %v: expected a body", c.path, c.headers)
+		}
+	}
+
+	if w = request("POST", "/", "If-None-Match", "*"); w.Code != 200 || w.Header().Get("ETag") != "" {
+		t.Fatalf("expected a POST to pass through, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestETag_Flush(t *testing.T) {
+	w := httptest.NewRecorder()
+	h := ETag(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, "event: one\n\n")
+		rw.(http.Flusher).Flush()
+		if !w.Flushed || w.Body.String() != "event: one\n\n" {
+			t.Fatalf("expected the first event to be flushed, got %v %q", w.Flushed, w.Body.String())
+		}
+		io.WriteString(rw, "event: two\n\n")
+	}))
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 200 || w.Body.String() != "event: one\n\nevent: two\n\n" || w.Header().Get("ETag") != "" {
+		t.Fatalf("expected a streamed response without an etag, got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestETagLimit(t *testing.T) {
+	h := ETagLimit(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/length" {
+			w.Header().Set("Content-Length", "12")
+			io.WriteString(w, "hello, world")
+			return
+		}
+		io.WriteString(w, "hello, ")
+		io.WriteString(w, "world")
+	}))
+	for _, path := range []string{"/", "/length"} {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("If-None-Match", "*")
+		w := testRequest(t, h, r)
+		if w.Code != 200 || w.Body.String() != "hello, world" || w.Header().Get("ETag") != "" {
+			t.Fatalf("%s: expected the response to pass through, got %d %q %v", path, w.Code, w.Body.String(), w.Header())
+		}
+	}
+	w := testRequest(t, ETagLimit(16)(writeString("hello, world")), httptest.NewRequest("GET", "/", nil))
+	if w.Header().Get("ETag") == "" {
+		t.Fatalf("expected a body under the limit to get an etag, got %v", w.Header())
+	}
+}
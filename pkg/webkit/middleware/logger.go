@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogEntry is the record of a request written by the Logger.
+type LogEntry struct {
+	Time       time.Time
+	RequestID  string
+	RemoteAddr string
+	Method     string
+	Path       string
+	Proto      string
+	Status     int
+	Bytes      int64
+	Duration   time.Duration
+	UserAgent  string
+}
+
+// String formats the entry as a line of key=value pairs (logfmt), with
+// the values quoted when needed.
+func (e *LogEntry) String() string {
+	var sb strings.Builder
+	field := func(key, value string) {
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		if value == "" || strings.ContainsAny(value, " =\"") || strings.IndexFunc(value, isControl) >= 0 {
+			value = strconv.Quote(value)
+		}
+		sb.WriteString(value)
+	}
+	field("time", e.Time.UTC().Format(time.RFC3339Nano))
+	if e.RequestID != "" {
+		field("request_id", e.RequestID)
+	}
+	field("remote", e.RemoteAddr)
+	field("method", e.Method)
+	field("path", e.Path)
+	field("proto", e.Proto)
+	field("status", strconv.Itoa(e.Status))
+	field("bytes", strconv.FormatInt(e.Bytes, 10))
+	field("duration", e.Duration.String())
+	field("user_agent", e.UserAgent)
+	return sb.String()
+}
+
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
+
+// Logger returns a middleware that writes a LogEntry for every request to
+// out, as a logfmt line. Use it after RequestID to log the request IDs,
+// and before Recoverer to log the requests that panic.
+func Logger(out io.Writer) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	return LoggerFunc(func(e *LogEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintln(out, e.String())
+	})
+}
+
+// LoggerFunc returns a middleware that calls fn with a LogEntry for every
+// request, once it is served.
+func LoggerFunc(fn func(e *LogEntry)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := NewWrapResponseWriter(w)
+			start := time.Now()
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				fn(&LogEntry{
+					Time:       start,
+					RequestID:  GetReqID(r.Context()),
+					RemoteAddr: r.RemoteAddr,
+					Method:     r.Method,
+					Path:       r.URL.RequestURI(),
+					Proto:      r.Proto,
+					Status:     status,
+					Bytes:      ww.BytesWritten(),
+					Duration:   time.Since(start),
+					UserAgent:  r.UserAgent(),
+				})
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := RequestID(Logger(&buf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "hello")
+	})))
+
+	r := httptest.NewRequest("GET", "/hello?name=bob", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	r.Header.Set("User-Agent", "test agent")
+	testRequest(t, h, r)
+	testRequest(t, h, httptest.NewRequest("POST", "/missing", nil))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	for _, want := range []string{
+		"request_id=req-1", "remote=192.0.2.1:1234", "method=GET", `path="/hello?name=bob"`,
+		"proto=HTTP/1.1", "status=200", "bytes=5", `user_agent="test agent"`,
+	} {
+		if !strings.Contains(lines[0], want) {
+			t.Fatalf("expected %q in %q", want, lines[0])
+		}
+	}
+	if !strings.Contains(lines[1], "method=POST") || !strings.Contains(lines[1], "status=404") ||
+		!strings.Contains(lines[1], `user_agent=""`) {
+		t.Fatalf("bad line %q", lines[1])
+	}
+}
+
+func TestLoggerFunc(t *testing.T) {
+	var entries []*LogEntry
+	h := LoggerFunc(func(e *LogEntry) {
+		entries = append(entries, e)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	testRequest(t, h, httptest.NewRequest("DELETE", "/users/1", nil))
+	if len(entries) != 1 || entries[0].Status != http.StatusNoContent || entries[0].Bytes != 0 ||
+		entries[0].Path != "/users/1" || entries[0].Time.IsZero() {
+		t.Fatalf("got %+v", entries)
+	}
+}
@@ -0,0 +1,116 @@
+// Package middleware provides a standard set of http middlewares for use
+// with webkit routers, or with any other http.Handler.
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// contextKey is a value for use with context.WithValue. It's used as
+// a pointer, so it fits in an interface{} without allocation.
+type contextKey struct {
+	name string
+}
+
+func (k *contextKey) String() string {
+	return "webkit/middleware context value " + k.name
+}
+
+// WrapResponseWriter is a http.ResponseWriter that records the status and
+// the number of bytes written, for use by middlewares. It passes Flush and
+// Hijack on to the original writer, when it supports them.
+type WrapResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+
+	// Status returns the status written, or 0 if it has not been
+	Status() int
+
+	// BytesWritten returns the number of bytes of the body written
+	BytesWritten() int64
+
+	// Unwrap returns the original http.ResponseWriter
+	Unwrap() http.ResponseWriter
+}
+
+// NewWrapResponseWriter wraps the http.ResponseWriter, unless it is a
+// WrapResponseWriter already.
+func NewWrapResponseWriter(w http.ResponseWriter) WrapResponseWriter {
+	if ww, ok := w.(WrapResponseWriter); ok {
+		return ww
+	}
+	return &wrapWriter{ResponseWriter: w}
+}
+
+type wrapWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *wrapWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *wrapWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// ReadFrom lets io.Copy use the io.ReaderFrom of the original writer
+func (w *wrapWriter) ReadFrom(r io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(w.ResponseWriter, r)
+	}
+	w.bytes += n
+	return n, err
+}
+
+func (w *wrapWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *wrapWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("middleware: the http.ResponseWriter does not support Hijack")
+}
+
+func (w *wrapWriter) Status() int {
+	return w.status
+}
+
+func (w *wrapWriter) BytesWritten() int64 {
+	return w.bytes
+}
+
+func (w *wrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testRequest serves the request on the handler and returns the
+// recorded response
+func testRequest(t *testing.T, h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func writeString(s string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, s)
+	}
+}
+
+func TestWrapResponseWriter(t *testing.T) {
+	var ww WrapResponseWriter
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww = NewWrapResponseWriter(w)
+		if NewWrapResponseWriter(ww) != ww {
+			t.Fatalf("expected a WrapResponseWriter to not be wrapped again")
+		}
+		ww.WriteHeader(http.StatusCreated)
+		ww.WriteHeader(http.StatusAccepted)
+		io.WriteString(ww, "hello")
+		ww.Flush()
+	})
+	w := testRequest(t, h, httptest.NewRequest("GET", "/", nil))
+	if ww.Status() != http.StatusCreated || ww.BytesWritten() != 5 || w.Body.String() != "hello" || !w.Flushed {
+		t.Fatalf("got status %d, %d bytes, body %q, flushed %v", ww.Status(), ww.BytesWritten(), w.Body.String(), w.Flushed)
+	}
+	if _, _, err := ww.Hijack(); err == nil {
+		t.Fatalf("expected an error hijacking a recorder")
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIP is a middleware that sets the RemoteAddr of the request to the
+// client IP sent by a proxy, in the True-Client-IP, X-Real-IP or
+// X-Forwarded-For header, in that order. Only the first (leftmost) address
+// of X-Forwarded-For is used, and values that are not IP addresses are
+// ignored.
+//
+// The headers are easily spoofed by clients, so it must only be used
+// behind a proxy that sets or strips them.
+func RealIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := realIP(r); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// realIP returns the client IP from the headers of the request,
+// or an empty string
+func realIP(r *http.Request) string {
+	ip := r.Header.Get("True-Client-IP")
+	if ip == "" {
+		ip = r.Header.Get("X-Real-IP")
+	}
+	if ip == "" {
+		ip = r.Header.Get("X-Forwarded-For")
+		if i := strings.IndexByte(ip, ','); i >= 0 {
+			ip = ip[:i]
+		}
+	}
+	ip = strings.TrimSpace(ip)
+	if ip == "" || net.ParseIP(ip) == nil {
+		return ""
+	}
+	return ip
+}
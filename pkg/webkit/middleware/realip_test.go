@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	h := RealIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.RemoteAddr)
+	}))
+	cases := []struct {
+		headers []string
+		want    string
+	}{
+		{nil, "192.0.2.1:1234"},
+		{[]string{"X-Forwarded-For", "203.0.113.7, 10.0.0.1"}, "203.0.113.7"},
+		{[]string{"X-Real-IP", "2001:db8::1", "X-Forwarded-For", "203.0.113.7"}, "2001:db8::1"},
+		{[]string{"True-Client-IP", "198.51.100.2", "X-Real-IP", "203.0.113.7"}, "198.51.100.2"},
+		{[]string{"X-Real-IP", "not-an-ip"}, "192.0.2.1:1234"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		for i := 0; i < len(c.headers); i += 2 {
+			r.Header.Set(c.headers[i], c.headers[i+1])
+		}
+		if got := testRequest(t, h, r).Body.String(); got != c.want {
+			t.Fatalf("%v: expected %q, got %q", c.headers, c.want, got)
+		}
+	}
+}
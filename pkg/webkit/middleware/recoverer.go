@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime/debug"
+)
+
+// Recoverer is a middleware that recovers from panics, writes the panic
+// and a stack trace to os.Stderr, and responds with a 500, Internal
+// Server Error, if nothing was written yet. See RecovererTo.
+func Recoverer(next http.Handler) http.Handler {
+	return RecovererTo(os.Stderr)(next)
+}
+
+// RecovererTo returns a middleware that recovers from panics, writes the
+// panic and a stack trace to out, and responds with a 500, Internal Server
+// Error, if nothing was written yet. A http.ErrAbortHandler panic is passed
+// on, since it is used to abort a response on purpose.
+func RecovererTo(out io.Writer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := NewWrapResponseWriter(w)
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				if rvr == http.ErrAbortHandler {
+					panic(rvr)
+				}
+				id := GetReqID(r.Context())
+				if id != "" {
+					id = " [" + id + "]"
+				}
+				fmt.Fprintf(out, "panic serving %s %s%s: %v\n%s", r.Method, r.URL.Path, id, rvr, debug.Stack())
+				if ww.Status() == 0 {
+					http.Error(ww, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
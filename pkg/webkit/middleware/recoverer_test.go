@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoverer(t *testing.T) {
+	var buf bytes.Buffer
+	h := RecovererTo(&buf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/written":
+			w.WriteHeader(http.StatusAccepted)
+			io.WriteString(w, "partial")
+		case "/abort":
+			panic(http.ErrAbortHandler)
+		}
+		panic("boom")
+	}))
+
+	w := testRequest(t, h, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 500 || w.Body.String() != "Internal Server Error\n" {
+		t.Fatalf("expected a 500, got %d %q", w.Code, w.Body.String())
+	}
+	if !strings.Contains(buf.String(), "panic serving GET /: boom") || !strings.Contains(buf.String(), "goroutine") {
+		t.Fatalf("expected the panic and stack to be logged, got %q", buf.String())
+	}
+
+	w = testRequest(t, h, httptest.NewRequest("GET", "/written", nil))
+	if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Fatalf("expected the response to be kept, got %d %q", w.Code, w.Body.String())
+	}
+
+	defer func() {
+		if rvr := recover(); rvr != http.ErrAbortHandler {
+			t.Fatalf("expected http.ErrAbortHandler to be passed on, got %v", rvr)
+		}
+	}()
+	testRequest(t, h, httptest.NewRequest("GET", "/abort", nil))
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// RequestIDHeader is the header used to read and write the request ID.
+var RequestIDHeader = "X-Request-Id"
+
+// RequestIDKey is the context key of the request ID.
+var RequestIDKey = &contextKey{"RequestID"}
+
+// maxRequestIDLen is the longest request ID accepted from a client
+const maxRequestIDLen = 128
+
+var (
+	// reqPrefix is a random prefix, unique to the process
+	reqPrefix string
+
+	// reqID is the counter of the request IDs of the process
+	reqID uint64
+)
+
+func init() {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("middleware: unable to read random bytes: " + err.Error())
+	}
+	reqPrefix = hex.EncodeToString(b[:]) + "-"
+}
+
+// RequestID is a middleware that gives each request an ID, and puts it
+// in the request context and the response headers. The ID of the request
+// header is reused when it is sent, so an ID can be traced across
+// services. Otherwise it is made of a random prefix unique to the process
+// and a counter, as in "5f3c9a1b2d4e6f70-000042".
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = reqPrefix + padCounter(atomic.AddUint64(&reqID, 1))
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), RequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetReqID returns the request ID from the context, or an empty string.
+func GetReqID(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// validRequestID reports whether the ID sent by a client can be used,
+// allowing only printable ascii so it is safe to log and echo back
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// padCounter formats the counter with at least six digits
+func padCounter(n uint64) string {
+	s := strconv.FormatUint(n, 10)
+	if len(s) < 6 {
+		s = "000000"[len(s):] + s
+	}
+	return s
+}
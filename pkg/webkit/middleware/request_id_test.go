@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, GetReqID(r.Context()))
+	}))
+
+	w1 := testRequest(t, h, httptest.NewRequest("GET", "/", nil))
+	w2 := testRequest(t, h, httptest.NewRequest("GET", "/", nil))
+	id1, id2 := w1.Body.String(), w2.Body.String()
+	if id1 == "" || id1 == id2 || !strings.HasPrefix(id1, reqPrefix) || w1.Header().Get(RequestIDHeader) != id1 {
+		t.Fatalf("got ids %q and %q, header %q", id1, id2, w1.Header().Get(RequestIDHeader))
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(RequestIDHeader, "upstream-42")
+	if w := testRequest(t, h, r); w.Body.String() != "upstream-42" {
+		t.Fatalf("expected the id of the request to be reused, got %q", w.Body.String())
+	}
+	for _, bad := range []string{"bad id", "bad\nid", strings.Repeat("x", maxRequestIDLen+1)} {
+		r = httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(RequestIDHeader, bad)
+		if w := testRequest(t, h, r); !strings.HasPrefix(w.Body.String(), reqPrefix) {
+			t.Fatalf("expected %q to be replaced, got %q", bad, w.Body.String())
+		}
+	}
+	if id := GetReqID(r.Context()); id != "" {
+		t.Fatalf("expected no id, got %q", id)
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Timeout returns a middleware that cancels the context of the request
+// after the timeout. The handlers must watch ctx.Done() and return once
+// it is closed, as Timeout does not stop them. If the deadline passed and
+// nothing was written yet, it responds with a 504, Gateway Timeout.
+func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			ww := NewWrapResponseWriter(w)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && ww.Status() == 0 {
+				ww.WriteHeader(http.StatusGatewayTimeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	h := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			<-r.Context().Done()
+		case "/fast":
+			io.WriteString(w, "fast")
+		}
+	}))
+
+	start := time.Now()
+	w := testRequest(t, h, httptest.NewRequest("GET", "/slow", nil))
+	if w.Code != http.StatusGatewayTimeout || time.Since(start) > time.Second {
+		t.Fatalf("expected a 504, got %d after %s", w.Code, time.Since(start))
+	}
+	if w = testRequest(t, h, httptest.NewRequest("GET", "/fast", nil)); w.Code != 200 || w.Body.String() != "fast" {
+		t.Fatalf("expected a 200, got %d %q", w.Code, w.Body.String())
+	}
+}
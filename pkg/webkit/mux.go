@@ -0,0 +1,673 @@
+package webkit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var _ Router = &Mux{}
+var _ Routes = &Mux{}
+
+// Mux is a simple HTTP route multiplexer that parses a request path,
+// records any URL params, and executes an end handler. It implements
+// the http.Handler interface and is friendly with the standard library.
+//
+// Mux is designed to be fast, minimal and offer a powerful API for building
+// modular and composable HTTP services with a large set of handlers. It's
+// particularly useful for writing large REST API services that break a handler
+// into many smaller parts composed of middlewares and end handlers.
+type Mux struct {
+	// The computed mux handler made of the chained middleware stack and
+	// the tree router
+	handler http.Handler
+
+	// The routing tree (see RouteMatcher)
+	tree RouteMatcher
+
+	// Routing context pool
+	pool *sync.Pool
+
+	// Custom route not found handler
+	notFoundHandler http.HandlerFunc
+
+	// Custom method not allowed handler
+	methodNotAllowedHandler http.HandlerFunc
+
+	// The middleware stack
+	middlewares []func(http.Handler) http.Handler
+
+	// The parent of an inline-mux (see Group and With)
+	parent *Mux
+
+	// Controls the behaviour of middleware chaining for inline-muxes
+	inline bool
+
+	// The route names and their patterns, shared with the inline-muxes
+	names map[string]string
+
+	// The name given to the routes of an inline-mux (see Named)
+	name string
+
+	// The routes registered, by the key of their pattern, shared with the
+	// inline-muxes (see CheckRoute)
+	registered map[string]registration
+
+	// The conditions of the routes of an inline-mux (see When)
+	matchers []Matcher
+}
+
+// NewMux returns a newly initialized Mux object that implements the Router
+// interface.
+func NewMux() *Mux {
+	return NewMuxMatcher(newDefaultMatcher())
+}
+
+// NewMuxMatcher returns a newly initialized Mux object, like NewMux, using
+// the RouteMatcher as its routing tree. The subrouters created with Route
+// use the same kind of RouteMatcher.
+func NewMuxMatcher(m RouteMatcher) *Mux {
+	mux := &Mux{tree: m, pool: &sync.Pool{}, names: map[string]string{}, registered: map[string]registration{}}
+	mux.pool.New = func() interface{} {
+		return NewRouteContext()
+	}
+	return mux
+}
+
+// NewRouter returns a new Mux object that implements the Router interface.
+func NewRouter() *Mux {
+	return NewMux()
+}
+
+// ServeHTTP is the single method of the http.Handler interface that makes
+// Mux interoperable with the standard library. It uses a sync.Pool to get and
+// reuse routing contexts for each request.
+func (mx *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Ensure the mux has some routes defined on the mux
+	if mx.handler == nil {
+		mx.NotFoundHandler().ServeHTTP(w, r)
+		return
+	}
+
+	// Check if a routing context already exists from a parent router.
+	rctx, _ := r.Context().Value(RouteCtxKey).(*Context)
+	if rctx != nil {
+		mx.handler.ServeHTTP(w, r)
+		return
+	}
+
+	// Fetch a RouteContext object from the sync pool, and call the computed
+	// mx.handler that is comprised of mx.middlewares + mx.routeHTTP.
+	// Once the request is finished, reset the routing context and put it back
+	// into the pool for reuse from another request.
+	rctx = mx.pool.Get().(*Context)
+	rctx.Reset()
+	rctx.Routes = mx
+	rctx.parentCtx = r.Context()
+
+	// NOTE: r.WithContext() causes 2 allocations and context.WithValue() causes 1 allocation
+	r = r.WithContext(context.WithValue(r.Context(), RouteCtxKey, rctx))
+
+	// Serve the request and once its done, put the request context back in the sync pool
+	mx.handler.ServeHTTP(w, r)
+	mx.pool.Put(rctx)
+}
+
+// Use appends a middleware handler to the Mux middleware stack.
+//
+// The middleware stack for any Mux will execute before searching for a matching
+// route to a specific handler, which provides opportunity to respond early,
+// change the course of the request execution, or set request-scoped values for
+// the next http.Handler.
+func (mx *Mux) Use(middlewares ...func(http.Handler) http.Handler) {
+	if mx.handler != nil {
+		panic("webkit: all middlewares must be defined before routes on a mux")
+	}
+	mx.middlewares = append(mx.middlewares, middlewares...)
+}
+
+// With adds inline middlewares for an endpoint handler, and returns a new
+// inline-Router sharing the routing tree of the Mux.
+func (mx *Mux) With(middlewares ...func(http.Handler) http.Handler) Router {
+	// Similarly as in handle(), we must build the mux handler once additional
+	// middleware registration isn't allowed for this stack, like now.
+	if !mx.inline && mx.handler == nil {
+		mx.updateRouteHandler()
+	}
+
+	// Copy the middlewares and matchers from the parent inline muxs
+	var mws Middlewares
+	var matchers []Matcher
+	if mx.inline {
+		mws = make(Middlewares, len(mx.middlewares))
+		copy(mws, mx.middlewares)
+		matchers = mx.matchers[:len(mx.matchers):len(mx.matchers)]
+	}
+	mws = append(mws, middlewares...)
+
+	return &Mux{
+		pool:                    mx.pool,
+		inline:                  true,
+		parent:                  mx,
+		tree:                    mx.tree,
+		names:                   mx.names,
+		registered:              mx.registered,
+		matchers:                matchers,
+		middlewares:             mws,
+		notFoundHandler:         mx.notFoundHandler,
+		methodNotAllowedHandler: mx.methodNotAllowedHandler,
+	}
+}
+
+// Named returns a new inline-Mux that names the routes registered on it,
+// so their URLs can be built with URLFor. Several routes may share a
+// name as long as they share the pattern, as for different methods.
+func (mx *Mux) Named(name string) Router {
+	if name == "" {
+		panic("webkit: route name must not be empty")
+	}
+	im := mx.With().(*Mux)
+	im.name = name
+	return im
+}
+
+// When returns a new inline-Mux whose routes are only served to requests
+// that all the matchers match, as in
+//
+//	mux.When(webkit.MatchHost("{tenant}.example.com")).Get("/", handler)
+//
+// Routes with the same method and pattern may be registered with different
+// conditions. They are tried in the order they were registered, then the
+// route without conditions is served, if there is one, or else the route
+// of the pattern for any method (see Handle). Otherwise, the search for a
+// route goes on as if the pattern had no route for the method, and may
+// find a less specific one, as "/users/*" for "/users/{id}".
+func (mx *Mux) When(matchers ...Matcher) Router {
+	im := mx.With().(*Mux)
+	im.matchers = append(im.matchers, matchers...)
+	return im
+}
+
+// Group creates a new inline-Mux with a copy of the middleware stack. It's
+// useful for a group of handlers along the same routing path that use an
+// additional set of middlewares.
+func (mx *Mux) Group(fn func(r Router)) Router {
+	im := mx.With()
+	if fn != nil {
+		fn(im)
+	}
+	return im
+}
+
+// Route creates a new Mux and mounts it along the `pattern` as a subrouter.
+// Effectively, this is a short-hand call to Mount.
+func (mx *Mux) Route(pattern string, fn func(r Router)) Router {
+	if fn == nil {
+		panic(fmt.Sprintf("webkit: attempting to Route() a nil subrouter on '%s'", pattern))
+	}
+	subRouter := NewMuxMatcher(mx.tree.fresh())
+	fn(subRouter)
+	mx.Mount(pattern, subRouter)
+	return subRouter
+}
+
+// Mount attaches another http.Handler or webkit Router as a subrouter along
+// a routing path. It's very useful to split up a large API as many independent
+// routers and compose them as a single service using Mount.
+//
+// Note that Mount() simply sets a wildcard along the `pattern` that will
+// continue routing at the `handler`, which in most cases is another webkit
+// Router. As a result, if you define two Mount() routes on the exact same
+// pattern the mount will panic.
+func (mx *Mux) Mount(pattern string, handler http.Handler) {
+	if handler == nil {
+		panic(fmt.Sprintf("webkit: attempting to Mount() a nil handler on '%s'", pattern))
+	}
+
+	// Provide runtime safety for ensuring a pattern isn't mounted on an existing
+	// routing pattern.
+	if mx.tree.has(pattern+"*") || mx.tree.has(pattern+"/*") {
+		panic(fmt.Sprintf("webkit: attempting to Mount() a handler on an existing path, '%s'", pattern))
+	}
+
+	// Assign sub-Router's with the parent not found & method not allowed handler
+	// if not specified.
+	subr, ok := handler.(*Mux)
+	if ok && subr.notFoundHandler == nil && mx.notFoundHandler != nil {
+		subr.NotFound(mx.notFoundHandler)
+	}
+	if ok && subr.methodNotAllowedHandler == nil && mx.methodNotAllowedHandler != nil {
+		subr.MethodNotAllowed(mx.methodNotAllowedHandler)
+	}
+
+	mountHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rctx := RouteContext(r.Context())
+
+		// shift the url path past the previous subrouter
+		rctx.RoutePath = mx.nextRoutePath(rctx)
+
+		// reset the wildcard URLParam which connects the subrouter
+		n := len(rctx.URLParams.Keys) - 1
+		if n >= 0 && rctx.URLParams.Keys[n] == "*" && len(rctx.URLParams.Values) > n {
+			rctx.URLParams.Values[n] = ""
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+
+	if pattern == "" || pattern[len(pattern)-1] != '/' {
+		mx.handle(mALL|mSTUB, pattern, mountHandler)
+		mx.handle(mALL|mSTUB, pattern+"/", mountHandler)
+		pattern += "/"
+	}
+
+	method := mALL
+	subroutes, _ := handler.(Routes)
+	if subroutes != nil {
+		method |= mSTUB
+	}
+	mx.handleRoutes(method, pattern+"*", mountHandler, subroutes)
+}
+
+// Handle adds the route `pattern` that matches any http method to
+// execute the `handler` http.Handler.
+func (mx *Mux) Handle(pattern string, handler http.Handler) {
+	mx.handle(mALL, pattern, handler)
+}
+
+// HandleFunc adds the route `pattern` that matches any http method to
+// execute the `handlerFn` http.HandlerFunc.
+func (mx *Mux) HandleFunc(pattern string, handlerFn http.HandlerFunc) {
+	mx.handle(mALL, pattern, handlerFn)
+}
+
+// Method adds the route `pattern` that matches `method` http method to
+// execute the `handler` http.Handler.
+func (mx *Mux) Method(method, pattern string, handler http.Handler) {
+	m, ok := methodMap[strings.ToUpper(method)]
+	if !ok {
+		panic(fmt.Sprintf("webkit: '%s' http method is not supported.", method))
+	}
+	mx.handle(m, pattern, handler)
+}
+
+// MethodFunc adds the route `pattern` that matches `method` http method to
+// execute the `handlerFn` http.HandlerFunc.
+func (mx *Mux) MethodFunc(method, pattern string, handlerFn http.HandlerFunc) {
+	mx.Method(method, pattern, handlerFn)
+}
+
+// Get adds the route `pattern` that matches a GET http method to
+// execute the `handlerFn` http.HandlerFunc.
+func (mx *Mux) Get(pattern string, handlerFn http.HandlerFunc) {
+	mx.handle(mGET, pattern, handlerFn)
+}
+
+// Post adds the route `pattern` that matches a POST http method to
+// execute the `handlerFn` http.HandlerFunc.
+func (mx *Mux) Post(pattern string, handlerFn http.HandlerFunc) {
+	mx.handle(mPOST, pattern, handlerFn)
+}
+
+// Put adds the route `pattern` that matches a PUT http method to
+// execute the `handlerFn` http.HandlerFunc.
+func (mx *Mux) Put(pattern string, handlerFn http.HandlerFunc) {
+	mx.handle(mPUT, pattern, handlerFn)
+}
+
+// Patch adds the route `pattern` that matches a PATCH http method to
+// execute the `handlerFn` http.HandlerFunc.
+func (mx *Mux) Patch(pattern string, handlerFn http.HandlerFunc) {
+	mx.handle(mPATCH, pattern, handlerFn)
+}
+
+// Delete adds the route `pattern` that matches a DELETE http method to
+// execute the `handlerFn` http.HandlerFunc.
+func (mx *Mux) Delete(pattern string, handlerFn http.HandlerFunc) {
+	mx.handle(mDELETE, pattern, handlerFn)
+}
+
+// Head adds the route `pattern` that matches a HEAD http method to
+// execute the `handlerFn` http.HandlerFunc. Without one, HEAD requests
+// are answered by the GET handler, with the body discarded.
+func (mx *Mux) Head(pattern string, handlerFn http.HandlerFunc) {
+	mx.handle(mHEAD, pattern, handlerFn)
+}
+
+// Options adds the route `pattern` that matches an OPTIONS http method to
+// execute the `handlerFn` http.HandlerFunc. Without one, OPTIONS requests
+// are answered with the Allow header of the route.
+func (mx *Mux) Options(pattern string, handlerFn http.HandlerFunc) {
+	mx.handle(mOPTIONS, pattern, handlerFn)
+}
+
+// NotFound sets a custom http.HandlerFunc for routing paths that could
+// not be found. The default 404 handler is `http.NotFound`.
+func (mx *Mux) NotFound(handlerFn http.HandlerFunc) {
+	// Build NotFound handler chain
+	hFn := handlerFn
+	if mx.inline && mx.parent != nil {
+		hFn = Chain(mx.middlewares...).HandlerFunc(hFn).ServeHTTP
+	}
+
+	// Update the notFoundHandler from this point forward
+	mx.notFoundHandler = hFn
+	mx.updateSubRoutes(func(subMux *Mux) {
+		if subMux.notFoundHandler == nil {
+			subMux.NotFound(hFn)
+		}
+	})
+}
+
+// MethodNotAllowed sets a custom http.HandlerFunc for routing paths where the
+// method is unresolved. The default handler returns a 405 with an empty body.
+// The Allow header is set before the handler is called.
+func (mx *Mux) MethodNotAllowed(handlerFn http.HandlerFunc) {
+	// Build MethodNotAllowed handler chain
+	hFn := handlerFn
+	if mx.inline && mx.parent != nil {
+		hFn = Chain(mx.middlewares...).HandlerFunc(hFn).ServeHTTP
+	}
+
+	// Update the methodNotAllowedHandler from this point forward
+	mx.methodNotAllowedHandler = hFn
+	mx.updateSubRoutes(func(subMux *Mux) {
+		if subMux.methodNotAllowedHandler == nil {
+			subMux.MethodNotAllowed(hFn)
+		}
+	})
+}
+
+// Routes returns a slice of routing information from the tree,
+// useful for traversing available routes of a router.
+func (mx *Mux) Routes() []Route {
+	return mx.tree.routes()
+}
+
+// Middlewares returns a slice of middleware handler functions.
+func (mx *Mux) Middlewares() []func(http.Handler) http.Handler {
+	return mx.middlewares
+}
+
+// Match searches the routing tree for a handler that matches the method/path.
+// It's similar to routing a http request, but without executing the handler
+// thereafter.
+//
+// Match has no request to evaluate the conditions of the routes added with
+// When against, so it skips them and only matches the routes without any
+// conditions. Use MatchRequest to take the conditional routes into account.
+//
+// Note: the *Context state is updated during execution, so manage
+// the state carefully or make a NewRouteContext().
+func (mx *Mux) Match(rctx *Context, method, path string) bool {
+	r := rctx.request
+	rctx.request = nil
+	ok := mx.match(rctx, method, path)
+	rctx.request = r
+	return ok
+}
+
+// MatchRequest is like Match for the method and path of the request, but
+// it also evaluates the conditions of the routes added with When against
+// the request, just like routing it would.
+func (mx *Mux) MatchRequest(rctx *Context, r *http.Request) bool {
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+	if path == "" {
+		path = "/"
+	}
+	prev := rctx.request
+	rctx.request = r
+	ok := mx.match(rctx, r.Method, path)
+	rctx.request = prev
+	return ok
+}
+
+// match searches the routing tree like Match, evaluating the conditional
+// routes against the request of the context, if there is one
+func (mx *Mux) match(rctx *Context, method, path string) bool {
+	m, ok := methodMap[method]
+	if !ok {
+		return false
+	}
+	h, subroutes := mx.tree.find(rctx, m, path)
+	if h != nil && subroutes != nil {
+		rctx.RoutePath = mx.nextRoutePath(rctx)
+		if sub, ok := subroutes.(*Mux); ok {
+			return sub.match(rctx, method, rctx.RoutePath)
+		}
+		return subroutes.Match(rctx, method, rctx.RoutePath)
+	}
+	return h != nil
+}
+
+// NotFoundHandler returns the default Mux 404 responder whenever a route
+// cannot be found.
+func (mx *Mux) NotFoundHandler() http.HandlerFunc {
+	if mx.notFoundHandler != nil {
+		return mx.notFoundHandler
+	}
+	return http.NotFound
+}
+
+// MethodNotAllowedHandler returns the default Mux 405 responder whenever
+// a method cannot be resolved for a route. The handler sets the Allow
+// header to the provided methods.
+func (mx *Mux) MethodNotAllowedHandler(methodsAllowed ...methodTyp) http.HandlerFunc {
+	next := mx.methodNotAllowedHandler
+	if next == nil {
+		next = methodNotAllowedHandler
+	}
+	if len(methodsAllowed) == 0 {
+		return next
+	}
+	allow := allowHeader(methodsAllowed)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		next(w, r)
+	}
+}
+
+// handle registers a http.Handler in the routing tree for a particular http method
+// and routing pattern.
+func (mx *Mux) handle(method methodTyp, pattern string, handler http.Handler) {
+	mx.handleRoutes(method, pattern, handler, nil)
+}
+
+// handleRoutes registers a http.Handler like handle, along with the subroutes
+// mounted on the routing pattern, if any.
+func (mx *Mux) handleRoutes(method methodTyp, pattern string, handler http.Handler, subroutes Routes) {
+	key, err := mx.checkRoute(method, pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	// Build the computed routing handler for this routing pattern.
+	if !mx.inline && mx.handler == nil {
+		mx.updateRouteHandler()
+	}
+
+	// Build endpoint handler with inline middlewares for the route
+	var h http.Handler
+	if mx.inline {
+		mx.handler = http.HandlerFunc(mx.routeHTTP)
+		h = Chain(mx.middlewares...).Handler(handler)
+	} else {
+		h = handler
+	}
+
+	// Record the name of the route
+	if mx.name != "" {
+		if p, ok := mx.names[mx.name]; ok && p != pattern {
+			panic(fmt.Sprintf("webkit: route name '%s' is already used by '%s'", mx.name, p))
+		}
+		mx.names[mx.name] = pattern
+	}
+
+	// Add the endpoint to the tree
+	h = mx.register(key, method, pattern, h)
+	mx.tree.insert(method, pattern, h, subroutes)
+
+	// A route for any method takes the endpoints of every method, the
+	// conditional routes of a method are put back in front of it
+	if method&mALL == mALL {
+		for m, e := range mx.registered[key] {
+			if m != mALL && e.matched != nil {
+				mx.tree.insert(m, e.pattern, e.matched, nil)
+			}
+		}
+	}
+}
+
+// routeHTTP routes a http.Request through the Mux routing tree to serve
+// the matching handler for a particular http method.
+func (mx *Mux) routeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Grab the route context object
+	rctx := r.Context().Value(RouteCtxKey).(*Context)
+
+	// The request routing path
+	routePath := rctx.RoutePath
+	if routePath == "" {
+		if r.URL.RawPath != "" {
+			routePath = r.URL.RawPath
+		} else {
+			routePath = r.URL.Path
+		}
+		if routePath == "" {
+			routePath = "/"
+		}
+	}
+
+	// Check if method is supported by webkit
+	if rctx.RouteMethod == "" {
+		rctx.RouteMethod = r.Method
+	}
+	method, ok := methodMap[rctx.RouteMethod]
+	if !ok {
+		mx.MethodNotAllowedHandler().ServeHTTP(w, r)
+		return
+	}
+
+	// Find the route, matching the conditional routes against the request
+	rctx.request = r
+	if h, _ := mx.tree.find(rctx, method, routePath); h != nil {
+		h.ServeHTTP(w, r)
+		return
+	}
+	if !rctx.methodNotAllowed {
+		mx.NotFoundHandler().ServeHTTP(w, r)
+		return
+	}
+
+	// Answer HEAD using the GET handler, and OPTIONS with the methods
+	// allowed, when they have no handlers of their own
+	switch method {
+	case mHEAD:
+		if h, _ := mx.tree.find(rctx, mGET, routePath); h != nil {
+			h.ServeHTTP(&headResponseWriter{w}, r)
+			return
+		}
+	case mOPTIONS:
+		w.Header().Set("Allow", allowHeader(rctx.methodsAllowed))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	mx.MethodNotAllowedHandler(rctx.methodsAllowed...).ServeHTTP(w, r)
+}
+
+// root returns the mux of an inline-mux, or the mux itself
+func (mx *Mux) root() *Mux {
+	for mx.inline && mx.parent != nil {
+		mx = mx.parent
+	}
+	return mx
+}
+
+// nextRoutePath returns the routing path for a subrouter, which is the
+// remainder of the path matched by the wildcard of the mount pattern.
+func (mx *Mux) nextRoutePath(rctx *Context) string {
+	routePath := "/"
+	nx := len(rctx.routeParams.Keys) - 1 // index of last param in list
+	if nx >= 0 && rctx.routeParams.Keys[nx] == "*" && len(rctx.routeParams.Values) > nx {
+		routePath = "/" + rctx.routeParams.Values[nx]
+	}
+	return routePath
+}
+
+// updateSubRoutes is a helper function to recursively update any subroutes
+// with new notFoundHandler or methodNotAllowedHandler.
+func (mx *Mux) updateSubRoutes(fn func(subMux *Mux)) {
+	for _, r := range mx.tree.routes() {
+		subMux, ok := r.SubRoutes.(*Mux)
+		if !ok {
+			continue
+		}
+		fn(subMux)
+	}
+}
+
+// updateRouteHandler builds the single mux handler that is a chain of the middleware
+// stack, as defined by calls to Use(), and the tree router (Mux) itself. After this
+// point, no other middlewares can be registered on this Mux's stack. But you can still
+// compose additional middlewares using a chained middleware handler (see Chain).
+func (mx *Mux) updateRouteHandler() {
+	mx.handler = chain(mx.middlewares, http.HandlerFunc(mx.routeHTTP))
+}
+
+// methodNotAllowedHandler is a helper function to respond with a 405,
+// method not allowed.
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	w.Write(nil)
+}
+
+// allowHeader returns the value of the Allow header for the methods,
+// in sorted order. HEAD is allowed along with GET, and OPTIONS is
+// always allowed, since the Mux answers them itself.
+func allowHeader(methods []methodTyp) string {
+	names := make([]string, 0, len(methods)+2)
+	var get, head, options bool
+	for _, m := range methods {
+		if name := methodTypString(m); name != "" {
+			names = append(names, name)
+		}
+		get = get || m == mGET
+		head = head || m == mHEAD
+		options = options || m == mOPTIONS
+	}
+	if get && !head {
+		names = append(names, "HEAD")
+	}
+	if !options {
+		names = append(names, "OPTIONS")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// headResponseWriter is a http.ResponseWriter that discards the body,
+// used to answer HEAD requests with a GET handler
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *headResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for the
+// http.ResponseController
+func (w *headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,385 @@
+package webkit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testRequest serves a request on the handler and returns
+// the recorded response along with its body
+func testRequest(t *testing.T, h http.Handler, method, path string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	body, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, string(body)
+}
+
+func writeString(s string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, s)
+	}
+}
+
+func TestMux_Routing(t *testing.T) {
+	m := NewMux()
+	m.Get("/", writeString("index"))
+	m.Get("/users", writeString("list users"))
+	m.Post("/users", writeString("create user"))
+	m.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "user "+URLParam(r, "id"))
+	})
+	m.Get("/users/{id}/posts/{post:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, URLParam(r, "id")+" post "+URLParam(r, "post"))
+	})
+	m.MethodFunc("delete", "/users/{id}", writeString("delete user"))
+	m.HandleFunc("/any", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "any "+r.Method)
+	})
+	m.Get("/static/*", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "file "+URLParam(r, "*"))
+	})
+
+	cases := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/", 200, "index"},
+		{"GET", "/users", 200, "list users"},
+		{"POST", "/users", 200, "create user"},
+		{"GET", "/users/42", 200, "user 42"},
+		{"DELETE", "/users/42", 200, "delete user"},
+		{"GET", "/users/42/posts/7", 200, "42 post 7"},
+		{"GET", "/users/42/posts/seven", 404, "404 page not found\n"},
+		{"PATCH", "/any", 200, "any PATCH"},
+		{"GET", "/static/css/site.css", 200, "file css/site.css"},
+		{"GET", "/nope", 404, "404 page not found\n"},
+	}
+	for _, c := range cases {
+		w, body := testRequest(t, m, c.method, c.path)
+		if w.Code != c.code || body != c.body {
+			t.Fatalf("%s %s: expected %d %q, got %d %q", c.method, c.path, c.code, c.body, w.Code, body)
+		}
+	}
+}
+
+func TestMux_MethodNotAllowed(t *testing.T) {
+	m := NewMux()
+	m.Get("/users", writeString("list users"))
+	m.Post("/users", writeString("create user"))
+	m.Get("/users/{id}", writeString("user"))
+	m.Put("/users/{id}", writeString("update"))
+
+	w, _ := testRequest(t, m, "DELETE", "/users")
+	if w.Code != 405 || w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Fatalf("expected 405 with Allow: GET, HEAD, OPTIONS, POST, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+	w, _ = testRequest(t, m, "PATCH", "/users/1")
+	if w.Code != 405 || w.Header().Get("Allow") != "GET, HEAD, OPTIONS, PUT" {
+		t.Fatalf("expected 405 with Allow: GET, HEAD, OPTIONS, PUT, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	m.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(405)
+		io.WriteString(w, "nope")
+	})
+	m.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		io.WriteString(w, "missing")
+	})
+	w, body := testRequest(t, m, "DELETE", "/users")
+	if w.Code != 405 || body != "nope" || w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Fatalf("custom 405: got %d %q %q", w.Code, body, w.Header().Get("Allow"))
+	}
+	w, body = testRequest(t, m, "GET", "/posts")
+	if w.Code != 404 || body != "missing" {
+		t.Fatalf("custom 404: got %d %q", w.Code, body)
+	}
+	w, _ = testRequest(t, m, "BREW", "/users")
+	if w.Code != 405 {
+		t.Fatalf("unknown method: got %d", w.Code)
+	}
+}
+
+func TestMux_Middlewares(t *testing.T) {
+	var order []string
+	mw := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	m := NewMux()
+	m.Use(mw("one"), mw("two"))
+	m.Use(mw("three"))
+	m.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	})
+	m.Handle("/chained", Chain(mw("inline")).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "chained")
+	}))
+
+	testRequest(t, m, "GET", "/")
+	testRequest(t, m, "GET", "/chained")
+	// the middlewares run for requests that do not match a route too
+	testRequest(t, m, "GET", "/nope")
+	want := "one two three handler one two three inline chained one two three"
+	if got := strings.Join(order, " "); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for Use after routes")
+		}
+	}()
+	m.Use(mw("late"))
+}
+
+func TestMux_Context(t *testing.T) {
+	var contexts []*Context
+	m := NewMux()
+	m.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		rctx := RouteContext(r.Context())
+		contexts = append(contexts, rctx)
+		io.WriteString(w, strings.Join(rctx.URLParams.Keys, ",")+"="+strings.Join(rctx.URLParams.Values, ","))
+		io.WriteString(w, " "+rctx.RoutePattern())
+	})
+	for i := 0; i < 3; i++ {
+		if _, body := testRequest(t, m, "GET", "/users/"+strings.Repeat("x", i+1)); body != "id="+strings.Repeat("x", i+1)+" /users/{id}" {
+			t.Fatalf("request %d: got %q", i, body)
+		}
+	}
+	// the contexts are reset and reused, rather than leaking state
+	if contexts[0].Routes != m {
+		t.Fatalf("bad routes on the context")
+	}
+
+	rctx := NewRouteContext()
+	if !m.Match(rctx, "GET", "/users/1") || rctx.URLParam("id") != "1" {
+		t.Fatalf("match failed")
+	}
+	if m.Match(NewRouteContext(), "POST", "/users/1") || m.Match(NewRouteContext(), "GET", "/users") {
+		t.Fatalf("unexpected match")
+	}
+}
+
+func TestMux_Walk(t *testing.T) {
+	m := NewMux()
+	m.Use(func(next http.Handler) http.Handler { return next })
+	m.Get("/users", writeString(""))
+	m.Post("/users", writeString(""))
+	m.Get("/users/{id}", writeString(""))
+	m.Handle("/ping", writeString(""))
+	var routes []string
+	err := Walk(m, func(method, route string, h http.Handler, mws ...func(http.Handler) http.Handler) error {
+		if len(mws) != 1 {
+			t.Fatalf("expected 1 middleware, got %d", len(mws))
+		}
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	// the route of Handle is walked once per method
+	if err != nil || len(routes) != 3+len(methodMap) {
+		t.Fatalf("walk: got %v, %v", routes, err)
+	}
+}
+
+func TestMux_Empty(t *testing.T) {
+	w, _ := testRequest(t, NewMux(), "GET", "/")
+	if w.Code != 404 {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for a pattern without a leading slash")
+		}
+	}()
+	NewMux().Get("users", writeString(""))
+}
+
+func TestMux_Subrouters(t *testing.T) {
+	var order []string
+	mw := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	pattern := func(w http.ResponseWriter, r *http.Request) {
+		rctx := RouteContext(r.Context())
+		io.WriteString(w, rctx.RoutePattern())
+		for i, k := range rctx.URLParams.Keys {
+			if k != "*" {
+				io.WriteString(w, " "+k+"="+rctx.URLParams.Values[i])
+			}
+		}
+	}
+
+	m := NewMux()
+	m.Use(mw("root"))
+	m.Get("/", pattern)
+	m.Route("/users/{user}", func(r Router) {
+		r.Use(mw("users"))
+		r.Get("/", pattern)
+		r.Route("/posts", func(r Router) {
+			r.Get("/{post}", pattern)
+		})
+		r.Group(func(r Router) {
+			r.Use(mw("admin"))
+			r.Delete("/", pattern)
+		})
+		r.With(mw("inline")).Put("/", pattern)
+	})
+	static := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rctx := RouteContext(r.Context())
+		io.WriteString(w, "static "+rctx.RoutePath)
+	})
+	m.Mount("/static", static)
+
+	cases := []struct {
+		method, path string
+		code         int
+		body         string
+		order        string
+	}{
+		{"GET", "/", 200, "/", "root"},
+		{"GET", "/users/1", 200, "/users/{user} user=1", "root users"},
+		{"GET", "/users/1/", 200, "/users/{user} user=1", "root users"},
+		{"GET", "/users/1/posts/2", 200, "/users/{user}/posts/{post} user=1 post=2", "root users"},
+		{"DELETE", "/users/1", 200, "/users/{user} user=1", "root users admin"},
+		{"PUT", "/users/1", 200, "/users/{user} user=1", "root users inline"},
+		{"PATCH", "/users/1", 405, "", "root users"},
+		{"GET", "/users/1/comments", 404, "404 page not found\n", "root users"},
+		{"GET", "/static", 200, "static /", "root"},
+		{"GET", "/static/css/site.css", 200, "static /css/site.css", "root"},
+	}
+	for _, c := range cases {
+		order = order[:0]
+		w, body := testRequest(t, m, c.method, c.path)
+		if w.Code != c.code || body != c.body {
+			t.Fatalf("%s %s: expected %d %q, got %d %q", c.method, c.path, c.code, c.body, w.Code, body)
+		}
+		if got := strings.Join(order, " "); got != c.order {
+			t.Fatalf("%s %s: expected middlewares %q, got %q", c.method, c.path, c.order, got)
+		}
+	}
+	if w, _ := testRequest(t, m, "PATCH", "/users/1"); w.Header().Get("Allow") != "DELETE, GET, HEAD, OPTIONS, PUT" {
+		t.Fatalf("expected Allow: DELETE, GET, HEAD, OPTIONS, PUT, got %q", w.Header().Get("Allow"))
+	}
+
+	rctx := NewRouteContext()
+	if !m.Match(rctx, "GET", "/users/1/posts/2") || rctx.URLParam("post") != "2" || rctx.URLParam("user") != "1" {
+		t.Fatalf("match failed: %v", rctx.URLParams)
+	}
+	if m.Match(NewRouteContext(), "POST", "/users/1/posts/2") {
+		t.Fatalf("unexpected match")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for mounting on an existing path")
+		}
+	}()
+	m.Mount("/static", static)
+}
+
+func TestMux_SubrouterNotFound(t *testing.T) {
+	m := NewMux()
+	m.Route("/api", func(r Router) {
+		r.Get("/ping", writeString("pong"))
+	})
+	m.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		io.WriteString(w, "missing")
+	})
+	m.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(405)
+		io.WriteString(w, "nope")
+	})
+	if w, body := testRequest(t, m, "GET", "/api/pong"); w.Code != 404 || body != "missing" {
+		t.Fatalf("expected the parent 404 handler, got %d %q", w.Code, body)
+	}
+	if w, body := testRequest(t, m, "POST", "/api/ping"); w.Code != 405 || body != "nope" {
+		t.Fatalf("expected the parent 405 handler, got %d %q", w.Code, body)
+	}
+
+	var routes []string
+	Walk(m, func(method, route string, h http.Handler, mws ...func(http.Handler) http.Handler) error {
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	if len(routes) != 1 || routes[0] != "GET /api/ping" {
+		t.Fatalf("walk: got %v", routes)
+	}
+}
+
+func TestMux_HeadOptions(t *testing.T) {
+	m := NewMux()
+	m.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		io.WriteString(w, "list users")
+	})
+	m.Post("/users", writeString("create user"))
+	m.Delete("/users/{id}", writeString("delete user"))
+	m.Head("/files", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Head", "yes")
+	})
+	m.Get("/files", writeString("files"))
+	m.Options("/custom", writeString("custom options"))
+	m.Route("/api", func(r Router) {
+		r.Get("/ping", writeString("pong"))
+	})
+
+	w, body := testRequest(t, m, "HEAD", "/users")
+	if w.Code != 200 || body != "" || w.Header().Get("X-Method") != "HEAD" {
+		t.Fatalf("head: got %d %q %v", w.Code, body, w.Header())
+	}
+	if w, body = testRequest(t, m, "HEAD", "/files"); body != "" || w.Header().Get("X-Head") != "yes" {
+		t.Fatalf("explicit head: got %q %v", body, w.Header())
+	}
+	if w, body = testRequest(t, m, "HEAD", "/api/ping"); w.Code != 200 || body != "" {
+		t.Fatalf("head on a subrouter: got %d %q", w.Code, body)
+	}
+	if w, _ = testRequest(t, m, "HEAD", "/users/1"); w.Code != 405 || w.Header().Get("Allow") != "DELETE, OPTIONS" {
+		t.Fatalf("head without get: got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	// the writer of a HEAD request flushes, and unwraps to the original one
+	var unwrapped http.ResponseWriter
+	m.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "chunk")
+		w.(http.Flusher).Flush()
+		if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); ok {
+			unwrapped = u.Unwrap()
+		}
+	})
+	if w, body = testRequest(t, m, "HEAD", "/stream"); !w.Flushed || body != "" || unwrapped != w {
+		t.Fatalf("head flush: got %v %q, unwrapped to %T", w.Flushed, body, unwrapped)
+	}
+
+	w, body = testRequest(t, m, "OPTIONS", "/users")
+	if w.Code != 204 || body != "" || w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Fatalf("options: got %d %q %q", w.Code, body, w.Header().Get("Allow"))
+	}
+	if w, _ = testRequest(t, m, "OPTIONS", "/api/ping"); w.Code != 204 || w.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Fatalf("options on a subrouter: got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+	if _, body = testRequest(t, m, "OPTIONS", "/custom"); body != "custom options" {
+		t.Fatalf("explicit options: got %q", body)
+	}
+	if w, _ = testRequest(t, m, "OPTIONS", "/nope"); w.Code != 404 {
+		t.Fatalf("options on a missing route: got %d", w.Code)
+	}
+}
@@ -0,0 +1,162 @@
+package webkit
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrMissingParam is returned by the typed URL param accessors when
+	// the request has no value for the param.
+	ErrMissingParam = errors.New("webkit: missing url param")
+
+	// ErrBadParam is returned by the typed URL param accessors when the
+	// value of the param can not be parsed.
+	ErrBadParam = errors.New("webkit: bad url param")
+)
+
+// paramTypes maps the names of the param types that can be used in a
+// routing pattern, as in `{id:int}`, to the regexp they match
+var paramTypes = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"slug":  `[a-z0-9]+(?:-[a-z0-9]+)*`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"date":  `[0-9]{4}-[0-9]{2}-[0-9]{2}`,
+}
+
+// RegisterParamType adds a named param type that can be used in routing
+// patterns in place of a regexp, as in `{slug:slug}`. A request with a
+// value that does not match the pattern does not match the route. The
+// built-in types are int, uint, alpha, alnum, slug, uuid and date. Like
+// RegisterMethod, it must be called before the routes using it are added,
+// and it is not safe to call concurrently with routing.
+func RegisterParamType(name, pattern string) {
+	if name == "" {
+		panic("webkit: param type name must not be empty")
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		panic(fmt.Sprintf("webkit: invalid pattern for param type '%s': %s", name, err))
+	}
+	paramTypes[name] = pattern
+}
+
+// paramTypePattern returns the anchored regexp of a named param
+// type, and a boolean reporting if the param type exists
+func paramTypePattern(name string) (string, bool) {
+	pattern, ok := paramTypes[name]
+	if !ok {
+		return "", false
+	}
+	return "^(?:" + pattern + ")$", true
+}
+
+// urlParam returns the url param for the key, or ErrMissingParam
+func urlParam(r *http.Request, key string) (string, error) {
+	value := URLParam(r, key)
+	if value == "" {
+		return "", fmt.Errorf("%w: %q", ErrMissingParam, key)
+	}
+	return value, nil
+}
+
+// URLParamInt returns the url param for the key parsed as an int.
+func URLParamInt(r *http.Request, key string) (int, error) {
+	value, err := urlParam(r, key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not an int: %q", ErrBadParam, key, value)
+	}
+	return n, nil
+}
+
+// URLParamTime returns the url param for the key parsed as a time using
+// the layout (see time.Parse).
+func URLParamTime(r *http.Request, key, layout string) (time.Time, error) {
+	value, err := urlParam(r, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is not a time: %q", ErrBadParam, key, value)
+	}
+	return t, nil
+}
+
+// UUID is a universally unique identifier, as defined in RFC 4122.
+type UUID [16]byte
+
+// String returns the canonical form of the UUID,
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// ParseUUID parses a UUID in the canonical form.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("%w: %q is not a uuid", ErrBadParam, s)
+	}
+	j := 0
+	for i := 0; i < len(s); i += 2 {
+		if s[i] == '-' {
+			i++
+		}
+		hi, ok1 := fromHex(s[i])
+		lo, ok2 := fromHex(s[i+1])
+		if !ok1 || !ok2 {
+			return UUID{}, fmt.Errorf("%w: %q is not a uuid", ErrBadParam, s)
+		}
+		u[j] = hi<<4 | lo
+		j++
+	}
+	return u, nil
+}
+
+// fromHex returns the value of a hex digit
+func fromHex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// URLParamUUID returns the url param for the key parsed as a UUID.
+func URLParamUUID(r *http.Request, key string) (UUID, error) {
+	value, err := urlParam(r, key)
+	if err != nil {
+		return UUID{}, err
+	}
+	u, err := ParseUUID(value)
+	if err != nil {
+		return UUID{}, fmt.Errorf("%w: %q is not a uuid: %q", ErrBadParam, key, value)
+	}
+	return u, nil
+}
@@ -0,0 +1,114 @@
+package webkit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParamTypes(t *testing.T) {
+	RegisterParamType("hex", `[0-9a-f]+`)
+
+	m := NewMux()
+	m.Get("/users/{id:int}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := URLParamInt(r, "id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(w, "user %d", id)
+	})
+	m.Get("/users/{name:alpha}", writeString("user by name"))
+	m.Get("/posts/{slug:slug}", writeString("post"))
+	m.Get("/colors/{color:hex}", writeString("color"))
+	m.Get("/objects/{id:uuid}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := URLParamUUID(r, "id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, id.String())
+	})
+	m.Get("/archive/{day:date}", func(w http.ResponseWriter, r *http.Request) {
+		day, err := URLParamTime(r, "day", "2006-01-02")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, day.Weekday().String())
+	})
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/users/42", 200, "user 42"},
+		{"/users/-7", 200, "user -7"},
+		{"/users/bob", 200, "user by name"},
+		{"/users/bob42", 404, "404 page not found\n"},
+		{"/posts/hello-world", 200, "post"},
+		{"/posts/Hello-World", 404, "404 page not found\n"},
+		{"/posts/hello--world", 404, "404 page not found\n"},
+		{"/colors/ff00aa", 200, "color"},
+		{"/colors/red", 404, "404 page not found\n"},
+		{"/objects/6BA7B810-9DAD-11d1-80b4-00c04fd430c8", 200, "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		{"/objects/6ba7b810-9dad-11d1-80b4", 404, "404 page not found\n"},
+		{"/archive/2022-10-17", 200, "Monday"},
+		{"/archive/2022-13-17", 400, ""},
+		{"/archive/yesterday", 404, "404 page not found\n"},
+	}
+	for _, c := range cases {
+		w, body := testRequest(t, m, "GET", c.path)
+		if w.Code != c.code || body != c.body {
+			t.Fatalf("%s: expected %d %q, got %d %q", c.path, c.code, c.body, w.Code, body)
+		}
+	}
+}
+
+func TestURLParamErrors(t *testing.T) {
+	m := NewMux()
+	m.Get("/{value}", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := URLParamInt(r, "value"); !errors.Is(err, ErrBadParam) {
+			t.Fatalf("int: expected ErrBadParam, got %v", err)
+		}
+		if _, err := URLParamUUID(r, "value"); !errors.Is(err, ErrBadParam) {
+			t.Fatalf("uuid: expected ErrBadParam, got %v", err)
+		}
+		if _, err := URLParamTime(r, "value", time.RFC3339); !errors.Is(err, ErrBadParam) {
+			t.Fatalf("time: expected ErrBadParam, got %v", err)
+		}
+		if _, err := URLParamInt(r, "other"); !errors.Is(err, ErrMissingParam) {
+			t.Fatalf("expected ErrMissingParam, got %v", err)
+		}
+	})
+	if w, _ := testRequest(t, m, "GET", "/abc"); w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for an invalid param type pattern")
+		}
+	}()
+	RegisterParamType("bad", `[a-`)
+}
+
+func TestParseUUID(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"6ba7b810-9dad-11d1-80b4-00c04fd430c",
+		"6ba7b810-9dad-11d1-80b4-00c04fd430c8a",
+		"6ba7b810x9dad-11d1-80b4-00c04fd430c8",
+		"6ba7b810-9dad-11d1-80b4-00c04fd430cg",
+	} {
+		if _, err := ParseUUID(s); !errors.Is(err, ErrBadParam) {
+			t.Fatalf("%q: expected ErrBadParam, got %v", s, err)
+		}
+	}
+	u, err := ParseUUID("00112233-4455-6677-8899-aabbccddeeff")
+	if err != nil || u != (UUID{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}) {
+		t.Fatalf("got %v, %v", u, err)
+	}
+}
@@ -0,0 +1,300 @@
+package webkit
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// radixMatcher is a RouteMatcher backed by two radix Trees. Static routes
+// are found with a single lookup of the path. Other routes are grouped by
+// the static prefix before their first param, and a path tries the routes
+// of its longest prefix first, then those of the shorter ones, which gives
+// the same precedence as the chi tree: static, regexp, param, catch-all.
+type radixMatcher struct {
+	// static maps the patterns without params to their *radixRoute
+	static *Tree
+
+	// params maps the static prefixes of the other patterns to their
+	// *radixGroup
+	params *Tree
+
+	// routes by the key of their pattern (see patternKey), as patterns
+	// that only differ by the names of their params share an endpoint
+	byKey map[string]*radixRoute
+
+	// routes in the order they were added
+	all []*radixRoute
+}
+
+// radixRoute is the route of a pattern
+type radixRoute struct {
+	segments  []radixSegment
+	endpoints endpoints
+	subroutes Routes
+}
+
+// radixSegment is a static or param segment of a routing pattern
+type radixSegment struct {
+	typ    nodeTyp
+	prefix string
+	rex    *regexp.Regexp
+	tail   byte
+}
+
+// radixGroup is the routes sharing a static prefix
+type radixGroup struct {
+	// routes with this prefix, in order of precedence
+	routes []*radixRoute
+
+	// candidates are the routes of this prefix and of the shorter
+	// prefixes of it, in the order they are tried
+	candidates []*radixRoute
+}
+
+// NewRadixMatcher returns a RouteMatcher backed by the radix Tree.
+func NewRadixMatcher() RouteMatcher {
+	return &radixMatcher{
+		static: NewTree(),
+		params: NewTree(),
+		byKey:  map[string]*radixRoute{},
+	}
+}
+
+func (m *radixMatcher) insert(method methodTyp, pattern string, handler http.Handler, subroutes Routes) {
+	key, err := patternKey(pattern)
+	if err != nil {
+		panic(err)
+	}
+	rt, ok := m.byKey[key]
+	if !ok {
+		rt = &radixRoute{segments: parseSegments(pattern), endpoints: endpoints{}}
+		m.byKey[key] = rt
+		m.all = append(m.all, rt)
+		m.add(rt)
+	}
+	rt.endpoints.set(method, handler, pattern)
+	if subroutes != nil {
+		rt.subroutes = subroutes
+	}
+}
+
+// add adds a new route to the trees
+func (m *radixMatcher) add(rt *radixRoute) {
+	first := rt.segments[0]
+	if len(rt.segments) == 1 && first.typ == ntStatic {
+		m.static.Insert(first.prefix, rt)
+		return
+	}
+
+	// the prefix of a pattern starting with a param is empty
+	var prefix string
+	if first.typ == ntStatic {
+		prefix = first.prefix
+	}
+	var g *radixGroup
+	if v, ok := m.params.Find(prefix); ok {
+		g = v.(*radixGroup)
+	} else {
+		g = &radixGroup{}
+		m.params.Insert(prefix, g)
+	}
+	i := sort.Search(len(g.routes), func(i int) bool {
+		return rt.before(g.routes[i])
+	})
+	g.routes = append(g.routes, nil)
+	copy(g.routes[i+1:], g.routes[i:])
+	g.routes[i] = rt
+
+	// update the candidates of the prefixes starting with this one
+	m.params.WalkPathAbove(prefix, func(s string, v any) bool {
+		g := v.(*radixGroup)
+		g.candidates = g.candidates[:0]
+		var groups []*radixGroup
+		m.params.WalkPathBelow(s, func(_ string, v any) bool {
+			groups = append(groups, v.(*radixGroup))
+			return false
+		})
+		for i := len(groups) - 1; i >= 0; i-- {
+			g.candidates = append(g.candidates, groups[i].routes...)
+		}
+		return false
+	})
+}
+
+func (m *radixMatcher) find(rctx *Context, method methodTyp, path string) (http.Handler, Routes) {
+	// Reset the context routing pattern and params
+	rctx.routePattern = ""
+	rctx.routeParams.Keys = rctx.routeParams.Keys[:0]
+	rctx.routeParams.Values = rctx.routeParams.Values[:0]
+
+	rt, h, handler := m.findRoute(rctx, method, path)
+	if rt == nil {
+		return nil, nil
+	}
+
+	// Record the routing params in the request lifecycle
+	rctx.routeParams.Keys = append(rctx.routeParams.Keys, h.paramKeys...)
+	rctx.URLParams.Keys = append(rctx.URLParams.Keys, rctx.routeParams.Keys...)
+	rctx.URLParams.Values = append(rctx.URLParams.Values, rctx.routeParams.Values...)
+
+	// Record the routing pattern in the request lifecycle
+	if h.pattern != "" {
+		rctx.routePattern = h.pattern
+		rctx.RoutePatterns = append(rctx.RoutePatterns, rctx.routePattern)
+	}
+	return handler, rt.subroutes
+}
+
+// findRoute returns the route of the path having a handler for the method,
+// its endpoint and the handler of the endpoint (see endpointHandler)
+func (m *radixMatcher) findRoute(rctx *Context, method methodTyp, path string) (*radixRoute, *endpoint, http.Handler) {
+	if v, ok := m.static.Find(path); ok {
+		rt := v.(*radixRoute)
+		if h, handler := rt.endpoint(rctx, method); handler != nil {
+			return rt, h, handler
+		}
+	}
+	_, v, ok := m.params.FindLongestPrefix(path)
+	if !ok {
+		return nil, nil, nil
+	}
+	for _, rt := range v.(*radixGroup).candidates {
+		if !rt.match(rctx, path) {
+			continue
+		}
+		if h, handler := rt.endpoint(rctx, method); handler != nil {
+			return rt, h, handler
+		}
+		rctx.routeParams.Values = rctx.routeParams.Values[:0]
+	}
+	return nil, nil, nil
+}
+
+func (m *radixMatcher) has(pattern string) bool {
+	key, err := patternKey(pattern)
+	if err != nil {
+		return false
+	}
+	_, ok := m.byKey[key]
+	return ok
+}
+
+func (m *radixMatcher) routes() []Route {
+	rts := []Route{}
+	for _, rt := range m.all {
+		rts = appendRoutes(rts, rt.endpoints, rt.subroutes)
+	}
+	return rts
+}
+
+func (m *radixMatcher) fresh() RouteMatcher {
+	return NewRadixMatcher()
+}
+
+// endpoint returns the endpoint of the method and its handler, or flags the
+// routing context with the methods allowed if the route has no handler for
+// it. The handler is nil as well when the conditions of the routes of the
+// endpoint do not match.
+func (rt *radixRoute) endpoint(rctx *Context, method methodTyp) (*endpoint, http.Handler) {
+	if h := rt.endpoints[method]; h != nil && h.handler != nil {
+		return h, endpointHandler(rctx, h.handler)
+	}
+	rctx.methodNotAllowed = true
+	rctx.addMethodsAllowed(rt.endpoints)
+	return nil, nil
+}
+
+// match reports whether the route matches the path, recording the values
+// of its params in rctx when it does
+func (rt *radixRoute) match(rctx *Context, path string) bool {
+	search := path
+	for _, seg := range rt.segments {
+		switch seg.typ {
+		case ntStatic:
+			if !strings.HasPrefix(search, seg.prefix) {
+				rctx.routeParams.Values = rctx.routeParams.Values[:0]
+				return false
+			}
+			search = search[len(seg.prefix):]
+
+		case ntParam, ntRegexp:
+			// the param value ends at the tail byte, or at the end of the
+			// path for a param ending the pattern
+			p := -1
+			if search != "" {
+				p = strings.IndexByte(search, seg.tail)
+				if p < 0 && seg.tail == '/' {
+					p = len(search)
+				}
+			}
+			switch {
+			case p < 0, seg.typ == ntRegexp && p == 0:
+				p = -1
+			case seg.typ == ntRegexp:
+				if !seg.rex.MatchString(search[:p]) {
+					p = -1
+				}
+			case strings.IndexByte(search[:p], '/') >= 0:
+				// avoid a match across path segments
+				p = -1
+			}
+			if p < 0 {
+				rctx.routeParams.Values = rctx.routeParams.Values[:0]
+				return false
+			}
+			rctx.routeParams.Values = append(rctx.routeParams.Values, search[:p])
+			search = search[p:]
+
+		case ntCatchAll:
+			rctx.routeParams.Values = append(rctx.routeParams.Values, search)
+			search = ""
+		}
+	}
+	if search != "" {
+		rctx.routeParams.Values = rctx.routeParams.Values[:0]
+		return false
+	}
+	return true
+}
+
+// before reports whether the route takes precedence over another route
+// sharing its static prefix. Their segments are compared in turn, as the
+// chi tree tries its nodes: static before regexp, param and catch-all, and
+// the longer static segment first.
+func (rt *radixRoute) before(o *radixRoute) bool {
+	for i := 0; i < len(rt.segments) && i < len(o.segments); i++ {
+		a, b := rt.segments[i], o.segments[i]
+		if a.typ != b.typ {
+			return a.typ < b.typ
+		}
+		if a.typ == ntStatic && len(a.prefix) != len(b.prefix) {
+			return len(a.prefix) > len(b.prefix)
+		}
+	}
+	return len(rt.segments) < len(o.segments)
+}
+
+// parseSegments splits a valid routing pattern into its segments
+func parseSegments(pattern string) []radixSegment {
+	var segs []radixSegment
+	for pat := pattern; ; {
+		typ, _, rexpat, tail, ps, pe := patNextSegment(pat)
+		if typ == ntStatic {
+			if pat != "" {
+				segs = append(segs, radixSegment{typ: ntStatic, prefix: pat})
+			}
+			return segs
+		}
+		if ps > 0 {
+			segs = append(segs, radixSegment{typ: ntStatic, prefix: pat[:ps]})
+		}
+		seg := radixSegment{typ: typ, tail: tail}
+		if typ == ntRegexp {
+			seg.rex = regexp.MustCompile(rexpat)
+		}
+		segs = append(segs, seg)
+		pat = pat[pe:]
+	}
+}
@@ -0,0 +1,80 @@
+package webkit
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestRadixMatcher runs the Mux tests with the radix Tree as the routing
+// tree, which must route in the same way as the chi tree.
+func TestRadixMatcher(t *testing.T) {
+	defer func(fn func() RouteMatcher) { newDefaultMatcher = fn }(newDefaultMatcher)
+	newDefaultMatcher = NewRadixMatcher
+
+	t.Run("Routing", TestMux_Routing)
+	t.Run("MethodNotAllowed", TestMux_MethodNotAllowed)
+	t.Run("Middlewares", TestMux_Middlewares)
+	t.Run("Context", TestMux_Context)
+	t.Run("Walk", TestMux_Walk)
+	t.Run("Empty", TestMux_Empty)
+	t.Run("Subrouters", TestMux_Subrouters)
+	t.Run("SubrouterNotFound", TestMux_SubrouterNotFound)
+	t.Run("HeadOptions", TestMux_HeadOptions)
+	t.Run("When", TestMux_When)
+	t.Run("WhenFallback", TestMux_WhenFallback)
+	t.Run("MatchWhen", TestMux_MatchWhen)
+	t.Run("CheckRoute", TestMux_CheckRoute)
+	t.Run("URLFor", TestMux_URLFor)
+	t.Run("RouteTable", TestRouteTable)
+	t.Run("ParamTypes", TestParamTypes)
+	t.Run("FileServer", TestMux_FileServer)
+	t.Run("FileServerSPA", TestMux_FileServerSPA)
+}
+
+func TestRadixMatcher_Precedence(t *testing.T) {
+	for name, newMatcher := range map[string]func() RouteMatcher{
+		"chi":   NewChiMatcher,
+		"radix": NewRadixMatcher,
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := NewMuxMatcher(newMatcher())
+			r.Get("/users/new", writeString("new"))
+			r.Get("/users/{id:[0-9]+}", writeString("id"))
+			r.Get("/users/{name}", writeString("name"))
+			r.Get("/users/{name}/posts", writeString("posts"))
+			r.Get("/users/*", writeString("rest"))
+			r.Get("/files/{name}.{ext}", writeString("file"))
+			r.Post("/users/{id}/posts", writeString("post"))
+			r.Get("/{page}", writeString("page"))
+			r.Handle("/any/{x}", writeString("any"))
+
+			tests := []struct {
+				method, path, want string
+				status             int
+			}{
+				{http.MethodGet, "/users/new", "new", 200},
+				{http.MethodGet, "/users/42", "id", 200},
+				{http.MethodGet, "/users/bob", "name", 200},
+				{http.MethodGet, "/users/bob/posts", "posts", 200},
+				{http.MethodPost, "/users/bob/posts", "post", 200},
+				{http.MethodGet, "/users/bob/likes", "rest", 200},
+				{http.MethodGet, "/users/", "rest", 200},
+				{http.MethodGet, "/files/report.pdf", "file", 200},
+				{http.MethodGet, "/files/report", "", 404},
+				{http.MethodGet, "/about", "page", 200},
+				{http.MethodPut, "/any/1", "any", 200},
+				{http.MethodDelete, "/users/bob/posts", "", 405},
+			}
+			for _, tt := range tests {
+				rr, body := testRequest(t, r, tt.method, tt.path)
+				if rr.Code != tt.status {
+					t.Errorf("%s %s: got status %d, want %d", tt.method, tt.path, rr.Code, tt.status)
+					continue
+				}
+				if tt.want != "" && body != tt.want {
+					t.Errorf("%s %s: got %q, want %q", tt.method, tt.path, body, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package webkit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrInvalidRoute is the error of a routing pattern that can not be
+	// parsed, such as one with a missing '}' or a bad regexp.
+	ErrInvalidRoute = errors.New("webkit: invalid route")
+
+	// ErrRouteConflict is the error of a route that would shadow a route
+	// registered already, with the same method, the same conditions (see
+	// When) and an equivalent pattern (one that only differs by the names
+	// of its params).
+	ErrRouteConflict = errors.New("webkit: route conflict")
+)
+
+// RouteError describes a route that can not be registered. The Mux panics
+// with a *RouteError when a route is registered, so the mistake shows at
+// the call that made it; use CheckRoute to get it as an error instead.
+type RouteError struct {
+	Method   string
+	Pattern  string
+	Existing string // the pattern of the conflicting route, if any
+	Err      error
+}
+
+func (e *RouteError) Error() string {
+	if e.Existing != "" {
+		return fmt.Sprintf("%s: %s %s shadows %s %s", e.Err, e.Method, e.Pattern, e.Method, e.Existing)
+	}
+	return fmt.Sprintf("%s: %s %s", e.Err, e.Method, e.Pattern)
+}
+
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// registration records the routes registered for the methods, with mALL
+// for any method, of the routing patterns sharing a key
+type registration map[methodTyp]*routeEntry
+
+// routeEntry records the routes registered for a method and pattern
+type routeEntry struct {
+	pattern string
+
+	// handler is the route without conditions, if any
+	handler http.Handler
+
+	// matched dispatches the conditional routes (see When), if any
+	matched *matchHandler
+}
+
+// CheckRoute reports whether a route for the method ("*" for any method,
+// as with Handle) and pattern can be registered, returning a *RouteError
+// if it can not.
+func (mx *Mux) CheckRoute(method, pattern string) error {
+	m := mALL
+	if method != "*" {
+		var ok bool
+		if m, ok = methodMap[strings.ToUpper(method)]; !ok {
+			return &RouteError{Method: method, Pattern: pattern, Err: fmt.Errorf("%w: unsupported method", ErrInvalidRoute)}
+		}
+	}
+	_, err := mx.checkRoute(m, pattern)
+	return err
+}
+
+// checkRoute validates the route, and returns the key of its pattern
+func (mx *Mux) checkRoute(method methodTyp, pattern string) (string, error) {
+	key, err := patternKey(pattern)
+	if err != nil {
+		return "", &RouteError{Method: methodName(method), Pattern: pattern, Err: err}
+	}
+	e, ok := mx.registered[key][method&mALL]
+	if !ok {
+		return key, nil
+	}
+	if len(mx.matchers) == 0 {
+		if e.handler != nil {
+			return "", &RouteError{Method: methodName(method), Pattern: pattern, Existing: e.pattern, Err: ErrRouteConflict}
+		}
+		return key, nil
+	}
+	if e.matched != nil {
+		conds := matchersString(mx.matchers)
+		for _, rt := range e.matched.routes {
+			if rt.conds == conds {
+				return "", &RouteError{Method: methodName(method), Pattern: pattern + " [" + conds + "]", Existing: e.pattern, Err: ErrRouteConflict}
+			}
+		}
+	}
+	return key, nil
+}
+
+// register records the route, once it has been checked, and returns the
+// handler to add to the tree for it
+func (mx *Mux) register(key string, method methodTyp, pattern string, handler http.Handler) http.Handler {
+	reg, ok := mx.registered[key]
+	if !ok {
+		reg = registration{}
+		mx.registered[key] = reg
+	}
+	e, ok := reg[method&mALL]
+	if !ok {
+		e = &routeEntry{pattern: pattern}
+		reg[method&mALL] = e
+	}
+	if len(mx.matchers) == 0 {
+		e.handler = handler
+	} else {
+		if e.matched == nil {
+			e.matched = &matchHandler{mux: mx.root(), reg: reg, method: method & mALL}
+		}
+		e.matched.routes = append(e.matched.routes, matchRoute{
+			matchers: mx.matchers,
+			conds:    matchersString(mx.matchers),
+			handler:  handler,
+		})
+	}
+	if e.matched == nil {
+		return handler
+	}
+	return e.matched
+}
+
+// patternKey validates the routing pattern, and returns a key that is the
+// same for the patterns that only differ by the names of their params
+func patternKey(pattern string) (key string, err error) {
+	if len(pattern) == 0 || pattern[0] != '/' {
+		return "", fmt.Errorf("%w: routing pattern must begin with '/'", ErrInvalidRoute)
+	}
+
+	// the pattern parser reports errors by panicking
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			msg := strings.TrimPrefix(fmt.Sprint(rvr), "chi: ")
+			key, err = "", fmt.Errorf("%w: %s", ErrInvalidRoute, msg)
+		}
+	}()
+	patParamKeys(pattern)
+
+	var sb strings.Builder
+	for pat := pattern; ; {
+		typ, param, rexpat, _, ps, pe := patNextSegment(pat)
+		if typ == ntStatic {
+			sb.WriteString(pat)
+			break
+		}
+		sb.WriteString(pat[:ps])
+		pat = pat[pe:]
+		if typ != ntCatchAll && param == "" {
+			return "", fmt.Errorf("%w: route param name is missing", ErrInvalidRoute)
+		}
+		switch typ {
+		case ntParam:
+			sb.WriteString("{}")
+		case ntRegexp:
+			if _, err := regexp.Compile(rexpat); err != nil {
+				return "", fmt.Errorf("%w: invalid regexp pattern '%s' in route param", ErrInvalidRoute, rexpat)
+			}
+			sb.WriteString("{:" + rexpat + "}")
+		case ntCatchAll:
+			sb.WriteString("*")
+		}
+	}
+	return sb.String(), nil
+}
+
+// methodName returns the name of the method, or "*" for any method
+func methodName(method methodTyp) string {
+	if method&mALL == mALL {
+		return "*"
+	}
+	return methodTypString(method)
+}
@@ -0,0 +1,83 @@
+package webkit
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// registerPanic registers the route, and returns the error it panics with
+func registerPanic(fn func()) (err error) {
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			err, _ = rvr.(error)
+		}
+	}()
+	fn()
+	return nil
+}
+
+func TestMux_CheckRoute(t *testing.T) {
+	m := NewMux()
+	m.Get("/users/{id}", writeString(""))
+	m.Delete("/users/{name}", writeString(""))
+	m.Get("/users/{id:int}", writeString(""))
+	m.Get("/users/new", writeString(""))
+	m.Handle("/files/*", http.NotFoundHandler())
+	m.Get("/files/*", writeString(""))
+	m.Group(func(r Router) {
+		r.Post("/users", writeString(""))
+	})
+
+	cases := []struct {
+		method, pattern string
+		err             error
+		msg             string
+	}{
+		{"GET", "/users", nil, ""},
+		{"PUT", "/users/{id}", nil, ""},
+		{"GET", "/users/{id:uint}", nil, ""},
+		{"GET", "/users/{user}", ErrRouteConflict, "webkit: route conflict: GET /users/{user} shadows GET /users/{id}"},
+		{"delete", "/users/{id}", ErrRouteConflict, "webkit: route conflict: DELETE /users/{id} shadows DELETE /users/{name}"},
+		{"GET", "/users/{n:int}", ErrRouteConflict, ""},
+		{"POST", "/users", ErrRouteConflict, ""},
+		{"*", "/files/*", ErrRouteConflict, "webkit: route conflict: * /files/* shadows * /files/*"},
+		{"*", "/users/{id}", nil, ""},
+		{"GET", "users", ErrInvalidRoute, "webkit: invalid route: routing pattern must begin with '/': GET users"},
+		{"GET", "/users/{id", ErrInvalidRoute, "webkit: invalid route: route param closing delimiter '}' is missing: GET /users/{id"},
+		{"GET", "/files/*/x", ErrInvalidRoute, ""},
+		{"GET", "/a/{id}/b/{id}", ErrInvalidRoute, ""},
+		{"GET", "/a/{id:[0-9}", ErrInvalidRoute, ""},
+		{"GET", "/a/{}", ErrInvalidRoute, ""},
+		{"BREW", "/coffee", ErrInvalidRoute, ""},
+	}
+	for _, c := range cases {
+		err := m.CheckRoute(c.method, c.pattern)
+		if !errors.Is(err, c.err) {
+			t.Fatalf("%s %s: expected %v, got %v", c.method, c.pattern, c.err, err)
+		}
+		if c.msg != "" && err.Error() != c.msg {
+			t.Fatalf("%s %s: expected %q, got %q", c.method, c.pattern, c.msg, err.Error())
+		}
+	}
+
+	// registering panics with the *RouteError
+	err := registerPanic(func() { m.Get("/users/{user}", writeString("")) })
+	var re *RouteError
+	if !errors.As(err, &re) || re.Existing != "/users/{id}" || re.Method != "GET" {
+		t.Fatalf("expected a *RouteError, got %v", err)
+	}
+	err = registerPanic(func() { m.Route("/users/{id", func(r Router) {}) })
+	if !errors.Is(err, ErrInvalidRoute) || !strings.Contains(err.Error(), "/users/{id") {
+		t.Fatalf("expected ErrInvalidRoute, got %v", err)
+	}
+	if err = registerPanic(func() { m.With().Get("/users/{id:int}", writeString("")) }); !errors.Is(err, ErrRouteConflict) {
+		t.Fatalf("expected the inline mux to see the routes, got %v", err)
+	}
+
+	// the routes were not changed by the failed registrations
+	if w, _ := testRequest(t, m, "GET", "/users/bob"); w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
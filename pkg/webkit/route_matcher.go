@@ -0,0 +1,60 @@
+package webkit
+
+import "net/http"
+
+// RouteMatcher is the routing tree of a Mux, which stores the routes and
+// finds the route of a request path. There are two of them: the chi tree
+// (see NewChiMatcher) and the radix Tree (see NewRadixMatcher), which
+// route in the same way; NewMux uses the faster one, which is the chi tree
+// in the routing benchmarks (see bench_test.go).
+type RouteMatcher interface {
+	// insert adds the handler of the method and pattern, and the subroutes
+	// mounted on the pattern, if any
+	insert(method methodTyp, pattern string, handler http.Handler, subroutes Routes)
+
+	// find returns the handler of the method and path, and the subroutes
+	// of the route, recording the routing params and pattern in rctx. When
+	// the path has routes, but not for the method, it flags rctx with the
+	// methods allowed instead.
+	find(rctx *Context, method methodTyp, path string) (http.Handler, Routes)
+
+	// has reports whether a route has an equivalent pattern
+	has(pattern string) bool
+
+	// routes returns the routing information of the routes
+	routes() []Route
+
+	// fresh returns a new empty RouteMatcher of the same kind
+	fresh() RouteMatcher
+}
+
+// newDefaultMatcher returns the RouteMatcher of NewMux
+var newDefaultMatcher = NewChiMatcher
+
+// NewChiMatcher returns a RouteMatcher backed by the chi routing tree.
+func NewChiMatcher() RouteMatcher {
+	return &chiTreeNode{}
+}
+
+func (n *chiTreeNode) insert(method methodTyp, pattern string, handler http.Handler, subroutes Routes) {
+	rn := n.InsertRoute(method, pattern, handler)
+	if subroutes != nil {
+		rn.subroutes = subroutes
+	}
+}
+
+func (n *chiTreeNode) find(rctx *Context, method methodTyp, path string) (http.Handler, Routes) {
+	rn, _, h := n.FindRoute(rctx, method, path)
+	if rn == nil {
+		return nil, nil
+	}
+	return h, rn.subroutes
+}
+
+func (n *chiTreeNode) has(pattern string) bool {
+	return n.findPattern(pattern)
+}
+
+func (n *chiTreeNode) fresh() RouteMatcher {
+	return &chiTreeNode{}
+}
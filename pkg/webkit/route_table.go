@@ -0,0 +1,137 @@
+package webkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a route of a router, as listed by NewRouteTable.
+// The Conditions are those of a route registered with When, and are empty
+// for a route without conditions.
+type RouteInfo struct {
+	Method      string   `json:"method"`
+	Pattern     string   `json:"pattern"`
+	Conditions  string   `json:"conditions,omitempty"`
+	Handler     string   `json:"handler"`
+	Middlewares []string `json:"middlewares"`
+}
+
+// RouteTable is the list of the routes of a router, sorted by pattern
+// and method. The routes of a method and pattern with conditions are
+// listed in the order they are tried.
+type RouteTable []RouteInfo
+
+// NewRouteTable walks the router and returns its routes (see Walk).
+func NewRouteTable(r Routes) RouteTable {
+	var rt RouteTable
+	Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		mh, ok := handler.(*matchHandler)
+		if !ok {
+			rt = append(rt, newRouteInfo(method, route, "", handler, middlewares))
+			return nil
+		}
+		mh.each(func(conds string, handler http.Handler) {
+			rt = append(rt, newRouteInfo(method, route, conds, handler, middlewares))
+		})
+		return nil
+	})
+	sort.SliceStable(rt, func(i, j int) bool {
+		if rt[i].Pattern != rt[j].Pattern {
+			return rt[i].Pattern < rt[j].Pattern
+		}
+		return rt[i].Method < rt[j].Method
+	})
+	return rt
+}
+
+// newRouteInfo describes the route, unwrapping the handler of a route
+// with inline middlewares
+func newRouteInfo(method, route, conds string, handler http.Handler, middlewares []func(http.Handler) http.Handler) RouteInfo {
+	if chain, ok := handler.(*ChainHandler); ok {
+		middlewares = append(middlewares[:len(middlewares):len(middlewares)], chain.Middlewares...)
+		handler = chain.Endpoint
+	}
+	mws := make([]string, 0, len(middlewares))
+	for _, mw := range middlewares {
+		mws = append(mws, funcName(mw))
+	}
+	return RouteInfo{
+		Method:      method,
+		Pattern:     route,
+		Conditions:  conds,
+		Handler:     handlerName(handler),
+		Middlewares: mws,
+	}
+}
+
+// WriteText writes the routes to w as a table, with a line per route.
+func (rt RouteTable) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tCONDITIONS\tHANDLER\tMIDDLEWARES")
+	for _, r := range rt {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Pattern, r.Conditions, r.Handler, strings.Join(r.Middlewares, ", "))
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the routes to w as a JSON array.
+func (rt RouteTable) WriteJSON(w io.Writer) error {
+	if rt == nil {
+		rt = RouteTable{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rt)
+}
+
+// RoutesHandler returns a debug handler that lists the routes of the
+// router, as a text table, or as JSON when the request has the query
+// format=json or accepts application/json. It can be mounted on the router
+// it lists, as in:
+//
+//	mux.Mount("/debug/routes", webkit.RoutesHandler(mux))
+//
+// Since the routes are listed for every request, it also lists the routes
+// registered after it.
+func RoutesHandler(r Routes) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rt := NewRouteTable(r)
+		if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			rt.WriteJSON(w)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rt.WriteText(w)
+	})
+}
+
+// handlerName returns the name of the function of a http.HandlerFunc,
+// or the type of any other http.Handler
+func handlerName(h http.Handler) string {
+	if fn, ok := h.(http.HandlerFunc); ok {
+		return funcName(fn)
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+// funcName returns the name of the function, trimmed to the package
+// name, as in "webkit.listUsers"
+func funcName(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Sprintf("%T", fn)
+	}
+	name := runtime.FuncForPC(v.Pointer()).Name()
+	if n := strings.LastIndexByte(name, '/'); n >= 0 {
+		name = name[n+1:]
+	}
+	return name
+}
@@ -0,0 +1,94 @@
+package webkit
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func listUsers(w http.ResponseWriter, r *http.Request) {}
+
+func getUser(w http.ResponseWriter, r *http.Request) {}
+
+func noopMiddleware(next http.Handler) http.Handler { return next }
+
+type staticHandler struct{}
+
+func (staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
+
+func TestRouteTable(t *testing.T) {
+	m := NewMux()
+	m.Use(noopMiddleware)
+	m.Get("/users", listUsers)
+	m.Route("/users/{id}", func(r Router) {
+		r.With(noopMiddleware).Get("/", getUser)
+	})
+	m.Method("GET", "/static", staticHandler{})
+	m.Mount("/debug/routes", RoutesHandler(m))
+
+	rt := NewRouteTable(m)
+	var got []string
+	for _, r := range rt {
+		if strings.HasPrefix(r.Pattern, "/debug") {
+			continue
+		}
+		got = append(got, r.Method+" "+r.Pattern+" "+r.Handler+" "+strings.Join(r.Middlewares, ","))
+	}
+	want := []string{
+		"GET /static webkit.staticHandler webkit.noopMiddleware",
+		"GET /users webkit.listUsers webkit.noopMiddleware",
+		"GET /users/{id}/ webkit.getUser webkit.noopMiddleware,webkit.noopMiddleware",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	var sb strings.Builder
+	if err := rt.WriteText(&sb); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != len(rt)+1 || !strings.HasPrefix(lines[0], "METHOD") || !strings.Contains(sb.String(), "webkit.listUsers") {
+		t.Fatalf("bad text table\n%s", sb.String())
+	}
+
+	// the debug handler
+	w, body := testRequest(t, m, "GET", "/debug/routes?format=json")
+	var listed []RouteInfo
+	if err := json.Unmarshal([]byte(body), &listed); err != nil || len(listed) != len(rt) {
+		t.Fatalf("expected %d routes, got %v, %v", len(rt), listed, err)
+	}
+	if w.Header().Get("Content-Type") != "application/json; charset=utf-8" || !reflect.DeepEqual(RouteTable(listed), rt) {
+		t.Fatalf("bad json listing %s", body)
+	}
+	if w, body = testRequest(t, m, "GET", "/debug/routes"); !strings.HasPrefix(body, "METHOD") || w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Fatalf("bad text listing %q", body)
+	}
+
+	// the conditional routes are listed in the order they are tried
+	m = NewMux()
+	m.When(MatchHeader("Accept-Version", "2")).With(noopMiddleware).Get("/users/{id}", getUser)
+	m.When(MatchHost("a.example.com")).Get("/users/{id}", getUser)
+	m.Handle("/users/{id}", http.HandlerFunc(listUsers))
+	got = got[:0]
+	for _, r := range NewRouteTable(m) {
+		if r.Method == "GET" {
+			got = append(got, r.Pattern+" ["+r.Conditions+"] "+r.Handler+" "+strings.Join(r.Middlewares, ","))
+		}
+	}
+	want = []string{
+		"/users/{id} [header:Accept-Version=2] webkit.getUser webkit.noopMiddleware",
+		"/users/{id} [host=a.example.com] webkit.getUser ",
+		"/users/{id} [] webkit.listUsers ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	sb.Reset()
+	if err := NewRouteTable(NewMux()).WriteJSON(&sb); err != nil || strings.TrimSpace(sb.String()) != "[]" {
+		t.Fatalf("expected an empty list, got %q, %v", sb.String(), err)
+	}
+}
@@ -2,6 +2,7 @@ package webkit
 
 import (
 	"context"
+	"io/fs"
 	"log"
 	"net/http"
 )
@@ -12,9 +13,35 @@ type Router interface {
 	// A Handler responds to an HTTP request.
 	http.Handler
 
+	// Routes is the routing information of the Router.
+	Routes
+
 	// Use appends one or more middlewares onto the Router stack.
 	Use(middlewares ...func(http.Handler) http.Handler)
 
+	// With adds inline middlewares for an endpoint handler.
+	With(middlewares ...func(http.Handler) http.Handler) Router
+
+	// Named adds a new inline-Router that names its routes for URLFor.
+	Named(name string) Router
+
+	// When adds a new inline-Router whose routes are only served to the
+	// requests the matchers match.
+	When(matchers ...Matcher) Router
+
+	// Group adds a new inline-Router along the current routing
+	// path, with a fresh middleware stack for the inline-Router.
+	Group(fn func(r Router)) Router
+
+	// Route mounts a sub-Router along a `pattern` string.
+	Route(pattern string, fn func(r Router)) Router
+
+	// Mount attaches another http.Handler along ./pattern/*
+	Mount(pattern string, h http.Handler)
+
+	// FileServer serves the files of a fs.FS along ./pattern/*
+	FileServer(pattern string, fsys fs.FS, opts FileServerOptions)
+
 	// Handle and HandleFunc adds routes for `pattern` that matches
 	// all HTTP methods.
 	Handle(pattern string, h http.Handler)
@@ -25,6 +52,15 @@ type Router interface {
 	Method(method, pattern string, h http.Handler)
 	MethodFunc(method, pattern string, h http.HandlerFunc)
 
+	// HTTP-method routing along `pattern`
+	Get(pattern string, h http.HandlerFunc)
+	Post(pattern string, h http.HandlerFunc)
+	Put(pattern string, h http.HandlerFunc)
+	Patch(pattern string, h http.HandlerFunc)
+	Delete(pattern string, h http.HandlerFunc)
+	Head(pattern string, h http.HandlerFunc)
+	Options(pattern string, h http.HandlerFunc)
+
 	// NotFound defines a handler to respond whenever a route could
 	// not be found.
 	NotFound(h http.HandlerFunc)
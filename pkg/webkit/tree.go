@@ -366,12 +366,10 @@ func (t *Tree) deletePrefixRecursive(parent, n *node, prefix string) int {
 func recursiveWalk(n *node, fn WalkFn) bool {
 	// Visit the leaf values, if there are any
 	if n.leaf != nil && fn(n.leaf.key, n.leaf.val) {
-		fmt.Printf("[leaf] prefix=%q, key=%q, val=%+v\n", n.prefix, n.leaf.key, n.leaf.val)
 		return true
 	}
 	// Recurse on the children...
 	for _, e := range n.edges {
-		fmt.Printf("[node] prefix=%q\n", e.node.prefix)
 		if recursiveWalk(e.node, fn) {
 			return true
 		}
@@ -0,0 +1,110 @@
+package webkit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrUnknownRoute is returned by URLFor when no route has the name.
+var ErrUnknownRoute = errors.New("webkit: unknown route name")
+
+// URLFor builds the path of the route with the name, registered on the
+// router serving the request, using the key and value pairs in params.
+// See Mux.URLFor.
+func URLFor(r *http.Request, name string, params ...string) (string, error) {
+	if rctx := RouteContext(r.Context()); rctx != nil {
+		if mx, ok := rctx.Routes.(*Mux); ok {
+			return mx.URLFor(name, params...)
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownRoute, name)
+}
+
+// URLFor builds the path of the route with the name (see Named), using the
+// key and value pairs in params to replace the url params of the routing
+// pattern, as in URLFor("user", "id", "42"). The routes of the mounted
+// subrouters are found too. Every url param must be given a value that
+// matches its regexp or param type, and the values are escaped. The value
+// for a wildcard, given with the key "*", is optional and is not escaped.
+func (mx *Mux) URLFor(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("%w: odd number of params for route %q", ErrBadParam, name)
+	}
+	pattern, ok := mx.namedPattern(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownRoute, name)
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+	return buildPath(pattern, values)
+}
+
+// namedPattern returns the full routing pattern of the route with the name,
+// searching the mounted subrouters too
+func (mx *Mux) namedPattern(name string) (string, bool) {
+	if pattern, ok := mx.names[name]; ok {
+		return pattern, true
+	}
+	for _, r := range mx.tree.routes() {
+		subMux, ok := r.SubRoutes.(*Mux)
+		if !ok {
+			continue
+		}
+		pattern, ok := subMux.namedPattern(name)
+		if !ok {
+			continue
+		}
+		prefix := strings.TrimSuffix(r.Pattern, "/*")
+		if pattern == "/" && prefix != "" {
+			return prefix, true
+		}
+		return prefix + pattern, true
+	}
+	return "", false
+}
+
+// buildPath replaces the url params of the pattern with the values
+func buildPath(pattern string, values map[string]string) (string, error) {
+	var sb strings.Builder
+	keys := make(map[string]bool, len(values))
+	for pat := pattern; ; {
+		typ, key, rexpat, _, ps, pe := patNextSegment(pat)
+		if typ == ntStatic {
+			sb.WriteString(pat)
+			break
+		}
+		sb.WriteString(pat[:ps])
+		pat = pat[pe:]
+
+		value := values[key]
+		keys[key] = true
+		if typ == ntCatchAll {
+			sb.WriteString(strings.TrimPrefix(value, "/"))
+			continue
+		}
+		if value == "" {
+			return "", fmt.Errorf("%w: %q for %q", ErrMissingParam, key, pattern)
+		}
+		if rexpat != "" {
+			rex, err := regexp.Compile(rexpat)
+			if err != nil || !rex.MatchString(value) {
+				return "", fmt.Errorf("%w: %q does not match %q: %q", ErrBadParam, key, rexpat, value)
+			}
+		} else if strings.IndexByte(value, '/') != -1 {
+			return "", fmt.Errorf("%w: %q contains a '/': %q", ErrBadParam, key, value)
+		}
+		sb.WriteString(url.PathEscape(value))
+	}
+	for key := range values {
+		if !keys[key] {
+			return "", fmt.Errorf("%w: %q is not in %q", ErrBadParam, key, pattern)
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,75 @@
+package webkit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestMux_URLFor(t *testing.T) {
+	m := NewMux()
+	m.Named("home").Get("/", writeString(""))
+	m.Named("users").Get("/users", writeString(""))
+	m.Named("users").Post("/users", writeString(""))
+	m.Named("user").Get("/users/{id:int}", writeString(""))
+	m.Named("search").Get("/search/{term}", writeString(""))
+	m.Route("/orgs/{org:slug}", func(r Router) {
+		r.Named("org").Get("/", writeString(""))
+		r.Route("/teams", func(r Router) {
+			r.Named("team").Get("/{team}", writeString(""))
+		})
+	})
+	m.Named("files").Get("/files/*", writeString(""))
+	m.Get("/link/{id}", func(w http.ResponseWriter, r *http.Request) {
+		u, err := URLFor(r, "team", "org", "acme", "team", URLParam(r, "id"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, u)
+	})
+
+	cases := []struct {
+		name   string
+		params []string
+		path   string
+		err    error
+	}{
+		{"home", nil, "/", nil},
+		{"users", nil, "/users", nil},
+		{"user", []string{"id", "42"}, "/users/42", nil},
+		{"user", []string{"id", "bob"}, "", ErrBadParam},
+		{"user", nil, "", ErrMissingParam},
+		{"user", []string{"id"}, "", ErrBadParam},
+		{"user", []string{"id", "42", "name", "bob"}, "", ErrBadParam},
+		{"search", []string{"term", "a b?"}, "/search/a%20b%3F", nil},
+		{"search", []string{"term", "a/b"}, "", ErrBadParam},
+		{"org", []string{"org", "acme"}, "/orgs/acme", nil},
+		{"org", []string{"org", "Acme"}, "", ErrBadParam},
+		{"team", []string{"org", "acme", "team", "core"}, "/orgs/acme/teams/core", nil},
+		{"files", []string{"*", "/css/site.css"}, "/files/css/site.css", nil},
+		{"files", nil, "/files/", nil},
+		{"nope", nil, "", ErrUnknownRoute},
+	}
+	for _, c := range cases {
+		path, err := m.URLFor(c.name, c.params...)
+		if path != c.path || !errors.Is(err, c.err) {
+			t.Fatalf("%s %v: expected %q, %v, got %q, %v", c.name, c.params, c.path, c.err, path, err)
+		}
+		if err == nil {
+			if w, _ := testRequest(t, m, "GET", path); w.Code != 200 {
+				t.Fatalf("%s: %q is not routed, got %d", c.name, path, w.Code)
+			}
+		}
+	}
+	if _, body := testRequest(t, m, "GET", "/link/core"); body != "/orgs/acme/teams/core" {
+		t.Fatalf("expected the url from the request, got %q", body)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for a duplicate route name")
+		}
+	}()
+	m.Named("user").Get("/people/{id}", writeString(""))
+}